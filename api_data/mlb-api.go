@@ -6,6 +6,8 @@ package api_data
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"time"
 )
@@ -72,16 +74,16 @@ type Schedule struct {
 type Game struct {
 	GamePk uint32 `json:"gamePk"`
 	// GameGUID               string    `json:"gameGuid"`
-	Link string `json:"link"`
-	// GameType               string    `json:"gameType"`
+	Link     string `json:"link"`
+	GameType string `json:"gameType"`
 	// Season                 string    `json:"season"`
 	// GameDate               time.Time `json:"gameDate"`
 	// OfficialDate           string    `json:"officialDate"`
 	// Status                 Status    `json:"status"`
-	// Teams                  Teams     `json:"teams"`
+	Teams TeamScheduleTeams `json:"teams"`
 	// Venue                  Venue     `json:"venue"`
 	// Content                Content   `json:"content"`
-	// IsTie                  bool      `json:"isTie"`
+	IsTie bool `json:"isTie"`
 	// GameNumber             int       `json:"gameNumber"`
 	// PublicFacing           bool      `json:"publicFacing"`
 	// DoubleHeader           string    `json:"doubleHeader"`
@@ -90,12 +92,12 @@ type Game struct {
 	// CalendarEventID        string    `json:"calendarEventID"`
 	// SeasonDisplay          string    `json:"seasonDisplay"`
 	// DayNight               string    `json:"dayNight"`
-	// ScheduledInnings       int       `json:"scheduledInnings"`
-	// ReverseHomeAwayStatus  bool      `json:"reverseHomeAwayStatus"`
+	ScheduledInnings      uint8 `json:"scheduledInnings"`
+	ReverseHomeAwayStatus bool  `json:"reverseHomeAwayStatus"`
 	// InningBreakLength      int       `json:"inningBreakLength"`
 	// GamesInSeries          int       `json:"gamesInSeries"`
 	// SeriesGameNumber       int       `json:"seriesGameNumber"`
-	// SeriesDescription      string    `json:"seriesDescription"`
+	SeriesDescription string `json:"seriesDescription"`
 	// RecordSource           string    `json:"recordSource"`
 	// IfNecessary            string    `json:"ifNecessary"`
 	// IfNecessaryDescription string    `json:"ifNecessaryDescription"`
@@ -116,6 +118,29 @@ func (lg *LiveGame) FromJSON(r io.Reader) error {
 	return e.Decode(lg)
 }
 
+// check that a decoded live game has the fields needed to build a game
+// decoding silently zero-values missing or retyped fields, so this catches payloads that are unusable
+func (lg *LiveGame) Validate() error {
+	var problems []error
+	if lg.GamePk == 0 {
+		problems = append(problems, errors.New("missing gamePk"))
+	}
+	if lg.GameData.Status.AbstractGameState == "" {
+		problems = append(problems, errors.New("missing gameData.status.abstractGameState"))
+	}
+	if lg.GameData.Teams.Away.Name == "" {
+		problems = append(problems, errors.New("missing gameData.teams.away.name"))
+	}
+	if lg.GameData.Teams.Home.Name == "" {
+		problems = append(problems, errors.New("missing gameData.teams.home.name"))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("live game payload is unusable: %w", errors.Join(problems...))
+	}
+	return nil
+}
+
 type LiveGame struct {
 	GamePk   int      `json:"gamePk"`
 	GameData GameData `json:"gameData"`
@@ -127,6 +152,11 @@ type Datetime struct {
 	// OfficialDate string    `json:"officialDate"`
 	// DayNight     string    `json:"dayNight"`
 }
+type GameDatetime struct {
+	DateTime time.Time `json:"dateTime"`
+	// when a delayed or suspended game is expected to resume, zero when unknown
+	ResumeDateTime time.Time `json:"resumeDateTime"`
+}
 type Status2 struct {
 	AbstractGameState string `json:"abstractGameState"`
 	DetailedState     string `json:"detailedState"`
@@ -144,9 +174,13 @@ type Teams2 struct {
 	Home Team2 `json:"home"`
 }
 type PlayerNamed struct {
-	ID            uint32 `json:"id"`
-	FullName      string `json:"fullName"`
-	PrimaryNumber string `json:"primaryNumber"`
+	ID            uint32    `json:"id"`
+	FullName      string    `json:"fullName"`
+	PrimaryNumber string    `json:"primaryNumber"`
+	PitchHand     PitchHand `json:"pitchHand"`
+}
+type PitchHand struct {
+	Code string `json:"code"`
 }
 type Away struct {
 	ID       uint32 `json:"id"`
@@ -163,11 +197,19 @@ type ProbablePitchers struct {
 	Home PlayerID `json:"home"`
 }
 type GameData struct {
-	Datetime         Datetime               `json:"datetime"`
+	Datetime         GameDatetime           `json:"datetime"`
 	Status           Status2                `json:"status"`
 	Teams            Teams2                 `json:"teams"`
 	Players          map[string]PlayerNamed `json:"players"`
 	ProbablePitchers ProbablePitchers       `json:"probablePitchers"`
+	GameInfo         GameInfo               `json:"gameInfo"`
+	Weather          Weather                `json:"weather"`
+}
+type GameInfo struct {
+	Attendance uint32 `json:"attendance"`
+}
+type Weather struct {
+	Temp string `json:"temp"`
 }
 type TeamName2 struct {
 	Name string `json:"name"`
@@ -190,6 +232,7 @@ type Offense struct {
 type Linescore struct {
 	CurrentInning uint8   `json:"currentInning"`
 	InningHalf    string  `json:"inningHalf"`
+	InningState   string  `json:"inningState"`
 	Teams         Teams3  `json:"teams"`
 	Defense       Defense `json:"defense"`
 	Offense       Offense `json:"offense"`
@@ -202,6 +245,94 @@ type Decisions struct {
 type LiveData struct {
 	Linescore Linescore `json:"linescore"`
 	Decisions Decisions `json:"decisions"`
+	Boxscore  Boxscore  `json:"boxscore"`
+	Plays     Plays     `json:"plays"`
+}
+type Plays struct {
+	CurrentPlay CurrentPlay `json:"currentPlay"`
+	AllPlays    []Play      `json:"allPlays"`
+}
+type Play struct {
+	About      PlayAbout   `json:"about"`
+	Result     PlayResult  `json:"result"`
+	Matchup    PlayMatchup `json:"matchup"`
+	PlayEvents []PlayEvent `json:"playEvents"`
+}
+type PlayAbout struct {
+	Inning     uint8  `json:"inning"`
+	HalfInning string `json:"halfInning"`
+	IsComplete bool   `json:"isComplete"`
+}
+type PlayResult struct {
+	EventType string `json:"eventType"`
+}
+type PlayMatchup struct {
+	Batter  PlayerID `json:"batter"`
+	Pitcher PlayerID `json:"pitcher"`
+}
+type CurrentPlay struct {
+	ReviewDetails ReviewDetails `json:"reviewDetails"`
+	PlayEvents    []PlayEvent   `json:"playEvents"`
+}
+type ReviewDetails struct {
+	InProgress bool `json:"inProgress"`
+}
+type PlayEvent struct {
+	Details PlayEventDetails `json:"details"`
+	IsPitch bool             `json:"isPitch"`
+}
+type PlayEventDetails struct {
+	EventType string `json:"eventType"`
+}
+type Boxscore struct {
+	Teams     BoxscoreTeams  `json:"teams"`
+	Officials []Official     `json:"officials"`
+	Info      []BoxscoreInfo `json:"info"`
+}
+type BoxscoreInfo struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+type Official struct {
+	Official     OfficialPerson `json:"official"`
+	OfficialType string         `json:"officialType"`
+}
+type OfficialPerson struct {
+	FullName string `json:"fullName"`
+}
+type BoxscoreTeams struct {
+	Away BoxscoreTeam `json:"away"`
+	Home BoxscoreTeam `json:"home"`
+}
+type BoxscoreTeam struct {
+	Players map[string]BoxscorePlayer `json:"players"`
+}
+type BoxscorePlayer struct {
+	Person       PlayerID          `json:"person"`
+	Stats        PlayerStats       `json:"stats"`
+	SeasonStats  PlayerSeasonStats `json:"seasonStats"`
+	BattingOrder string            `json:"battingOrder"`
+	Position     Position          `json:"position"`
+}
+type Position struct {
+	Abbreviation string `json:"abbreviation"`
+}
+type PlayerSeasonStats struct {
+	Pitching SeasonPitchingStats `json:"pitching"`
+}
+type SeasonPitchingStats struct {
+	Wins   uint16 `json:"wins"`
+	Losses uint16 `json:"losses"`
+}
+type PlayerStats struct {
+	Pitching PitchingStats `json:"pitching"`
+	Batting  BattingStats  `json:"batting"`
+}
+type BattingStats struct {
+	Summary string `json:"summary"`
+}
+type PitchingStats struct {
+	PitchesThrown uint16 `json:"pitchesThrown"`
 }
 type Teams3 struct {
 	Home Team3 `json:"home"`
@@ -209,4 +340,141 @@ type Teams3 struct {
 }
 type Team3 struct {
 	Runs uint8 `json:"runs"`
+	Hits uint8 `json:"hits"`
+}
+
+// response to live game endpoint, limited to the boxscore totals
+func (lb *LiveBoxscore) FromJSON(r io.Reader) error {
+	e := json.NewDecoder(r)
+	return e.Decode(lb)
+}
+
+type LiveBoxscore struct {
+	GamePk   int              `json:"gamePk"`
+	GameData BoxscoreGameData `json:"gameData"`
+	LiveData BoxscoreLiveData `json:"liveData"`
+}
+type BoxscoreGameData struct {
+	Status Status2 `json:"status"`
+}
+type BoxscoreLiveData struct {
+	Boxscore BoxscoreTotals `json:"boxscore"`
+}
+type BoxscoreTotals struct {
+	Teams BoxscoreTotalsTeams `json:"teams"`
+}
+type BoxscoreTotalsTeams struct {
+	Away BoxscoreTotalsTeam `json:"away"`
+	Home BoxscoreTotalsTeam `json:"home"`
+}
+type BoxscoreTotalsTeam struct {
+	Team      TeamName2 `json:"team"`
+	TeamStats TeamStats `json:"teamStats"`
+}
+type TeamStats struct {
+	Batting  BattingTotals  `json:"batting"`
+	Fielding FieldingTotals `json:"fielding"`
+}
+type BattingTotals struct {
+	Runs       uint16 `json:"runs"`
+	Hits       uint16 `json:"hits"`
+	LeftOnBase uint16 `json:"leftOnBase"`
+}
+type FieldingTotals struct {
+	Errors uint16 `json:"errors"`
+}
+
+// response to live feed endpoint, narrowed to the scoring plays
+func (ls *LiveScoring) FromJSON(r io.Reader) error {
+	e := json.NewDecoder(r)
+	return e.Decode(ls)
+}
+
+type LiveScoring struct {
+	GamePk   int              `json:"gamePk"`
+	GameData BoxscoreGameData `json:"gameData"`
+	LiveData ScoringLiveData  `json:"liveData"`
+}
+type ScoringLiveData struct {
+	Plays ScoringPlays `json:"plays"`
+}
+type ScoringPlays struct {
+	// indices into allPlays of the plays on which runs scored
+	ScoringPlays []int         `json:"scoringPlays"`
+	AllPlays     []ScoringPlay `json:"allPlays"`
+}
+type ScoringPlay struct {
+	About  ScoringPlayAbout  `json:"about"`
+	Result ScoringPlayResult `json:"result"`
+}
+type ScoringPlayAbout struct {
+	Inning     uint8  `json:"inning"`
+	HalfInning string `json:"halfInning"`
+}
+type ScoringPlayResult struct {
+	Description string `json:"description"`
+	AwayScore   uint8  `json:"awayScore"`
+	HomeScore   uint8  `json:"homeScore"`
+}
+
+// response to standings endpoint
+func (s *Standings) FromJSON(r io.Reader) error {
+	e := json.NewDecoder(r)
+	return e.Decode(s)
+}
+
+type Standings struct {
+	Records []StandingsRecord `json:"records"`
+}
+type StandingsRecord struct {
+	League      TeamName2    `json:"league"`
+	Division    TeamName2    `json:"division"`
+	TeamRecords []TeamRecord `json:"teamRecords"`
+}
+type TeamRecord struct {
+	Team              TeamName2 `json:"team"`
+	Wins              uint16    `json:"wins"`
+	Losses            uint16    `json:"losses"`
+	WinningPercentage string    `json:"winningPercentage"`
+	GamesBack         string    `json:"gamesBack"`
+	DivisionRank      string    `json:"divisionRank"`
+	Streak            Streak    `json:"streak"`
+}
+type Streak struct {
+	StreakCode string `json:"streakCode"`
+}
+
+// response to schedule endpoint when queried for one team over a date range, or for every team on a date
+func (ts *TeamSchedule) FromJSON(r io.Reader) error {
+	e := json.NewDecoder(r)
+	return e.Decode(ts)
+}
+
+type TeamSchedule struct {
+	Dates []TeamScheduleDate `json:"dates"`
+}
+type TeamScheduleDate struct {
+	Games []TeamScheduleGame `json:"games"`
+}
+type TeamScheduleGame struct {
+	GamePk   uint32             `json:"gamePk"`
+	GameDate time.Time          `json:"gameDate"`
+	Status   TeamScheduleStatus `json:"status"`
+	Teams    TeamScheduleTeams  `json:"teams"`
+}
+type TeamScheduleStatus struct {
+	AbstractGameState string `json:"abstractGameState"`
+	DetailedState     string `json:"detailedState"`
+}
+type TeamScheduleTeams struct {
+	Away TeamScheduleTeam `json:"away"`
+	Home TeamScheduleTeam `json:"home"`
+}
+type TeamScheduleTeam struct {
+	Team  TeamScheduleInfo `json:"team"`
+	Score uint8            `json:"score"`
+}
+type TeamScheduleInfo struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
 }
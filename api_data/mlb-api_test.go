@@ -0,0 +1,23 @@
+package api_data
+
+import (
+	"strings"
+	"testing"
+)
+
+// decoding and validating arbitrary responses, e.g. truncated or from a proxy's error page, should never panic
+// the seed corpus is in testdata/fuzz/FuzzLiveGameFromJSON
+func FuzzLiveGameFromJSON(f *testing.F) {
+	f.Fuzz(func(t *testing.T, payload string) {
+		lg := LiveGame{}
+		if err := lg.FromJSON(strings.NewReader(payload)); err != nil {
+			return
+		}
+		if err := lg.Validate(); err != nil {
+			return
+		}
+		if lg.GamePk == 0 || lg.GameData.Teams.Away.Name == "" || lg.GameData.Teams.Home.Name == "" {
+			t.Errorf("validated a live game missing required fields: %+v", lg.GameData)
+		}
+	})
+}
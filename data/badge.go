@@ -0,0 +1,105 @@
+package data
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// the label on the left of every badge
+const badgeLabel = "MLB"
+
+// badge colors for each game status, and for teams without a game
+var badgeColors = map[GameStatus]string{
+	StatusLive:    "#4c1",
+	StatusPreview: "#007ec6",
+	StatusFinal:   "#555",
+}
+
+const badgeNoGameColor = "#9f9f9f"
+
+// pick a team's game to show on a badge, by abbreviation (case-insensitive)
+// a live game comes first, then the next game to start, then the last to finish, nil if the team has no game
+func (g *Games) ForBadge(team string) *Game {
+	var next, last *Game
+	for _, game := range g.Data {
+		teams := game.State.Teams
+		if !strings.EqualFold(teams.Away.Info.Abbreviation, team) && !strings.EqualFold(teams.Home.Info.Abbreviation, team) {
+			continue
+		}
+		switch game.State.Status.General {
+		case StatusLive:
+			return game
+		case StatusPreview:
+			if next == nil || game.State.Status.StartTime.DateTime.Before(next.State.Status.StartTime.DateTime) {
+				next = game
+			}
+		case StatusFinal:
+			if last == nil || game.State.Status.StartTime.DateTime.After(last.State.Status.StartTime.DateTime) {
+				last = game
+			}
+		}
+	}
+	if next != nil {
+		return next
+	}
+	return last
+}
+
+// the text shown on a badge for a game, e.g. "NYY 3 - 2 BOS · Top 5th"
+func badgeMessage(game *Game) string {
+	if game == nil {
+		return "no game today"
+	}
+	s := game.State
+	away, home := s.Teams.Away, s.Teams.Home
+	switch s.Status.General {
+	case StatusLive:
+		message := fmt.Sprintf("%s %d - %d %s", away.Info.Abbreviation, away.Score, home.Score, home.Info.Abbreviation)
+		if s.Inning.InningLabel != "" {
+			message += " · " + s.Inning.InningLabel
+		}
+		return message
+	case StatusFinal:
+		return fmt.Sprintf("%s %d - %d %s · %s", away.Info.Abbreviation, away.Score, home.Score, home.Info.Abbreviation, s.Status.Detailed)
+	default:
+		return fmt.Sprintf("%s @ %s · %s", away.Info.Abbreviation, home.Info.Abbreviation, s.Status.Detailed)
+	}
+}
+
+// roughly how wide text is drawn on a badge, in pixels
+func badgeTextWidth(text string) int {
+	return utf8.RuneCountInString(text)*7 + 10
+}
+
+// write a badge for a game as SVG, or one saying the team has no game if game is nil
+func BadgeSVG(w io.Writer, game *Game) error {
+	message := badgeMessage(game)
+	color := badgeNoGameColor
+	if game != nil {
+		if statusColor, exists := badgeColors[game.State.Status.General]; exists {
+			color = statusColor
+		}
+	}
+
+	var escaped strings.Builder
+	if err := xml.EscapeText(&escaped, []byte(message)); err != nil {
+		return err
+	}
+
+	labelWidth := badgeTextWidth(badgeLabel)
+	messageWidth := badgeTextWidth(message)
+	width := labelWidth + messageWidth
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">`+
+		`<title>%[2]s: %[3]s</title>`+
+		`<rect width="%[4]d" height="20" fill="#555"/>`+
+		`<rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[7]d" y="14">%[2]s</text>`+
+		`<text x="%[8]d" y="14">%[3]s</text>`+
+		`</g></svg>`,
+		width, badgeLabel, escaped.String(), labelWidth, messageWidth, color, labelWidth/2, labelWidth+messageWidth/2)
+	return err
+}
@@ -0,0 +1,43 @@
+package data
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBadgeShowsLiveScore(t *testing.T) {
+	teams := func(away, home string, awayScore, homeScore uint8) Teams {
+		return Teams{
+			Away: Team{Info: Info{Abbreviation: away}, Score: awayScore},
+			Home: Team{Info: Info{Abbreviation: home}, Score: homeScore},
+		}
+	}
+	start := time.Date(2024, 7, 4, 17, 5, 0, 0, time.UTC)
+	games := &Games{Data: []*Game{
+		{ID: 1, State: State{Teams: teams("BOS", "NYY", 1, 4), Status: Status{General: StatusFinal, Detailed: "Final", StartTime: api_data.Datetime{DateTime: start}}}},
+		{ID: 2, State: State{Teams: teams("BOS", "NYY", 3, 2), Status: Status{General: StatusLive, Detailed: "In Progress", StartTime: api_data.Datetime{DateTime: start.Add(5 * time.Hour)}}, Inning: Inning{Number: 5, InningLabel: "Top 5th"}}},
+		{ID: 3, State: State{Teams: teams("LAD", "SF", 0, 0), Status: Status{General: StatusPreview, Detailed: "Scheduled"}}},
+	}}
+
+	game := games.ForBadge("nyy")
+	if assert.NotNil(t, game) {
+		assert.Equal(t, uint32(2), game.ID, "a live game should be shown over a finished one")
+	}
+
+	var svg bytes.Buffer
+	assert.NoError(t, BadgeSVG(&svg, game))
+	assert.True(t, bytes.HasPrefix(svg.Bytes(), []byte("<svg ")))
+	assert.Contains(t, svg.String(), ">BOS 3 - 2 NYY · Top 5th</text>", "the badge should show the live score")
+	assert.Contains(t, svg.String(), `fill="#4c1"`)
+
+	assert.Equal(t, uint32(3), games.ForBadge("SF").ID)
+	assert.Nil(t, games.ForBadge("SEA"))
+
+	svg.Reset()
+	assert.NoError(t, BadgeSVG(&svg, nil))
+	assert.Contains(t, svg.String(), ">no game today</text>")
+}
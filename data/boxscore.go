@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+type Boxscore struct {
+	ID     uint32       `json:"id"`
+	Status GameStatus   `json:"status"`
+	Away   BoxscoreLine `json:"away"`
+	Home   BoxscoreLine `json:"home"`
+}
+
+// a team's line in the boxscore
+type BoxscoreLine struct {
+	Name       string `json:"name"`
+	Runs       uint16 `json:"runs"`
+	Hits       uint16 `json:"hits"`
+	Errors     uint16 `json:"errors"`
+	LeftOnBase uint16 `json:"left_on_base"`
+}
+
+// function used to retrieve a game's boxscore
+type BoxscoreFunc func(ctx context.Context, id uint32) (Boxscore, error)
+
+// boxscores for final games never change, so they are kept in a small LRU
+// preview and live boxscores are always fetched fresh
+type BoxscoreCache struct {
+	final *lru.Cache[uint32, Boxscore]
+	fetch BoxscoreFunc
+}
+
+// create a boxscore cache holding up to size final boxscores, using FetchBoxscore if fetch is nil
+func NewBoxscoreCache(fetch BoxscoreFunc, size int) *BoxscoreCache {
+	if fetch == nil {
+		fetch = FetchBoxscore
+	}
+	return &BoxscoreCache{
+		final: newLRU[uint32, Boxscore](size),
+		fetch: fetch,
+	}
+}
+
+// get a game's boxscore, from the cache if the game is final
+func (bc *BoxscoreCache) Get(ctx context.Context, id uint32) (Boxscore, error) {
+	if boxscore, cached := bc.final.Get(id); cached {
+		return boxscore, nil
+	}
+
+	boxscore, err := bc.fetch(ctx, id)
+	if err != nil {
+		return Boxscore{}, err
+	}
+
+	if boxscore.Status == StatusFinal {
+		bc.final.Add(id, boxscore)
+	}
+	return boxscore, nil
+}
+
+// get a game's boxscore from the MLB API
+func FetchBoxscore(ctx context.Context, id uint32) (Boxscore, error) {
+	link := fmt.Sprintf("%s?fields=%s", apiURL(fmt.Sprintf("/v1.1/game/%d/feed/live", id)), generateFieldsString(api_data.LiveBoxscore{}))
+
+	// limit each fetch to 10 seconds
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, link, nil)
+	if err != nil {
+		return Boxscore{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Boxscore{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Boxscore{}, fmt.Errorf("%w: %s", ErrGameNotFound, link)
+	} else if resp.StatusCode != http.StatusOK {
+		return Boxscore{}, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
+	lb := api_data.LiveBoxscore{}
+	err = lb.FromJSON(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return Boxscore{}, fmt.Errorf("failed to decode boxscore from %s: %w", link, err)
+	}
+
+	line := func(team api_data.BoxscoreTotalsTeam) BoxscoreLine {
+		return BoxscoreLine{
+			Name:       team.Team.Name,
+			Runs:       team.TeamStats.Batting.Runs,
+			Hits:       team.TeamStats.Batting.Hits,
+			Errors:     team.TeamStats.Fielding.Errors,
+			LeftOnBase: team.TeamStats.Batting.LeftOnBase,
+		}
+	}
+
+	// an unknown status is passed through as-is and is never cached
+	status, _ := ParseGameStatus(lb.GameData.Status.AbstractGameState)
+
+	return Boxscore{
+		ID:     uint32(lb.GamePk),
+		Status: status,
+		Away:   line(lb.LiveData.Boxscore.Teams.Away),
+		Home:   line(lb.LiveData.Boxscore.Teams.Home),
+	}, nil
+}
@@ -0,0 +1,29 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoxscoreCacheServesFinalFromCache(t *testing.T) {
+	fetches := map[uint32]int{}
+	statuses := map[uint32]GameStatus{1: StatusFinal, 2: StatusLive}
+	bc := NewBoxscoreCache(func(ctx context.Context, id uint32) (Boxscore, error) {
+		fetches[id]++
+		return Boxscore{ID: id, Status: statuses[id], Home: BoxscoreLine{Runs: 4}}, nil
+	}, 8)
+
+	for i := 0; i < 2; i++ {
+		boxscore, err := bc.Get(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(4), boxscore.Home.Runs)
+
+		_, err = bc.Get(context.Background(), 2)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, fetches[1], "second request for a final boxscore should be served from cache")
+	assert.Equal(t, 2, fetches[2], "live boxscores should not be cached")
+}
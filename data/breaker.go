@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// returned instead of calling the MLB API while the circuit breaker is open
+var ErrBreakerOpen = errors.New("circuit breaker is open, MLB API calls are paused")
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// a circuit breaker around MLB API calls
+// after threshold consecutive failures it opens and fails fast for the cooldown,
+// then lets a single probe through (half-open) to decide whether to close again
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	clock     Clock
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+		clock:     RealClock,
+	}
+}
+
+// replace the clock used to time the cooldown
+// must be called before the breaker is shared between goroutines
+func (b *Breaker) SetClock(clock Clock) {
+	b.clock = clock
+}
+
+// report the breaker's current state
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.clock.Now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// wrap a fetch function with the breaker
+func (b *Breaker) Fetch(fetch FetchFunc) FetchFunc {
+	return func(ctx context.Context, link string) (Game, error) {
+		var game Game
+		err := b.do(func() error {
+			var err error
+			game, err = fetch(ctx, link)
+			return err
+		})
+		return game, err
+	}
+}
+
+// wrap a list function with the breaker
+func (b *Breaker) List(list ListFunc) ListFunc {
+	return func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]ScheduledGame, error) {
+		var games []ScheduledGame
+		err := b.do(func() error {
+			var err error
+			games, err = list(ctx, logger, sportID, dateString)
+			return err
+		})
+		return games, err
+	}
+}
+
+// run a call through the breaker, failing fast if it is open
+func (b *Breaker) do(call func() error) error {
+	b.mu.Lock()
+	if b.state == BreakerOpen {
+		// after the cooldown, let exactly one probe through
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown || b.probing {
+			b.mu.Unlock()
+			return ErrBreakerOpen
+		}
+		b.probing = true
+	}
+	b.mu.Unlock()
+
+	err := call()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+
+	// a missing game or a canceled request doesn't mean the MLB API is down
+	if err == nil || errors.Is(err, ErrGameNotFound) || errors.Is(err, context.Canceled) {
+		b.state = BreakerClosed
+		b.failures = 0
+		return err
+	}
+
+	b.failures++
+	if b.state == BreakerOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.clock.Now()
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerOpensAndFailsFast(t *testing.T) {
+	calls := 0
+	healthy := false
+	fetch := func(ctx context.Context, link string) (Game, error) {
+		calls++
+		if !healthy {
+			return Game{}, errors.New("MLB API is down")
+		}
+		return Game{ID: 1}, nil
+	}
+
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC))
+	breaker := NewBreaker(3, time.Minute)
+	breaker.SetClock(clock)
+	guarded := breaker.Fetch(fetch)
+
+	// drive the breaker open with consecutive failures
+	for i := 0; i < 3; i++ {
+		_, err := guarded(context.Background(), "link")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrBreakerOpen)
+	}
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	// while open, calls fail fast without reaching the API
+	_, err := guarded(context.Background(), "link")
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.Equal(t, 3, calls, "open breaker should not call the API")
+
+	// after the cooldown, a successful probe closes the breaker
+	clock.Advance(time.Minute - time.Nanosecond)
+	assert.Equal(t, BreakerOpen, breaker.State(), "the breaker should stay open for the whole cooldown")
+	clock.Advance(time.Nanosecond)
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	healthy = true
+	game, err := guarded(context.Background(), "link")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), game.ID)
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestBreakerIgnoresNotFound(t *testing.T) {
+	breaker := NewBreaker(1, time.Minute)
+	guarded := breaker.Fetch(func(ctx context.Context, link string) (Game, error) {
+		return Game{}, ErrGameNotFound
+	})
+
+	_, err := guarded(context.Background(), "link")
+	assert.ErrorIs(t, err, ErrGameNotFound)
+	assert.Equal(t, BreakerClosed, breaker.State(), "a missing game should not trip the breaker")
+}
@@ -0,0 +1,43 @@
+package data
+
+import (
+	"sync"
+	"time"
+)
+
+// source of the current time, so decisions based on it can be tested without waiting
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// the system clock
+var RealClock Clock = realClock{}
+
+// a clock that only moves when told to, for tests
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// move the clock forward (or back, for a negative duration)
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,170 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+// format of the dates accepted by the MLB schedule endpoint's date parameter
+const ScheduleDateFormat = "01/02/2006"
+
+// most dates whose games can be asked for at once
+const MaxDates = 7
+
+type DatedGames struct {
+	Metadata Metadata    `json:"metadata"`
+	Data     []DatedGame `json:"data"`
+}
+
+// a lightweight view of a game on a date, straight from the schedule
+type DatedGame struct {
+	ID uint32 `json:"id"`
+	// the schedule date the game is listed on, as MM/DD/YYYY
+	Date      string     `json:"date"`
+	Away      string     `json:"away"`
+	Home      string     `json:"home"`
+	AwayScore uint8      `json:"away_score"`
+	HomeScore uint8      `json:"home_score"`
+	StartTime time.Time  `json:"start_time"`
+	Status    GameStatus `json:"status"`
+	Detailed  string     `json:"detailed"`
+}
+
+func (dg *DatedGames) ToJSON() ([]byte, error) {
+	dg.Metadata.APIVersion = APIVersion
+	if dg.Data == nil {
+		dg.Data = []DatedGame{}
+	}
+	js, err := json.Marshal(dg)
+	return js, err
+}
+
+// function used to list the games on a date (formatted as ScheduleDateFormat)
+type DateScheduleFunc func(ctx context.Context, date string) ([]DatedGame, error)
+
+// games on dates that are in the past won't change, so they are kept in a small LRU
+// today and later dates are always fetched fresh
+type DateGamesCache struct {
+	past  *lru.Cache[string, []DatedGame]
+	fetch DateScheduleFunc
+	loc   *time.Location
+	clock Clock
+}
+
+// create a dated games cache holding up to size past dates, deciding what "today" is in loc,
+// using FetchDateSchedule if fetch is nil
+func NewDateGamesCache(fetch DateScheduleFunc, size int, loc *time.Location) *DateGamesCache {
+	if fetch == nil {
+		fetch = FetchDateSchedule
+	}
+	return &DateGamesCache{
+		past:  newLRU[string, []DatedGame](size),
+		fetch: fetch,
+		loc:   loc,
+		clock: RealClock,
+	}
+}
+
+// replace the clock used to decide what "today" is
+// must be called before the cache is shared between goroutines
+func (dc *DateGamesCache) SetClock(clock Clock) {
+	dc.clock = clock
+}
+
+// get the games on each of the dates, merged and sorted by start time, from the cache for dates that are over
+func (dc *DateGamesCache) Get(ctx context.Context, dates []string) ([]DatedGame, error) {
+	today, err := time.Parse(ScheduleDateFormat, dc.clock.Now().In(dc.loc).Format(ScheduleDateFormat))
+	if err != nil {
+		return nil, err
+	}
+
+	games := []DatedGame{}
+	for _, date := range dates {
+		day, err := time.Parse(ScheduleDateFormat, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", date, err)
+		}
+
+		dateGames, cached := dc.past.Get(date)
+		if !cached {
+			dateGames, err = dc.fetch(ctx, date)
+			if err != nil {
+				return nil, err
+			}
+			if day.Before(today) {
+				dc.past.Add(date, dateGames)
+			}
+		}
+		games = append(games, dateGames...)
+	}
+
+	sort.SliceStable(games, func(i, j int) bool {
+		if !games[i].StartTime.Equal(games[j].StartTime) {
+			return games[i].StartTime.Before(games[j].StartTime)
+		}
+		return games[i].ID < games[j].ID
+	})
+	return games, nil
+}
+
+// get the games on a date from the MLB API schedule
+func FetchDateSchedule(ctx context.Context, date string) ([]DatedGame, error) {
+	fieldsSchedule := generateFieldsString(api_data.TeamSchedule{})
+	apiUrl := fmt.Sprintf("%s?sportId=%d&date=%s&fields=%s", apiURL("/v1/schedule"), SportID, date, fieldsSchedule)
+
+	// limit each fetch to 10 seconds
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
+	body := scheduleUsage.count(io.LimitReader(resp.Body, maxResponseSize), apiUrl)
+	schedule := api_data.TeamSchedule{}
+	err = schedule.FromJSON(body)
+	body.done()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode schedule from %s: %w", apiUrl, err)
+	}
+
+	games := []DatedGame{}
+	for _, scheduleDate := range schedule.Dates {
+		for _, game := range scheduleDate.Games {
+			// an unknown status is passed through as-is
+			status, _ := ParseGameStatus(game.Status.AbstractGameState)
+
+			games = append(games, DatedGame{
+				ID:        game.GamePk,
+				Date:      date,
+				Away:      game.Teams.Away.Team.Name,
+				Home:      game.Teams.Home.Team.Name,
+				AwayScore: game.Teams.Away.Score,
+				HomeScore: game.Teams.Home.Score,
+				StartTime: game.GameDate,
+				Status:    status,
+				Detailed:  game.Status.DetailedState,
+			})
+		}
+	}
+	return games, nil
+}
@@ -0,0 +1,34 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateGamesCacheOnlyCachesPastDates(t *testing.T) {
+	fetches := 0
+	dc := NewDateGamesCache(func(ctx context.Context, date string) ([]DatedGame, error) {
+		fetches++
+		return []DatedGame{{ID: uint32(fetches), Date: date}}, nil
+	}, 8, time.UTC)
+	clock := NewFakeClock(time.Date(2024, 7, 5, 19, 5, 0, 0, time.UTC))
+	dc.SetClock(clock)
+
+	for i := 0; i < 2; i++ {
+		games, err := dc.Get(context.Background(), []string{"07/04/2024", "07/05/2024"})
+		assert.NoError(t, err)
+		assert.Len(t, games, 2)
+	}
+	assert.Equal(t, 3, fetches, "only the date that's over should be served from cache")
+
+	// once today is over, it is cached too
+	clock.Advance(24 * time.Hour)
+	for i := 0; i < 2; i++ {
+		_, err := dc.Get(context.Background(), []string{"07/05/2024"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, fetches, "a date should be cached once it's over")
+}
@@ -0,0 +1,40 @@
+package data
+
+// compare two sets of games, returning the keys of the games that were added, updated, and removed going from old to new
+// added and updated keys follow the order of new, removed keys follow the order of old
+func Diff(old, new *Games) ([]GameKey, []GameKey, []GameKey) {
+	var added, updated, removed []GameKey
+
+	oldGames := make(map[GameKey]*Game)
+	if old != nil {
+		for _, game := range old.Data {
+			oldGames[game.Key()] = game
+		}
+	}
+	newGames := make(map[GameKey]*Game)
+	if new != nil {
+		for _, game := range new.Data {
+			newGames[game.Key()] = game
+		}
+	}
+
+	if new != nil {
+		for _, game := range new.Data {
+			oldGame, exists := oldGames[game.Key()]
+			if !exists {
+				added = append(added, game.Key())
+			} else if !oldGame.Equal(*game) {
+				updated = append(updated, game.Key())
+			}
+		}
+	}
+	if old != nil {
+		for _, game := range old.Data {
+			if _, exists := newGames[game.Key()]; !exists {
+				removed = append(removed, game.Key())
+			}
+		}
+	}
+
+	return added, updated, removed
+}
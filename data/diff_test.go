@@ -0,0 +1,48 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	game := func(id uint32, homeScore uint8) *Game {
+		return &Game{ID: id, State: State{Teams: Teams{Home: Team{Score: homeScore}}}}
+	}
+	games := func(data ...*Game) *Games {
+		return &Games{Data: data}
+	}
+	keys := func(ids ...uint32) []GameKey {
+		keys := make([]GameKey, len(ids))
+		for i, id := range ids {
+			keys[i] = MLBKey(id)
+		}
+		return keys
+	}
+
+	cases := []struct {
+		name    string
+		old     *Games
+		new     *Games
+		added   []GameKey
+		updated []GameKey
+		removed []GameKey
+	}{
+		{"no change", games(game(1, 0), game(2, 0)), games(game(1, 0), game(2, 0)), nil, nil, nil},
+		{"added", games(game(1, 0)), games(game(1, 0), game(2, 0)), keys(2), nil, nil},
+		{"updated", games(game(1, 0), game(2, 0)), games(game(1, 1), game(2, 0)), nil, keys(1), nil},
+		{"removed", games(game(1, 0), game(2, 0)), games(game(2, 0)), nil, nil, keys(1)},
+		{"all at once", games(game(1, 0), game(2, 0)), games(game(2, 3), game(3, 0)), keys(3), keys(2), keys(1)},
+		{"from nothing", nil, games(game(1, 0)), keys(1), nil, nil},
+		{"to nothing", games(game(1, 0)), &Games{}, nil, nil, keys(1)},
+		{"same id in another sport", games(game(1, 0)), games(game(1, 0), &Game{ID: 1, SportID: 11}), []GameKey{KeyOf(11, 1)}, nil, nil},
+	}
+
+	for _, c := range cases {
+		added, updated, removed := Diff(c.old, c.new)
+		assert.Equal(t, c.added, added, c.name)
+		assert.Equal(t, c.updated, updated, c.name)
+		assert.Equal(t, c.removed, removed, c.name)
+	}
+}
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// what the audit does with games in a detailed state, overriding the built-in pruning rules
+const (
+	// never prune the games, e.g. to keep "Completed Early" games around
+	DispositionKeep = "keep"
+	// prune the games as soon as they're audited, e.g. to drop a "Forfeit"
+	DispositionPrune = "prune"
+	// prune the games once they started longer ago than the given duration, e.g. "prune-after:6h"
+	DispositionPruneAfter = "prune-after"
+)
+
+// how the audit treats games in a detailed state
+type Disposition struct {
+	Action string
+	// for DispositionPruneAfter, how long after its start a game is pruned
+	After time.Duration
+}
+
+// dispositions by detailed state (case-insensitive)
+// detailed states without one follow the built-in rules, so the defaults are to have none
+type Dispositions map[string]Disposition
+
+// parse dispositions from entries like "Forfeit=prune", "Completed Early=keep", or "Cancelled=prune-after:2h"
+func ParseDispositions(entries []string) (Dispositions, error) {
+	dispositions := make(Dispositions)
+
+	for _, raw := range entries {
+		entry := strings.TrimSpace(raw)
+		if entry == "" {
+			continue
+		}
+
+		detailed, rawAction, found := strings.Cut(entry, "=")
+		detailed = strings.TrimSpace(detailed)
+		if !found || detailed == "" {
+			return nil, fmt.Errorf("invalid disposition %q, expected a detailed state and action like Forfeit=prune", entry)
+		}
+
+		action, rawAfter, hasAfter := strings.Cut(strings.TrimSpace(rawAction), ":")
+		disposition := Disposition{Action: strings.ToLower(strings.TrimSpace(action))}
+		switch {
+		case disposition.Action == DispositionPruneAfter && hasAfter:
+			after, err := time.ParseDuration(strings.TrimSpace(rawAfter))
+			if err != nil || after <= 0 {
+				return nil, fmt.Errorf("invalid duration in disposition %q", entry)
+			}
+			disposition.After = after
+		case (disposition.Action == DispositionKeep || disposition.Action == DispositionPrune) && !hasAfter:
+		default:
+			return nil, fmt.Errorf("invalid action in disposition %q, expected keep, prune, or prune-after:<duration>", entry)
+		}
+		dispositions[strings.ToLower(detailed)] = disposition
+	}
+
+	return dispositions, nil
+}
+
+// look up the disposition for a game's detailed state, if one is configured
+func (d Dispositions) For(game Game) (Disposition, bool) {
+	disposition, exists := d[strings.ToLower(strings.TrimSpace(game.State.Status.Detailed))]
+	return disposition, exists
+}
+
+// check whether a game with this disposition should be pruned now
+func (d Disposition) Prunes(game Game, now time.Time) bool {
+	switch d.Action {
+	case DispositionPrune:
+		return true
+	case DispositionPruneAfter:
+		return now.Sub(game.State.Status.StartTime.DateTime) > d.After
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDispositions(t *testing.T) {
+	dispositions, err := ParseDispositions([]string{" forfeit=prune ", "Completed Early=Keep", "Cancelled=prune-after:2h", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, Dispositions{
+		"forfeit":         {Action: DispositionPrune},
+		"completed early": {Action: DispositionKeep},
+		"cancelled":       {Action: DispositionPruneAfter, After: 2 * time.Hour},
+	}, dispositions)
+
+	for _, invalid := range []string{"Forfeit", "=prune", "Forfeit=drop", "Forfeit=prune-after", "Forfeit=prune-after:soon", "Forfeit=keep:2h"} {
+		_, err := ParseDispositions([]string{invalid})
+		assert.Error(t, err, "%q should be rejected", invalid)
+	}
+}
+
+func TestAuditFollowsDispositions(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC))
+	start := clock.Now()
+	detailed := map[string]string{"1": "Forfeit", "2": "Completed Early", "3": "Final"}
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{
+			Link:         link,
+			Metadata:     Metadata{Timestamp: clock.Now(), Ready: true},
+			State:        State{Status: Status{General: StatusFinal, Detailed: detailed[link], StartTime: api_data.Datetime{DateTime: start}}},
+			refreshAfter: clock.Now().Add(24 * time.Hour),
+		}, nil
+	}, 0)
+	gc.SetClock(clock)
+	dispositions, err := ParseDispositions([]string{"Forfeit=prune", "Completed Early=keep"})
+	assert.NoError(t, err)
+	gc.SetDispositions(dispositions)
+
+	for _, sg := range []ScheduledGame{{ID: 1, Link: "1"}, {ID: 2, Link: "2"}, {ID: 3, Link: "3"}} {
+		_, err := gc.Discover(sg)
+		assert.NoError(t, err)
+		_, valid := gc.GetOne(context.Background(), sg.Key())
+		assert.True(t, valid)
+	}
+
+	_, removed, _ := gc.Audit(context.Background())
+	assert.Equal(t, []GameKey{MLBKey(1)}, removed, "forfeits should be dropped right away")
+	discovered, err := gc.Discover(ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	assert.False(t, discovered, "a pruned forfeit still on the schedule should not be discovered again")
+
+	clock.Advance(16 * time.Hour)
+	_, removed, _ = gc.Audit(context.Background())
+	assert.Equal(t, []GameKey{MLBKey(3)}, removed, "other finals should still be pruned by the built-in rules")
+	_, valid := gc.GetOne(context.Background(), MLBKey(2))
+	assert.True(t, valid, "games completed early should be kept")
+}
@@ -0,0 +1,61 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// games singled out editorially (rivalries, playoff races), by game id or by matchup
+type Featured struct {
+	ids      map[uint32]bool
+	matchups map[string]bool
+}
+
+// parse featured games from entries like "745123" (a game id) or "NYY-BOS" (team abbreviations, in either order)
+func ParseFeatured(entries []string) (*Featured, error) {
+	f := &Featured{
+		ids:      make(map[uint32]bool),
+		matchups: make(map[string]bool),
+	}
+
+	for _, raw := range entries {
+		entry := strings.TrimSpace(raw)
+		if entry == "" {
+			continue
+		}
+
+		if id, err := strconv.ParseUint(entry, 10, 32); err == nil {
+			f.ids[uint32(id)] = true
+			continue
+		}
+
+		away, home, found := strings.Cut(entry, "-")
+		if !found || strings.TrimSpace(away) == "" || strings.TrimSpace(home) == "" {
+			return nil, fmt.Errorf("invalid featured game %q, expected a game id or a matchup like NYY-BOS", entry)
+		}
+		f.matchups[matchupKey(away, home)] = true
+	}
+
+	return f, nil
+}
+
+// check whether a game is featured, either by id or by the teams playing
+func (f *Featured) Matches(game Game) bool {
+	if f == nil {
+		return false
+	}
+	if f.ids[game.ID] {
+		return true
+	}
+	return f.matchups[matchupKey(game.State.Teams.Away.Info.Abbreviation, game.State.Teams.Home.Info.Abbreviation)]
+}
+
+// key a matchup so that it matches regardless of which team is home
+func matchupKey(a, b string) string {
+	a, b = strings.ToUpper(strings.TrimSpace(a)), strings.ToUpper(strings.TrimSpace(b))
+	if b < a {
+		a, b = b, a
+	}
+	return a + "-" + b
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeaturedGames(t *testing.T) {
+	featured, err := ParseFeatured([]string{"745123", " nyy-BOS "})
+	assert.NoError(t, err)
+
+	teams := func(away, home string) Teams {
+		return Teams{Away: Team{Info: Info{Abbreviation: away}}, Home: Team{Info: Info{Abbreviation: home}}}
+	}
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		switch link {
+		case "rivalry":
+			return Game{ID: 1, Link: link, Metadata: Metadata{Ready: true}, State: State{Teams: teams("BOS", "NYY")}}, nil
+		case "by-id":
+			return Game{ID: 745123, Link: link, Metadata: Metadata{Ready: true}, State: State{Teams: teams("LAD", "SF")}}, nil
+		default:
+			return Game{ID: 3, Link: link, Metadata: Metadata{Ready: true}, State: State{Teams: teams("NYY", "TB")}}, nil
+		}
+	}, 0)
+	gc.SetFeatured(featured)
+
+	for _, sg := range []ScheduledGame{{ID: 1, Link: "rivalry"}, {ID: 745123, Link: "by-id"}, {ID: 3, Link: "other"}} {
+		_, err := gc.Discover(sg)
+		assert.NoError(t, err)
+		gc.GetOne(context.Background(), sg.Key())
+	}
+
+	games, err := GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	featuredIDs := map[uint32]bool{}
+	for _, game := range games.Data {
+		featuredIDs[game.ID] = game.Featured
+	}
+	assert.Equal(t, map[uint32]bool{1: true, 745123: true, 3: false}, featuredIDs)
+	assert.False(t, games.Data[2].Featured, "featured games should sort ahead of others with the same status")
+
+	_, err = ParseFeatured([]string{"NYY"})
+	assert.Error(t, err, "a lone team is not a matchup")
+}
@@ -2,23 +2,102 @@ package data
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/claycot/mlb-gameday-api/api_data"
 )
 
+// returned when the MLB API has no game at the requested link
+var ErrGameNotFound = errors.New("game not found")
+
+// sport id of Major League Baseball in the MLB API, the default sport
+const SportID = 1
+
+// identifies a game in the cache, since game ids are only unique within a sport
+type GameKey struct {
+	SportID int
+	ID      uint32
+}
+
+// the key of a game, counting games without a sport (e.g. from old recordings) as MLB
+func KeyOf(sportID int, id uint32) GameKey {
+	if sportID == 0 {
+		sportID = SportID
+	}
+	return GameKey{SportID: sportID, ID: id}
+}
+
+// the key of an MLB game
+func MLBKey(id uint32) GameKey {
+	return GameKey{SportID: SportID, ID: id}
+}
+
+// the ids of the games with the given keys, e.g. for a payload of game ids
+func KeyIDs(keys []GameKey) []uint32 {
+	ids := make([]uint32, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ID
+	}
+	return ids
+}
+
+// version of the response schema, sent in the metadata of every payload
+// bump it whenever the shape of a payload changes in a way clients could trip over,
+// new fields and events are additive and left out of it, since clients ignore what they don't know
+//
+//	1: first versioned schema
+//	2: data is always an array rather than null, and payloads and streams only hold the games of one sport, MLB by default
+const APIVersion = "2"
+
+// maximum time allowed for a single request to the MLB API
+const FetchTimeout = 10 * time.Second
+
+// maximum size of a response body read from the MLB API, anything longer is truncated and fails to decode
+const maxResponseSize = 8 << 20
+
 type Games struct {
 	Metadata Metadata `json:"metadata"`
 	Data     []*Game  `json:"data"`
+	// ids of games removed recently, listed in the initial games so clients holding stale copies can drop them
+	Removed []uint32 `json:"removed,omitempty"`
+}
+
+// postponed games listed again under new ids
+type Reschedules struct {
+	Metadata Metadata     `json:"metadata"`
+	Data     []Reschedule `json:"data"`
+}
+
+// a postponed game's id and the id it was rescheduled under
+type Reschedule struct {
+	ID      uint32 `json:"id"`
+	NewID   uint32 `json:"new_id"`
+	SportID int    `json:"sport_id"`
+}
+
+func (rs *Reschedules) ToJSON() ([]byte, error) {
+	rs.Metadata.APIVersion = APIVersion
+	if rs.Data == nil {
+		rs.Data = []Reschedule{}
+	}
+	js, err := json.Marshal(rs)
+	return js, err
 }
 
 type GameIDs struct {
@@ -27,20 +106,160 @@ type GameIDs struct {
 }
 
 type GameCache struct {
-	cache  sync.Map
-	length uint8
+	// games by GameKey
+	cache sync.Map
+	// number of games in the cache, updated by concurrent discovers and deletes
+	length atomic.Int32
+	fetch  FetchFunc
+	sem    chan struct{}
+	// consecutive not found responses per game
+	notFound sync.Map
+	// games whose last fetch failed
+	failed sync.Map
+	// games to flag as featured when fetched
+	featured *Featured
+	// source of the time used to stamp games and decide when they are due or pruned
+	clock Clock
+	// when each recently removed game was removed, kept for removedRetention
+	removed          sync.Map
+	removedRetention time.Duration
+	// consecutive schedule listings each game has been missing from, and how many it takes to remove it (0 never does)
+	missing          sync.Map
+	missingThreshold int
+	// games tracked on demand rather than found on the schedule, which reconciling leaves alone
+	onDemand sync.Map
+	// where problems with fetched games are reported
+	logger *log.Logger
+	// if set, newly discovered games are fetched in the background until it is canceled, instead of on first GetOne
+	prefetch context.Context
+	// most games refreshed per audit, the rest being left for the next one (0 refreshes every due game)
+	fetchBudget int
+	// overrides of the built-in pruning rules by detailed state, and the games they pruned,
+	// which are still on the schedule but aren't discovered again
+	dispositions Dispositions
+	disposed     sync.Map
+}
+
+// maximum number of games held in the cache
+const maxGames = 255
+
+// how often preview games more than a day from starting are refreshed, e.g. those loaded for a multi-day board
+const upcomingRefresh = time.Hour
+
+// number of consecutive not found responses after which a game is considered removed upstream
+const maxNotFound = 3
+
+// function used by the cache to retrieve up-to-date information on a game from its link
+type FetchFunc func(ctx context.Context, link string) (Game, error)
+
+// function used to list the games of a sport scheduled on a given date
+type ListFunc func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]ScheduledGame, error)
+
+// a game as listed on the schedule, before its live information is fetched
+type ScheduledGame struct {
+	ID               uint32
+	Link             string
+	Tie              bool
+	SportID          int
+	ScheduledInnings uint8
+	// set for neutral-site games where the nominal home team bats first
+	ReverseHomeAway bool
+	// names of the teams, used to recognize a postponed game listed again under a new id
+	AwayTeam string
+	HomeTeam string
+	// the schedule's game type code (e.g. "R" or "D") and its name for the series, e.g. "AL Division Series"
+	GameType          string
+	SeriesDescription string
+	// the schedule date (MM/DD/YYYY) the game was listed on, empty if it wasn't listed by date
+	Date string
 }
 
+// names of the schedule's game type codes
+var gameTypes = map[string]string{
+	"R": "Regular Season",
+	"F": "Wild Card",
+	"D": "Division Series",
+	"L": "League Championship Series",
+	"W": "World Series",
+	"S": "Spring Training",
+	"E": "Exhibition",
+	"A": "All-Star Game",
+}
+
+// the readable name of a schedule game type code, or the code itself if it isn't known
+func gameTypeLabel(code string) string {
+	if label, ok := gameTypes[code]; ok {
+		return label
+	}
+	return code
+}
+
+// the cache key of a scheduled game
+func (sg ScheduledGame) Key() GameKey {
+	return KeyOf(sg.SportID, sg.ID)
+}
+
+// innings in a regulation game, assumed when the schedule doesn't say
+const regulationInnings = 9
+
 type Game struct {
 	Metadata Metadata `json:"metadata"`
 	Link     string   `json:"link"`
 	ID       uint32   `json:"id"`
-	State    State    `json:"state"`
+	SportID  int      `json:"sport_id"`
+	// the kind of game, e.g. "Regular Season" or "Division Series", and the schedule's name for its series
+	GameType          string `json:"game_type"`
+	SeriesDescription string `json:"series_description"`
+	// the teams as they're usually shown, e.g. "NYY @ BOS", empty until the game is fetched
+	Matchup string `json:"matchup"`
+	State   State  `json:"state"`
+	// set for games marked as featured in the config
+	Featured bool `json:"featured"`
+	// earliest time the MLB API's caching hints allow the game to be refetched
+	refreshAfter time.Time
+	// when the game was discovered, how many times it has been fetched since, and how many of those found it changed
+	discovered time.Time
+	fetches    uint32
+	changes    uint32
+	// when the game was last fetched and found changed, which is when it was last modified for clients
+	changedAt time.Time
+	// the schedule date the game was discovered on, so it's only reconciled against listings of that date
+	scheduleDate string
+}
+
+// how often a game has been fetched and found changed since it was discovered, for tuning refresh intervals
+type UpdateCount struct {
+	ID         uint32    `json:"id"`
+	SportID    int       `json:"sport_id"`
+	Discovered time.Time `json:"discovered"`
+	Fetches    uint32    `json:"fetches"`
+	Changes    uint32    `json:"changes"`
+}
+
+// the cache key of a game
+func (g Game) Key() GameKey {
+	return KeyOf(g.SportID, g.ID)
+}
+
+// whether two versions of a game would look the same to a client, ignoring when each was fetched
+// the state carries the score, status, diamond, and count
+func (g Game) Equal(other Game) bool {
+	return g.ID == other.ID &&
+		g.Link == other.Link &&
+		g.SportID == other.SportID &&
+		g.GameType == other.GameType &&
+		g.SeriesDescription == other.SeriesDescription &&
+		g.Matchup == other.Matchup &&
+		g.Featured == other.Featured &&
+		g.Metadata.Ready == other.Metadata.Ready &&
+		reflect.DeepEqual(g.State, other.State)
 }
 
 type Metadata struct {
 	Timestamp time.Time `json:"timestamp"`
 	Ready     bool      `json:"ready"`
+	// set on payload envelopes when they are marshaled, not on individual games
+	APIVersion string `json:"api_version,omitempty"`
 }
 
 type State struct {
@@ -49,11 +268,65 @@ type State struct {
 	Diamond Diamond `json:"diamond"`
 	Outs    uint8   `json:"outs"`
 	Status  Status  `json:"status"`
+	Tie     bool    `json:"tie"`
+	// who won a final game ("away", "home", or "tie"), empty for games that aren't over
+	Winner string `json:"winner"`
+	// why a delayed game is stalled, e.g. "Rain" or "Tarp on Field"
+	DelayReason string `json:"delay_reason"`
+	// when a delayed game is expected to resume, null when the feed doesn't say
+	ResumptionTime *time.Time `json:"resumption_time"`
+	// set for live games while a challenge or umpire review is underway
+	ReviewInProgress bool `json:"review_in_progress"`
+	// set for live games while the latest play event is a mound visit
+	MoundVisit bool `json:"mound_visit"`
+	// set while a game is suspended and waiting to be resumed, possibly on a later date
+	Suspended bool `json:"suspended"`
+	// innings the game is scheduled for (e.g. 7 for some doubleheaders), and whether it is or was shorter than regulation,
+	// either by schedule or by ending early, so clients can show "F/7"
+	ScheduledInnings uint8 `json:"scheduled_innings"`
+	Shortened        bool  `json:"shortened"`
+	// where the batting team's tying and go-ahead runs are ("third", "second", "first", "plate"),
+	// for live games from the 7th inning on, empty when they aren't on the field
+	TyingRun   string `json:"tying_run"`
+	GoAheadRun string `json:"go_ahead_run"`
+	// set for live games in the final scheduled inning or later while the fielding team leads by 3 runs or fewer,
+	// or by few enough that the tying run is on base, at bat, or on deck, when a save is on the line
+	SaveSituation bool `json:"save_situation"`
+	// whether the runner on second hasn't batted in the current half inning, so was placed there,
+	// kept to flag the automatic runner once the scheduled innings are known
+	secondPlaced bool
+	// set for neutral-site games where the nominal home team bats first, in the top of each inning
+	ReverseHomeAway bool `json:"reverse_home_away"`
+	// which team is at bat ("away" or "home") during a half inning of a live game, empty otherwise
+	Batting string `json:"batting"`
+	// the umpire crew of a live or final game, starting with the home plate umpire
+	Umpires []Umpire `json:"umpires"`
+	// paid attendance and the temperature at first pitch in Fahrenheit, for live and final games, zero when unavailable
+	Attendance      uint32 `json:"attendance"`
+	FirstPitchTempF int    `json:"first_pitch_temp_f"`
+	// the home team's runs minus the away team's
+	RunDifferential int `json:"run_differential"`
+	// the team that most recently held the lead ("away" or "home"), kept through ties and empty until someone scores,
+	// and how many times the lead has changed hands across refreshes
+	LastLeader  string `json:"last_leader"`
+	LeadChanges uint8  `json:"lead_changes"`
+	// rare events in a live or final game, like an immaculate inning, a no-hitter through 7, or a cycle
+	Notables []Notable `json:"notables"`
+}
+
+type Umpire struct {
+	Name string `json:"name"`
+	// "Home Plate", "First Base", "Second Base", "Third Base", or e.g. "Left Field" in the postseason
+	Position string `json:"position"`
 }
 
 type Inning struct {
 	Number     uint8  `json:"number"`
 	Top_bottom string `json:"top_bottom"`
+	// "Top", "Middle", "Bottom", or "End", including the breaks between halves
+	InningState string `json:"inning_state"`
+	// the inning state and ordinal for live games, e.g. "Top 5th" or "Middle 11th", empty otherwise
+	InningLabel string `json:"inning_label"`
 }
 
 type Diamond struct {
@@ -64,7 +337,7 @@ type Diamond struct {
 }
 
 type Status struct {
-	General   string            `json:"general"`
+	General   GameStatus        `json:"general"`
 	Detailed  string            `json:"detailed"`
 	StartTime api_data.Datetime `json:"start_time"`
 }
@@ -87,54 +360,238 @@ type Info struct {
 }
 
 type Player struct {
-	ID     uint32 `json:"id"`
-	Name   string `json:"name"`
-	Number string `json:"number"`
+	ID         uint32 `json:"id"`
+	Name       string `json:"name"`
+	Number     string `json:"number"`
+	PitchCount uint16 `json:"pitch_count"`
+	// throwing hand ("L"/"R") and season W-L record, for probable starters in preview games
+	Hand   string `json:"hand"`
+	Record string `json:"record"`
+	// spot in the batting order (1-9) and fielding position ("SS", "DH"), for the current batter in live games
+	LineupSpot uint8  `json:"lineup_spot"`
+	Position   string `json:"position"`
+	// the current batter's line in the game so far in live games, e.g. "1-3, 2B"
+	BatterGameLine string `json:"batter_game_line"`
+	// set for the runner on second in an extra inning of a live game who was placed there to start it, rather than reaching it
+	AutomaticRunner bool `json:"automatic_runner"`
 }
 
+// data is always sent as an array, even when there is nothing in it
 func (g *Games) ToJSON() ([]byte, error) {
+	g.Metadata.APIVersion = APIVersion
+	if g.Data == nil {
+		g.Data = []*Game{}
+	}
 	js, err := json.Marshal(g)
 	return js, err
 }
 
+// columns of the CSV export, one row per game
+var csvHeader = []string{"id", "matchup", "away", "home", "away_score", "home_score", "status", "detailed", "inning", "half"}
+
+// write the games as CSV for spreadsheets, with a header row and a row per game
+func (g *Games) ToCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, game := range g.Data {
+		s := game.State
+		err := cw.Write([]string{
+			strconv.FormatUint(uint64(game.ID), 10),
+			game.Matchup,
+			s.Teams.Away.Info.Name,
+			s.Teams.Home.Info.Name,
+			strconv.Itoa(int(s.Teams.Away.Score)),
+			strconv.Itoa(int(s.Teams.Home.Score)),
+			string(s.Status.General),
+			s.Status.Detailed,
+			strconv.Itoa(int(s.Inning.Number)),
+			s.Inning.Top_bottom,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
 func (g *GameIDs) ToJSON() ([]byte, error) {
+	g.Metadata.APIVersion = APIVersion
+	if g.Data == nil {
+		g.Data = []*uint32{}
+	}
 	js, err := json.Marshal(g)
 	return js, err
 }
 
+// how far the cache is through fetching the games it discovered on startup
+type WarmupProgress struct {
+	Metadata Metadata `json:"metadata"`
+	Data     Progress `json:"data"`
+}
+
+type Progress struct {
+	Ready int `json:"ready"`
+	Total int `json:"total"`
+}
+
+func (wp *WarmupProgress) ToJSON() ([]byte, error) {
+	wp.Metadata.APIVersion = APIVersion
+	js, err := json.Marshal(wp)
+	return js, err
+}
+
+// create a game cache that uses the given fetch function (or FetchGame if nil),
+// allowing at most concurrency simultaneous fetches (or unlimited if 0)
+func NewGameCache(fetch FetchFunc, concurrency int) *GameCache {
+	gc := &GameCache{fetch: fetch, clock: RealClock, logger: log.New(io.Discard, "", 0)}
+	if concurrency > 0 {
+		gc.sem = make(chan struct{}, concurrency)
+	}
+	return gc
+}
+
+// flag matching games as featured from their next fetch on
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetFeatured(f *Featured) {
+	gc.featured = f
+}
+
+// report problems with fetched games, like missing team data, to the logger
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetLogger(logger *log.Logger) {
+	gc.logger = logger
+}
+
+// replace the clock used to stamp, refresh, and prune games
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetClock(c Clock) {
+	gc.clock = c
+}
+
+// keep the ids of removed games for the given window (0 to not keep them)
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetRemovedRetention(d time.Duration) {
+	gc.removedRetention = d
+}
+
+// remove games missing from the schedule for the given number of consecutive listings (0 to never remove them)
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetMissingThreshold(n int) {
+	gc.missingThreshold = n
+}
+
+// fetch newly discovered games in the background, bounded by the fetch limit, until ctx is canceled
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetPrefetch(ctx context.Context) {
+	gc.prefetch = ctx
+}
+
+// refresh at most n games per audit, live games first but with some kept for the others,
+// leaving the rest due for the next audit (0 for no limit)
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetFetchBudget(n int) {
+	gc.fetchBudget = n
+}
+
+// prune games in the given detailed states as they say instead of by the built-in rules
+// must be called before the cache is shared between goroutines
+func (gc *GameCache) SetDispositions(d Dispositions) {
+	gc.dispositions = d
+}
+
+// list the ids of games removed within the retention window, in ascending order
+func (gc *GameCache) Removed() []uint32 {
+	now := gc.clock.Now()
+	var removed []uint32
+	gc.removed.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(time.Time)) > gc.removedRetention {
+			gc.removed.Delete(key)
+		} else {
+			removed = append(removed, key.(GameKey).ID)
+		}
+		return true
+	})
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+	return removed
+}
+
+// get the clock the cache uses, so callers can agree with it on what time it is
+func (gc *GameCache) Clock() Clock {
+	return gc.clock
+}
+
 // add a partial game to the cache
-func (gc *GameCache) Discover(id uint32, link string) (bool, error) {
+func (gc *GameCache) Discover(sg ScheduledGame) (bool, error) {
 	// check if the game already exists before discovering
-	_, exists := gc.cache.Load(id)
-	if exists {
+	// games are keyed by sport, so the same id showing up for another sport is a game of its own
+	// games pruned by a disposition stay pruned
+	key := sg.Key()
+	if _, disposed := gc.disposed.Load(key); disposed {
+		return false, nil
+	}
+
+	if _, exists := gc.cache.Load(key); exists {
 		return false, nil
 	}
 
-	// TODO: Magic number uint8 max
-	if gc.length == 255 {
-		return false, fmt.Errorf("games cache is full with %d games", gc.length)
+	// reserve a slot for the game before storing it, so concurrent discovers can't overfill the cache
+	if gc.length.Add(1) > maxGames {
+		gc.length.Add(-1)
+		return false, fmt.Errorf("games cache is full with %d games", maxGames)
+	}
+
+	if sg.ScheduledInnings == 0 {
+		sg.ScheduledInnings = regulationInnings
 	}
 
 	// if the game doesn't exist, discover it
-	gc.cache.Store(id, Game{
+	// another caller may have discovered it in the meantime, in which case the slot is given back
+	_, exists := gc.cache.LoadOrStore(key, Game{
 		Metadata: Metadata{
-			Timestamp: time.Now(),
+			Timestamp: gc.clock.Now(),
 			Ready:     false,
 		},
-		Link: link,
-		ID:   id,
+		discovered:        gc.clock.Now(),
+		scheduleDate:      sg.Date,
+		Link:              sg.Link,
+		ID:                sg.ID,
+		SportID:           key.SportID,
+		GameType:          gameTypeLabel(sg.GameType),
+		SeriesDescription: sg.SeriesDescription,
+		State: State{
+			Teams: Teams{
+				Away: Team{Info: Info{Name: sg.AwayTeam}},
+				Home: Team{Info: Info{Name: sg.HomeTeam}},
+			},
+			Tie:              sg.Tie,
+			ScheduledInnings: sg.ScheduledInnings,
+			ReverseHomeAway:  sg.ReverseHomeAway,
+		},
 	})
-	gc.length++
+	if exists {
+		gc.length.Add(-1)
+		return false, nil
+	}
 
+	// a game that comes back is no longer removed
+	gc.removed.Delete(key)
+
+	// get the game ready ahead of the first request for it, which would otherwise wait on the fetch
+	if gc.prefetch != nil {
+		go gc.GetOne(gc.prefetch, key)
+	}
 	return true, nil
 }
 
 // return the link for a game, or an error if it doesn't exist
-func (gc *GameCache) GetLink(id uint32) (string, error) {
+func (gc *GameCache) GetLink(key GameKey) (string, error) {
 	// check if the game exists
-	game, exists := gc.cache.Load(id)
+	game, exists := gc.cache.Load(key)
 	if !exists {
-		return "", fmt.Errorf("game with id %d does not exist, even as a partial", id)
+		return "", fmt.Errorf("game with id %d does not exist for sport %d, even as a partial", key.ID, key.SportID)
 	}
 
 	// if the game exists, return the link
@@ -142,37 +599,130 @@ func (gc *GameCache) GetLink(id uint32) (string, error) {
 }
 
 // use the stored game link to update cache game info
-func (gc *GameCache) Fetch(ctx context.Context, id uint32) (bool, error) {
+func (gc *GameCache) Fetch(ctx context.Context, key GameKey) (bool, error) {
 	// get the link from the game cache
-	link, err := gc.GetLink(id)
+	link, err := gc.GetLink(key)
 	if err != nil {
 		return false, err
 	}
 
+	// wait for a free fetch slot if the cache is limiting concurrency
+	// every fetch goes through here, so fan-outs like warming and prefetching can't exceed the limit either
+	if gc.sem != nil {
+		select {
+		case gc.sem <- struct{}{}:
+			defer func() { <-gc.sem }()
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
 	// get updated information on the game, passing context to handle cancellation
-	newGame, err := FetchGame(ctx, link)
+	fetch := gc.fetch
+	if fetch == nil {
+		fetch = func(ctx context.Context, link string) (Game, error) {
+			return fetchGame(ctx, link, gc.clock)
+		}
+	}
+	start := time.Now()
+	newGame, err := fetch(ctx, link)
+	if record, ok := ctx.Value(fetchTimerKey{}).(func(time.Duration)); ok {
+		record(time.Since(start))
+	}
 	if err != nil {
+		gc.failed.Store(key, true)
+		// not found responses only count while they're consecutive, so any other answer from the MLB API resets them
+		if !errors.Is(err, ErrGameNotFound) && !errors.Is(err, ErrBreakerOpen) && ctx.Err() == nil {
+			gc.notFound.Delete(key)
+		}
 		return false, err
 	}
+	gc.notFound.Delete(key)
+	gc.failed.Delete(key)
+	newGame.Featured = gc.featured.Matches(newGame)
 
 	// if successful, check if the game has changed
-	oldGameRaw, exists := gc.cache.Load(id)
+	oldGameRaw, exists := gc.cache.Load(key)
+
+	// some feeds leave out a team's league, which is only worth a warning the first time the game is filled in
+	if !exists || !oldGameRaw.(Game).Metadata.Ready {
+		for _, team := range []Team{newGame.State.Teams.Away, newGame.State.Teams.Home} {
+			if team.Info.League == "" {
+				gc.logger.Printf("[WARN] Game %d has no league for the %s\r\n", key.ID, team.Info.Name)
+			}
+		}
+	}
 	if exists {
 		oldGame := oldGameRaw.(Game)
-		// if the game did not change, return false
-		if reflect.DeepEqual(oldGame, newGame) {
-			return false, nil
+		// a tie reported by the schedule sticks, even if the live feed hasn't caught up
+		newGame.State.Tie = newGame.State.Tie || oldGame.State.Tie
+		newGame.State.Winner = winner(newGame.State)
+		// the sport, game type, and scheduled innings come from the schedule, since the live feed doesn't carry them
+		newGame.SportID = oldGame.SportID
+		newGame.GameType = oldGame.GameType
+		newGame.SeriesDescription = oldGame.SeriesDescription
+		newGame.State.ScheduledInnings = oldGame.State.ScheduledInnings
+		newGame.State.Shortened = isShortened(newGame.State)
+		// so does whether the home team bats first, which changes who the half inning puts at bat
+		if oldGame.State.ReverseHomeAway {
+			newGame.State.ReverseHomeAway = true
+			orientBatting(&newGame.State)
 		}
+		// the final inning depends on the scheduled innings, which the live feed doesn't carry
+		newGame.State.SaveSituation = isSaveSituation(newGame.State)
+		// as do extra innings
+		newGame.State.Diamond.Second.AutomaticRunner = isAutomaticRunner(newGame.State)
+		trackLeadChanges(oldGame.State, &newGame.State)
+		newGame.discovered, newGame.fetches, newGame.changes = oldGame.discovered, oldGame.fetches, oldGame.changes
+		newGame.changedAt = oldGame.changedAt
+		newGame.scheduleDate = oldGame.scheduleDate
 	}
 
-	// otherwise, store the game and return true
-	gc.cache.Store(id, newGame)
-	return true, nil
+	// store the game either way, so its timestamp and caching hints stay fresh,
+	// and report whether it changed in a way clients would see
+	changed := !exists || !oldGameRaw.(Game).Equal(newGame)
+	newGame.fetches++
+	if changed {
+		newGame.changes++
+		newGame.changedAt = newGame.Metadata.Timestamp
+	}
+	gc.cache.Store(key, newGame)
+	return changed, nil
+}
+
+// list how often each game in the cache has been fetched and found changed, most changed first
+func (gc *GameCache) UpdateCounts() []UpdateCount {
+	counts := []UpdateCount{}
+	gc.cache.Range(func(key, value any) bool {
+		game := value.(Game)
+		counts = append(counts, UpdateCount{
+			ID:         key.(GameKey).ID,
+			SportID:    key.(GameKey).SportID,
+			Discovered: game.discovered,
+			Fetches:    game.fetches,
+			Changes:    game.changes,
+		})
+		return true
+	})
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Changes != counts[j].Changes {
+			return counts[i].Changes > counts[j].Changes
+		}
+		return counts[i].ID < counts[j].ID
+	})
+	return counts
 }
 
-// retrieve a game from the cache by ID
-func (gc *GameCache) GetOne(ctx context.Context, id uint32) (Game, bool) {
-	gameRaw, exists := gc.cache.Load(id)
+type fetchTimerKey struct{}
+
+// report the time spent fetching games from the MLB API on behalf of ctx to record, e.g. fetches made on demand for a request
+func WithFetchTimer(ctx context.Context, record func(time.Duration)) context.Context {
+	return context.WithValue(ctx, fetchTimerKey{}, record)
+}
+
+// retrieve a game from the cache by key
+func (gc *GameCache) GetOne(ctx context.Context, key GameKey) (Game, bool) {
+	gameRaw, exists := gc.cache.Load(key)
 
 	// if the game doesn't exist, return empty and false
 	if !exists {
@@ -183,15 +733,13 @@ func (gc *GameCache) GetOne(ctx context.Context, id uint32) (Game, bool) {
 
 	// if the game exists, but isn't ready, load it
 	if !game.Metadata.Ready {
-		updated, err := gc.Fetch(ctx, id)
-
-		// if failed to fetch a new version, return empty and false
-		if !updated || err != nil {
+		// a concurrent fetch (e.g. a prefetch) may have readied the game first, in which case this one finds it unchanged
+		if _, err := gc.Fetch(ctx, key); err != nil {
 			return Game{}, false
 		}
 
 		// try to load again
-		if updatedGameRaw, ok := gc.cache.Load(id); ok {
+		if updatedGameRaw, ok := gc.cache.Load(key); ok && updatedGameRaw.(Game).Metadata.Ready {
 			return updatedGameRaw.(Game), true
 		}
 
@@ -205,151 +753,538 @@ func (gc *GameCache) GetOne(ctx context.Context, id uint32) (Game, bool) {
 
 // retrieve all ready games from the cache
 func (gc *GameCache) GetAll() ([]*Game, error) {
-	if gc.length > 0 {
-		games := make([]*Game, gc.length)
+	if length := gc.length.Load(); length > 0 {
+		// the cache may change while it's walked, so the length is only a capacity hint
+		games := make([]*Game, 0, length)
 
-		g := 0
 		gc.cache.Range(func(key, value interface{}) bool {
 			game := value.(Game)
 
 			if game.Metadata.Ready {
-				games[g] = &game
-				g++
+				games = append(games, &game)
 			}
 			return true
 		})
-		return games[0:g], nil
+		return games, nil
 	} else {
-		return nil, nil
+		return []*Game{}, nil
 	}
 }
 
+// count the discovered games that haven't been fetched yet, and are still expected to be
+// games whose last fetch failed or that have gone missing from the schedule are settled for now,
+// so they don't hold up everything else being ready
+func (gc *GameCache) Pending() int {
+	pending := 0
+	gc.cache.Range(func(key, value interface{}) bool {
+		if value.(Game).Metadata.Ready {
+			return true
+		}
+		_, failed := gc.failed.Load(key)
+		_, missing := gc.missing.Load(key)
+		if !failed && !missing {
+			pending++
+		}
+		return true
+	})
+	return pending
+}
+
+// start tracking a game of a sport by id, fetching it immediately
+// returns the game and whether it was newly added to the cache
+func (gc *GameCache) Track(ctx context.Context, key GameKey) (Game, bool, error) {
+	discovered, err := gc.Discover(ScheduledGame{ID: key.ID, Link: GameLink(key.ID), SportID: key.SportID})
+	if err != nil {
+		return Game{}, false, err
+	}
+	if discovered {
+		gc.onDemand.Store(key, struct{}{})
+	}
+
+	// fetch even if the game was already tracked, so the caller gets fresh data
+	_, err = gc.Fetch(ctx, key)
+	if err != nil {
+		// don't leave a partial game behind if it can't be fetched
+		if discovered {
+			gc.Delete(key)
+		}
+		return Game{}, false, err
+	}
+
+	game, valid := gc.GetOne(ctx, key)
+	if !valid {
+		return Game{}, false, fmt.Errorf("game with id %d could not be loaded after fetching", key.ID)
+	}
+	return game, discovered, nil
+}
+
+// fetch every discovered game that isn't ready yet, returning the games that failed
+// if progress isn't nil, it is called after each fetch with the number of games that are ready out of the total,
+// in order and from the calling goroutine
+func (gc *GameCache) Warm(ctx context.Context, progress func(ready, total int)) []GameKey {
+	var unready []GameKey
+	total := 0
+	gc.cache.Range(func(key, value interface{}) bool {
+		total++
+		if !value.(Game).Metadata.Ready {
+			unready = append(unready, key.(GameKey))
+		}
+		return true
+	})
+
+	// fetch concurrently, relying on the cache's fetch limit to bound upstream load
+	type result struct {
+		key   GameKey
+		valid bool
+	}
+	results := make(chan result, len(unready))
+	for _, key := range unready {
+		go func(gameKey GameKey) {
+			_, valid := gc.GetOne(ctx, gameKey)
+			results <- result{gameKey, valid}
+		}(key)
+	}
+
+	var failed []GameKey
+	ready := total - len(unready)
+	for range unready {
+		r := <-results
+		if r.valid {
+			ready++
+		} else {
+			failed = append(failed, r.key)
+		}
+		if progress != nil {
+			progress(ready, total)
+		}
+	}
+
+	return failed
+}
+
 // remove a game from the cache
-func (gc *GameCache) Delete(id uint32) {
-	_, exists := gc.cache.Load(id)
+func (gc *GameCache) Delete(key GameKey) {
+	// must check the game existed before decrementing the length, atomically so concurrent deletes only count once
+	_, existed := gc.cache.LoadAndDelete(key)
+	if existed {
+		gc.notFound.Delete(key)
+		gc.failed.Delete(key)
+		gc.missing.Delete(key)
+		gc.onDemand.Delete(key)
+		gc.length.Add(-1)
+		if gc.removedRetention > 0 {
+			gc.removed.Store(key, gc.clock.Now())
+		}
+	}
+}
 
-	// must check if the game exists before decrementing the length
-	if exists {
-		gc.cache.Delete(id)
-		gc.length--
+// compare the cache against a complete schedule listing of the given dates, removing games that have been missing from it
+// for the cache's threshold of consecutive listings and returning them
+// a single missing listing is often an upstream blip, so games are only removed once they stay gone
+// only games discovered on one of the listed dates can be missing from it: games from a date that rolled over
+// (e.g. a live game past midnight) are left for the audit to prune once they're final,
+// and games tracked on demand were never on the schedule to begin with
+func (gc *GameCache) Reconcile(listed []GameKey, dates []string) []GameKey {
+	if gc.missingThreshold <= 0 {
+		return nil
 	}
+
+	onSchedule := make(map[GameKey]bool, len(listed))
+	for _, key := range listed {
+		onSchedule[key] = true
+	}
+
+	var removed []GameKey
+	gc.cache.Range(func(key, value interface{}) bool {
+		id, game := key.(GameKey), value.(Game)
+		if _, tracked := gc.onDemand.Load(id); tracked || onSchedule[id] || game.State.Status.General == StatusFinal ||
+			!slices.Contains(dates, game.scheduleDate) {
+			gc.missing.Delete(id)
+			return true
+		}
+
+		count := 1
+		if previous, exists := gc.missing.Load(id); exists {
+			count = previous.(int) + 1
+		}
+		gc.missing.Store(id, count)
+		if count >= gc.missingThreshold {
+			gc.Delete(id)
+			removed = append(removed, id)
+		}
+		return true
+	})
+	return removed
+}
+
+// find preview or postponed games that left the schedule as a game between the same teams appeared on it, i.e. postponed games
+// rescheduled under a new id, removing them from the cache and returning the old and new ids
+// listed is the complete schedule listing and added the games in it that were newly discovered
+// a game is only rescheduled under a new id of its own sport
+func (gc *GameCache) Rescheduled(listed []ScheduledGame, added []GameKey) []Reschedule {
+	if len(added) == 0 {
+		return nil
+	}
+
+	onSchedule := make(map[GameKey]bool, len(listed))
+	for _, sg := range listed {
+		onSchedule[sg.Key()] = true
+	}
+	var candidates []ScheduledGame
+	for _, sg := range listed {
+		if slices.Contains(added, sg.Key()) && sg.AwayTeam != "" && sg.HomeTeam != "" {
+			candidates = append(candidates, sg)
+		}
+	}
+
+	var rescheduled []Reschedule
+	gc.cache.Range(func(key, value interface{}) bool {
+		id, game := key.(GameKey), value.(Game)
+		// the MLB API lists postponed games as final, until they're rescheduled
+		postponed := strings.HasPrefix(strings.ToLower(game.State.Status.Detailed), "postponed")
+		if _, tracked := gc.onDemand.Load(id); tracked || onSchedule[id] || (game.State.Status.General != StatusPreview && !postponed) {
+			return true
+		}
+
+		for i, sg := range candidates {
+			if sg.Key().SportID == id.SportID && sg.AwayTeam == game.State.Teams.Away.Info.Name && sg.HomeTeam == game.State.Teams.Home.Info.Name {
+				rescheduled = append(rescheduled, Reschedule{ID: id.ID, NewID: sg.ID, SportID: id.SportID})
+				candidates = slices.Delete(candidates, i, i+1)
+				gc.Delete(id)
+				break
+			}
+		}
+		return true
+	})
+	return rescheduled
 }
 
 // refresh games and prune dead games
-func (gc *GameCache) Audit(ctx context.Context) ([]uint32, []uint32, []uint32) {
-	var updated, removed, failed []uint32
+// games due for a refresh are fetched in status priority order, so live games update before stale ones
+func (gc *GameCache) Audit(ctx context.Context) ([]GameKey, []GameKey, []GameKey) {
+	var updated, removed, failed []GameKey
+	var due []GameKey
+	status := make(map[GameKey]GameStatus)
+	fetched := make(map[GameKey]time.Time)
+	now := gc.clock.Now()
 	gc.cache.Range(func(key, value interface{}) bool {
 		game := value.(Game)
-		id := key.(uint32)
+		id := key.(GameKey)
+
+		// a configured disposition for the game's detailed state replaces the built-in pruning rules
+		disposition, configured := gc.dispositions.For(game)
+		if configured && disposition.Prunes(game, now) {
+			gc.disposed.Store(id, true)
+			gc.Delete(id)
+			removed = append(removed, id)
+			return true
+		}
 
 		// refresh live games
-		// also refresh preview and final games (less frequently)
-		if (game.State.Status.General == "Live" && time.Since(game.Metadata.Timestamp) > (5*time.Second)) ||
-			(game.State.Status.General == "Preview" && time.Since(game.Metadata.Timestamp) > (15*time.Minute)) ||
-			(game.State.Status.General == "Final" && time.Since(game.Metadata.Timestamp) > (30*time.Minute)) {
-			// refresh active games
-			dataChanged, err := gc.Fetch(ctx, id)
-			if err != nil {
-				failed = append(failed, id)
-			} else if dataChanged {
-				updated = append(updated, id)
-			}
-			// prune games that are final and started over 15 hours ago
-			// also prune games that don't start for 24 hours (postponed)
-		} else if (game.State.Status.General == "Final" && time.Since(game.State.Status.StartTime.DateTime) > (15*time.Hour)) ||
-			(game.State.Status.General == "Preview" && time.Until(game.Metadata.Timestamp) > (24*time.Hour)) {
+		// also refresh preview, final, and suspended games (less frequently, and previews more than a day out least of all)
+		// but never before the MLB API's caching hints say the data could have changed
+		age := now.Sub(game.Metadata.Timestamp)
+		previewRefresh := 15 * time.Minute
+		if game.State.Status.StartTime.DateTime.Sub(now) > 24*time.Hour {
+			previewRefresh = upcomingRefresh
+		}
+		isDue := (game.State.Suspended && age > (15*time.Minute)) ||
+			(!game.State.Suspended && game.State.Status.General == StatusLive && age > (5*time.Second)) ||
+			(game.State.Status.General == StatusPreview && age > previewRefresh) ||
+			(game.State.Status.General == StatusFinal && age > (30*time.Minute))
+		if isDue && !now.Before(game.refreshAfter) {
+			due = append(due, id)
+			status[id] = game.State.Status.General
+			fetched[id] = game.Metadata.Timestamp
+			// prune games that are final and started over 15 hours ago (suspended games are kept until they are resumed)
+			// also prune games that don't start for 24 hours (postponed), unless their detailed state has a disposition
+		} else if !configured && ((game.State.Status.General == StatusFinal && !game.State.Suspended && now.Sub(game.State.Status.StartTime.DateTime) > (15*time.Hour)) ||
+			(game.State.Status.General == StatusPreview && game.Metadata.Timestamp.Sub(now) > (24*time.Hour))) {
 			gc.Delete(id)
 			removed = append(removed, id)
 		}
 		return true
 	})
+
+	// within a status, the games refreshed longest ago go first, so games deferred by the budget aren't passed over again
+	sort.SliceStable(due, func(i, j int) bool {
+		if statusPriority[status[due[i]]] != statusPriority[status[due[j]]] {
+			return statusPriority[status[due[i]]] < statusPriority[status[due[j]]]
+		}
+		return fetched[due[i]].Before(fetched[due[j]])
+	})
+	if gc.fetchBudget > 0 && len(due) > gc.fetchBudget {
+		due = budgetFetches(due, status, gc.fetchBudget)
+	}
+
+	for _, id := range due {
+		// refresh active games
+		dataChanged, err := gc.Fetch(ctx, id)
+		if errors.Is(err, ErrGameNotFound) && gc.countNotFound(id) >= maxNotFound {
+			// the game was removed upstream (e.g. a canceled spring game)
+			gc.Delete(id)
+			removed = append(removed, id)
+		} else if err != nil {
+			failed = append(failed, id)
+		} else if dataChanged {
+			updated = append(updated, id)
+		}
+	}
 	return updated, removed, failed
 }
 
-// when a user first visits, get all games
-func GetInitialGames(gamesStore *GameCache) (*Games, error) {
+// pick the games to refresh within the budget, from games already in refresh order
+// live games go first, but a quarter of the budget (at least one fetch) is kept for the others so they aren't starved
+// when there are as many live games as the budget; either side's unused share goes to the other
+func budgetFetches(due []GameKey, status map[GameKey]GameStatus, budget int) []GameKey {
+	var live, others []GameKey
+	for _, key := range due {
+		if status[key] == StatusLive {
+			live = append(live, key)
+		} else {
+			others = append(others, key)
+		}
+	}
+
+	kept := min(len(others), max(1, budget/4))
+	live = live[:min(len(live), budget-kept)]
+	others = others[:min(len(others), budget-len(live))]
+	return append(live, others...)
+}
+
+// count another consecutive not found response for a game, returning the new count
+func (gc *GameCache) countNotFound(id GameKey) int {
+	count := 1
+	if previous, exists := gc.notFound.Load(id); exists {
+		count = previous.(int) + 1
+	}
+	gc.notFound.Store(id, count)
+	return count
+}
+
+// orderings available for the initial games
+const (
+	SortDefault = ""
+	SortRecent  = "recent"
+)
+
+// when a user first visits, get all games in the given order
+func GetInitialGames(gamesStore *GameCache, order string) (*Games, error) {
 	games, err := gamesStore.GetAll()
 
 	if err != nil {
 		return nil, err
 	}
 
-	sortGames(games)
+	switch order {
+	case SortDefault:
+		sortGames(games)
+	case SortRecent:
+		sortGamesRecent(games)
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", order)
+	}
 
+	// the envelope is only ready once every discovered game is settled, so clients know if more are coming
 	return &Games{
 		Metadata: Metadata{
-			Timestamp: time.Now(),
-			Ready:     true,
+			Timestamp: gamesStore.Clock().Now(),
+			Ready:     gamesStore.Pending() == 0,
 		},
-		Data: games,
+		Data:    games,
+		Removed: gamesStore.Removed(),
 	}, nil
 }
 
-// get formatted information on live games with a given date string MM/DD/YYYY (or "" to get today)
-func ListGamesByDate(ctx context.Context, logger *log.Logger, dateString string) ([]uint32, []string, error) {
-	// set the date for the game fetch
-	if dateString == "" {
-		// force LA time since server might change day early
-		pacificTime, err := time.LoadLocation("America/Los_Angeles")
-		if err != nil {
-			return nil, nil, err
+// keep only the games of a sport, counting games without a sport (e.g. from old recordings) as MLB
+func (g *Games) ForSport(sportID int) {
+	games := make([]*Game, 0, len(g.Data))
+	for _, game := range g.Data {
+		if game.SportID == sportID || (game.SportID == 0 && sportID == SportID) {
+			games = append(games, game)
+		}
+	}
+	g.Data = games
+}
+
+// drop final games that started more than maxAge before now, keeping all other games
+func (g *Games) WithoutFinalsOlderThan(now time.Time, maxAge time.Duration) {
+	games := make([]*Game, 0, len(g.Data))
+	for _, game := range g.Data {
+		if game.State.Status.General == StatusFinal && now.Sub(game.State.Status.StartTime.DateTime) > maxAge {
+			continue
+		}
+		games = append(games, game)
+	}
+	g.Data = games
+}
+
+// when the most recently changed game last changed, zero if there are no games
+// games that weren't fetched into the cache (e.g. from recordings) count as changed when they were fetched
+func (g *Games) LastModified() time.Time {
+	var latest time.Time
+	for _, game := range g.Data {
+		if changed := game.lastChanged(); changed.After(latest) {
+			latest = changed
+		}
+	}
+	return latest
+}
+
+// when the game last changed for clients, or when it was fetched if it wasn't fetched into the cache
+func (g *Game) lastChanged() time.Time {
+	if g.changedAt.IsZero() {
+		return g.Metadata.Timestamp
+	}
+	return g.changedAt
+}
+
+// get the schedule date string MM/DD/YYYY for a number of days from today on the clock in the given timezone (negative for past days)
+func ScheduleDate(clock Clock, loc *time.Location, daysFromToday int) string {
+	return clock.Now().In(loc).AddDate(0, 0, daysFromToday).Format("01/02/2006")
+}
+
+// list games with ListGamesByDate, taking "" to mean today on the clock in the given timezone
+// the server's own zone might change day early, so the configured one is used instead
+func ListGamesIn(clock Clock, loc *time.Location) ListFunc {
+	return func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]ScheduledGame, error) {
+		if dateString == "" {
+			dateString = ScheduleDate(clock, loc, 0)
 		}
-		dateString = time.Now().In(pacificTime).Format("01/02/2006")
+		return ListGamesByDate(ctx, logger, sportID, dateString)
+	}
+}
+
+// get formatted information on live games with a given date string MM/DD/YYYY
+// use ListGamesIn to list today's games without working out the date
+func ListGamesByDate(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]ScheduledGame, error) {
+	if dateString == "" {
+		return nil, fmt.Errorf("no date to list games on")
 	}
 
 	// get fields from struct
 	fieldsSchedule := generateFieldsString(api_data.Schedule{})
 
-	apiUrl := fmt.Sprintf("%s/api/v1/schedule/?sportId=1&date=%s&fields=%s", os.Getenv("MLB_API_URL"), dateString, fieldsSchedule)
+	apiUrl := fmt.Sprintf("%s?sportId=%d&date=%s&fields=%s", apiURL("/v1/schedule/"), sportID, dateString, fieldsSchedule)
 
 	// log request
 	logger.Printf("[INFO] Making request: %s", apiUrl)
 
 	// limit each fetch to 10 seconds
-	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, apiUrl, nil)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// get the list of today's games from MLB
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
 
-	// marshal the list of games into a struct
+	// marshal the list of games into a struct, keeping track of how much was pulled
+	body := scheduleUsage.count(io.LimitReader(resp.Body, maxResponseSize), apiUrl)
 	schedule := api_data.Schedule{}
-	err = schedule.FromJSON(resp.Body)
+	err = schedule.FromJSON(body)
+	body.done()
 	if err != nil {
-		return nil, nil, err
-	} else if len(schedule.Dates) == 0 {
-		return nil, nil, fmt.Errorf("schedule endpoint returned no games for provided date: %s", dateString)
+		return nil, err
+	}
+	// an off-day lists no dates at all, which is a complete (empty) listing rather than a failure
+	if len(schedule.Dates) == 0 {
+		return []ScheduledGame{}, nil
+	}
+
+	// the schedule can list a game more than once (e.g. split squad oddities), so keep only its first listing
+	games := make([]ScheduledGame, 0, len(schedule.Dates[0].Games))
+	seen := make(map[uint32]bool)
+	for _, game := range schedule.Dates[0].Games {
+		if seen[game.GamePk] {
+			logger.Printf("[WARN] Schedule for %s listed game %d more than once, keeping the first listing\r\n", dateString, game.GamePk)
+			continue
+		}
+		seen[game.GamePk] = true
+
+		games = append(games, ScheduledGame{
+			ID:                game.GamePk,
+			Link:              liveGameLink(game.Link),
+			Tie:               game.IsTie,
+			SportID:           sportID,
+			ScheduledInnings:  game.ScheduledInnings,
+			ReverseHomeAway:   game.ReverseHomeAwayStatus,
+			AwayTeam:          game.Teams.Away.Team.Name,
+			HomeTeam:          game.Teams.Home.Team.Name,
+			GameType:          game.GameType,
+			SeriesDescription: game.SeriesDescription,
+		})
 	}
+	return games, nil
+}
 
-	// get fields for links
-	fieldsLivegame := generateFieldsString(api_data.LiveGame{})
+// the path the MLB API is mounted under on the official host
+const defaultAPIPath = "/api"
 
-	gameIds := make([]uint32, len(schedule.Dates[0].Games))
-	gameLinks := make([]string, len(schedule.Dates[0].Games))
-	for gameNum := range schedule.Dates[0].Games {
-		gameIds[gameNum] = schedule.Dates[0].Games[gameNum].GamePk
-		// build the link with the desired fields
-		gameLinks[gameNum] = fmt.Sprintf("%s%s?fields=%s", os.Getenv("MLB_API_URL"), schedule.Dates[0].Games[gameNum].Link, fieldsLivegame)
+// get the path the MLB API is mounted under on MLB_API_URL, which mirrors and proxies may set with MLB_API_PATH
+// (e.g. "/statsapi", or "" for the root), without a trailing slash
+func APIPath() string {
+	path, set := os.LookupEnv("MLB_API_PATH")
+	if !set {
+		path = defaultAPIPath
 	}
-	return gameIds, gameLinks, nil
+	return strings.TrimSuffix(path, "/")
+}
+
+// build the full URL to a path under the MLB API's base path, e.g. "/v1/schedule"
+func apiURL(path string) string {
+	return os.Getenv("MLB_API_URL") + APIPath() + path
+}
+
+// build the live game link for a game id, for games that aren't on a fetched schedule
+func GameLink(id uint32) string {
+	return liveGameLink(apiURL(fmt.Sprintf("/v1.1/game/%d/feed/live", id)))
+}
+
+// build the full link to a live game with the desired fields from a link on the schedule
+// links are normally relative to the official host, so they are moved under the configured base path,
+// but mirrors may return absolute links or ones already under their own path, which are kept as they are
+func liveGameLink(link string) string {
+	if parsed, err := url.Parse(link); err != nil || !parsed.IsAbs() {
+		switch {
+		case APIPath() != "" && strings.HasPrefix(link, APIPath()+"/"):
+			link = os.Getenv("MLB_API_URL") + link
+		case strings.HasPrefix(link, defaultAPIPath+"/"):
+			link = apiURL(strings.TrimPrefix(link, defaultAPIPath))
+		default:
+			link = os.Getenv("MLB_API_URL") + link
+		}
+	}
+
+	separator := "?"
+	if strings.Contains(link, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%sfields=%s", link, separator, generateFieldsString(api_data.LiveGame{}))
 }
 
 // get game object given a link
 func FetchGame(ctx context.Context, link string) (Game, error) {
+	return fetchGame(ctx, link, RealClock)
+}
+
+// get game object given a link, stamped with the time on the clock
+func fetchGame(ctx context.Context, link string, clock Clock) (Game, error) {
 	// get information on the live game, from the link provided in the schedule response
 	// fmt.Printf("dispatching request for game %d at link %s\n", gameIndex, schedule.Dates[0].Games[gameIndex].Link)
 
 	// limit each fetch to 10 seconds
-	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, link, nil)
@@ -364,13 +1299,60 @@ func FetchGame(ctx context.Context, link string) (Game, error) {
 	defer resp.Body.Close()
 	// fmt.Printf("got game info for game %d; status %s\n", gameIndex, resp.Status)
 
+	// don't try to parse error responses as games
+	if resp.StatusCode == http.StatusNotFound {
+		return Game{}, fmt.Errorf("%w: %s", ErrGameNotFound, link)
+	} else if resp.StatusCode != http.StatusOK {
+		return Game{}, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
 	// marshal the live game data into a struct
+	body := gameUsage.count(io.LimitReader(resp.Body, maxResponseSize), link)
 	lg := api_data.LiveGame{}
-	err = lg.FromJSON(resp.Body)
+	err = lg.FromJSON(body)
+	body.done()
+	if err != nil {
+		return Game{}, fmt.Errorf("failed to decode live game from %s: %w", link, err)
+	}
+
+	// make sure the payload has what we need before building a game from it
+	err = lg.Validate()
 	if err != nil {
 		return Game{}, err
 	}
 
+	game := buildGame(&lg, link, clock.Now())
+	game.refreshAfter = parseRefreshAfter(resp.Header, game.Metadata.Timestamp)
+	return game, nil
+}
+
+// find the earliest time a response may change from its Cache-Control max-age or Expires headers
+// returns the zero time if the response has no usable caching hints
+func parseRefreshAfter(header http.Header, now time.Time) time.Time {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return time.Time{}
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err == nil && seconds > 0 {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+
+	// Expires is only consulted if there is no max-age
+	expires, err := http.ParseTime(header.Get("Expires"))
+	if err == nil && expires.After(now) {
+		return expires
+	}
+
+	return time.Time{}
+}
+
+// convert a live game response from the MLB API into a game object, stamped with now
+func buildGame(lg *api_data.LiveGame, link string, now time.Time) Game {
 	// make a map of the players in the game, starting with a null value
 	players := make(map[uint32]*Player)
 	players[0] = &Player{
@@ -391,19 +1373,26 @@ func FetchGame(ctx context.Context, link string) (Game, error) {
 	// set pitcher information based on game state
 	var pitcherHomeID uint32
 	var pitcherAwayID uint32
-	switch lg.GameData.Status.AbstractGameState {
-	case "Preview":
+	// an unknown status is passed through as-is and matches none of the cases below
+	general, _ := ParseGameStatus(lg.GameData.Status.AbstractGameState)
+	switch general {
+	case StatusPreview:
 		pitcherAwayID = lg.GameData.ProbablePitchers.Away.ID
 		pitcherHomeID = lg.GameData.ProbablePitchers.Home.ID
-	case "Live":
-		if lg.GameData.Teams.Away.Name == lg.LiveData.Linescore.Defense.Team.Name {
+	case StatusLive:
+		switch awayDefending, known := awayOnDefense(lg); {
+		// the linescore can briefly lack its teams, e.g. early in the 1st, so fall back to the probable pitchers
+		case !known:
+			pitcherAwayID = lg.GameData.ProbablePitchers.Away.ID
+			pitcherHomeID = lg.GameData.ProbablePitchers.Home.ID
+		case awayDefending:
 			pitcherAwayID = lg.LiveData.Linescore.Defense.Pitcher.ID
 			pitcherHomeID = lg.LiveData.Linescore.Offense.Pitcher.ID
-		} else {
+		default:
 			pitcherAwayID = lg.LiveData.Linescore.Offense.Pitcher.ID
 			pitcherHomeID = lg.LiveData.Linescore.Defense.Pitcher.ID
 		}
-	case "Final":
+	case StatusFinal:
 		if lg.LiveData.Linescore.Teams.Away.Runs > lg.LiveData.Linescore.Teams.Home.Runs {
 			pitcherAwayID = lg.LiveData.Decisions.Winner.ID
 			pitcherHomeID = lg.LiveData.Decisions.Loser.ID
@@ -413,23 +1402,48 @@ func FetchGame(ctx context.Context, link string) (Game, error) {
 		}
 	}
 
+	// look up a player, falling back to the null player if the payload references someone it doesn't include
+	player := func(id uint32) Player {
+		if p, ok := players[id]; ok {
+			return *p
+		}
+		return *players[0]
+	}
+
 	// set information about teams
+	pitcherHome := player(pitcherHomeID)
+	pitcherAway := player(pitcherAwayID)
+
+	// probable starters in a preview game carry their hand and season record
+	if general == StatusPreview {
+		pitcherHome = withStarterDetails(lg, pitcherHome)
+		pitcherAway = withStarterDetails(lg, pitcherAway)
+	}
+
+	// each team's current pitcher in a live game carries their pitch count from the boxscore
+	if general == StatusLive {
+		pitchCounts := make(map[uint32]uint16)
+		for _, team := range []api_data.BoxscoreTeam{lg.LiveData.Boxscore.Teams.Away, lg.LiveData.Boxscore.Teams.Home} {
+			for _, p := range team.Players {
+				pitchCounts[p.Person.ID] = p.Stats.Pitching.PitchesThrown
+			}
+		}
+		if pitcherHomeID != 0 {
+			pitcherHome.PitchCount = pitchCounts[pitcherHomeID]
+		}
+		if pitcherAwayID != 0 {
+			pitcherAway.PitchCount = pitchCounts[pitcherAwayID]
+		}
+	}
+
 	th := &Team{
-		Info: Info{
-			Name:         lg.GameData.Teams.Home.Name,
-			Abbreviation: lg.GameData.Teams.Home.Abbreviation,
-			League:       lg.GameData.Teams.Home.League.Name,
-		},
-		Pitcher: *players[pitcherHomeID],
+		Info:    teamInfo(lg.GameData.Teams.Home),
+		Pitcher: pitcherHome,
 		Score:   lg.LiveData.Linescore.Teams.Home.Runs,
 	}
 	ta := &Team{
-		Info: Info{
-			Name:         lg.GameData.Teams.Away.Name,
-			Abbreviation: lg.GameData.Teams.Away.Abbreviation,
-			League:       lg.GameData.Teams.Away.League.Name,
-		},
-		Pitcher: *players[pitcherAwayID],
+		Info:    teamInfo(lg.GameData.Teams.Away),
+		Pitcher: pitcherAway,
 		Score:   lg.LiveData.Linescore.Teams.Away.Runs,
 	}
 
@@ -440,28 +1454,65 @@ func FetchGame(ctx context.Context, link string) (Game, error) {
 			Home: *th,
 		},
 		Inning: Inning{
-			Number:     lg.LiveData.Linescore.CurrentInning,
-			Top_bottom: lg.LiveData.Linescore.InningHalf,
+			Number:      lg.LiveData.Linescore.CurrentInning,
+			Top_bottom:  lg.LiveData.Linescore.InningHalf,
+			InningState: lg.LiveData.Linescore.InningState,
 		},
 		Diamond: Diamond{
-			Batter: *players[lg.LiveData.Linescore.Offense.Batter.ID],
-			First:  *players[lg.LiveData.Linescore.Offense.First.ID],
-			Second: *players[lg.LiveData.Linescore.Offense.Second.ID],
-			Third:  *players[lg.LiveData.Linescore.Offense.Third.ID],
+			Batter: player(lg.LiveData.Linescore.Offense.Batter.ID),
+			First:  player(lg.LiveData.Linescore.Offense.First.ID),
+			Second: player(lg.LiveData.Linescore.Offense.Second.ID),
+			Third:  player(lg.LiveData.Linescore.Offense.Third.ID),
 		},
 		Outs: lg.LiveData.Linescore.Outs,
 		Status: Status{
-			General:   lg.GameData.Status.AbstractGameState,
+			General:   general,
 			Detailed:  lg.GameData.Status.DetailedState,
-			StartTime: lg.GameData.Datetime,
+			StartTime: api_data.Datetime{DateTime: lg.GameData.Datetime.DateTime},
 		},
 	}
 
+	if s.Status.General == StatusLive {
+		s.Inning.InningLabel = inningLabel(s.Inning)
+	}
+	s.DelayReason = parseDelayReason(s.Status.Detailed)
+	if resume := lg.GameData.Datetime.ResumeDateTime; strings.Contains(strings.ToLower(s.Status.Detailed), "delay") && !resume.IsZero() {
+		s.ResumptionTime = &resume
+	}
+
+	// "Suspended", "Suspended: Rain"
+	s.Suspended = strings.HasPrefix(s.Status.Detailed, "Suspended")
+
+	// flag stoppages in live games
+	// reviews come from liveData.plays.currentPlay.reviewDetails.inProgress (or a review detailedState),
+	// mound visits from the eventType of the last liveData.plays.currentPlay.playEvents entry
+	if s.Status.General == StatusLive {
+		detailed := strings.ToLower(s.Status.Detailed)
+		s.ReviewInProgress = lg.LiveData.Plays.CurrentPlay.ReviewDetails.InProgress ||
+			strings.Contains(detailed, "review") || strings.Contains(detailed, "challenge")
+
+		events := lg.LiveData.Plays.CurrentPlay.PlayEvents
+		s.MoundVisit = len(events) > 0 && events[len(events)-1].Details.EventType == "mound_visit"
+	}
+
+	// a final game with level scores ended in a tie (spring training and exhibitions)
+	// a suspended game is only level until it is resumed
+	// postponed and canceled games are also final at 0-0, but were never played
+	s.Tie = s.Status.General == StatusFinal && !s.Suspended && !notPlayed(s.Status.Detailed) && s.Teams.Home.Score == s.Teams.Away.Score
+	s.Winner = winner(*s)
+	s.RunDifferential = int(s.Teams.Home.Score) - int(s.Teams.Away.Score)
+	switch {
+	case s.RunDifferential > 0:
+		s.LastLeader = "home"
+	case s.RunDifferential < 0:
+		s.LastLeader = "away"
+	}
+
 	// catch API quirks in batter display
 	// 1. if the game hasn't started
 	// 2. if there are 3 outs, the team is still at bat but the other team's batter is up
 	// 3. they're batting and also on base
-	if s.Status.General != "Live" ||
+	if s.Status.General != StatusLive ||
 		s.Outs == 3 ||
 		s.Diamond.Batter == s.Diamond.First ||
 		s.Diamond.Batter == s.Diamond.Second ||
@@ -469,8 +1520,30 @@ func FetchGame(ctx context.Context, link string) (Game, error) {
 		s.Diamond.Batter = *players[0]
 	}
 
+	// the current batter in a live game carries their lineup spot and position from the boxscore
+	if s.Status.General == StatusLive {
+		s.Diamond.Batter = withLineupDetails(lg, s.Diamond.Batter)
+	}
+
+	// in extra innings, each half starts with a runner placed on second
+	s.secondPlaced = placedOnSecond(lg, *s)
+	s.Diamond.Second.AutomaticRunner = isAutomaticRunner(*s)
+
+	// work out who is batting, and late in live games, point out where the tying and go-ahead runs are
+	orientBatting(s)
+
+	// the crew, crowd and weather are only reported once a game is underway
+	if s.Status.General == StatusLive || s.Status.General == StatusFinal {
+		s.Umpires = listUmpires(lg)
+		s.Attendance = gameAttendance(lg)
+		s.FirstPitchTempF, _ = strconv.Atoi(strings.TrimSpace(lg.GameData.Weather.Temp))
+	}
+
+	// notable events come from the plays and linescore
+	s.Notables = findNotables(lg, *s, func(id uint32) string { return player(id).Name })
+
 	// update information for finalized games
-	if s.Status.General == "Final" {
+	if s.Status.General == StatusFinal {
 		// clear the batter
 		s.Diamond.Batter = *players[0]
 		// zero the outs
@@ -481,35 +1554,381 @@ func FetchGame(ctx context.Context, link string) (Game, error) {
 	// fmt.Printf("writing game data for %d\n", gameIndex)
 	// fmt.Printf("data: %v", lg)
 	return Game{
-		ID:    uint32(lg.GamePk),
-		Link:  link,
-		State: *s,
+		ID:      uint32(lg.GamePk),
+		Link:    link,
+		Matchup: fmt.Sprintf("%s @ %s", s.Teams.Away.Info.Abbreviation, s.Teams.Home.Info.Abbreviation),
+		State:   *s,
 		Metadata: Metadata{
-			Timestamp: time.Now(),
+			Timestamp: now,
 			Ready:     true,
 		},
-	}, nil
+	}
 }
 
-// sort games in-place
-func sortGames(games []*Game) {
-	statusOrder := map[string]int{
-		"Live":    0,
-		"Final":   1,
-		"Preview": 2,
+// whether the away team is on defense in a live game, judged by the linescore's defense team, or else its offense team
+// known is false if the linescore names neither team, so neither side's pitcher can be told apart
+func awayOnDefense(lg *api_data.LiveGame) (awayDefending bool, known bool) {
+	away, home := lg.GameData.Teams.Away.Name, lg.GameData.Teams.Home.Name
+	defense, offense := lg.LiveData.Linescore.Defense.Team.Name, lg.LiveData.Linescore.Offense.Team.Name
+	switch {
+	case defense != "" && defense == away, offense != "" && offense == home:
+		return true, true
+	case defense != "" && defense == home, offense != "" && offense == away:
+		return false, true
 	}
+	return false, false
+}
 
+// describe a team, filling in what partial feeds (e.g. some minor leagues early in the season) leave out
+// a missing abbreviation is derived from the name and a missing name from the abbreviation, so no team is nameless
+// a missing league is left empty
+func teamInfo(team api_data.Team2) Info {
+	info := Info{
+		Name:         strings.TrimSpace(team.Name),
+		Abbreviation: strings.TrimSpace(team.Abbreviation),
+		League:       team.League.Name,
+	}
+	if info.Name == "" {
+		info.Name = info.Abbreviation
+	}
+	if info.Name == "" {
+		info.Name = "TBD"
+	}
+	if info.Abbreviation == "" {
+		info.Abbreviation = abbreviate(info.Name)
+	}
+	return info
+}
+
+// abbreviate a team name to the initials of its first three words, e.g. "Toledo Mud Hens" to "TMH",
+// or a single-word name to its first three letters, e.g. "Athletics" to "ATH"
+func abbreviate(name string) string {
+	words := strings.Fields(strings.ToUpper(name))
+	if len(words) == 0 {
+		return ""
+	}
+	if len(words) == 1 {
+		letters := []rune(words[0])
+		return string(letters[:min(3, len(letters))])
+	}
+
+	var initials []rune
+	for _, word := range words[:min(3, len(words))] {
+		initials = append(initials, []rune(word)[0])
+	}
+	return string(initials)
+}
+
+// get the reason for a delay from a detailed state like "Delayed: Rain" or "Rain Delay"
+// returns an empty string for games that aren't delayed or don't give a reason
+func parseDelayReason(detailed string) string {
+	lower := strings.ToLower(detailed)
+	if !strings.Contains(lower, "delay") {
+		return ""
+	}
+
+	// "Delayed: Rain", "Delayed Start: Tarp on Field"
+	if _, reason, found := strings.Cut(detailed, ":"); found {
+		return strings.TrimSpace(reason)
+	}
+
+	// "Rain Delay"
+	if i := strings.Index(lower, " delay"); i > 0 {
+		return strings.TrimSpace(detailed[:i])
+	}
+
+	return ""
+}
+
+// label an inning for display, e.g. "Top 5th", preferring the inning state to the half so breaks read "Middle 3rd"
+func inningLabel(inning Inning) string {
+	if inning.Number == 0 {
+		return ""
+	}
+	state := inning.InningState
+	if state == "" {
+		state = inning.Top_bottom
+	}
+	if state == "" {
+		return ordinal(inning.Number)
+	}
+	return state + " " + ordinal(inning.Number)
+}
+
+// check whether the runner on second hasn't batted in the current half inning, judged by the batters of its plays
+func placedOnSecond(lg *api_data.LiveGame, s State) bool {
+	runner := s.Diamond.Second.ID
+	if runner == 0 {
+		return false
+	}
+	for _, play := range lg.LiveData.Plays.AllPlays {
+		if play.About.Inning == s.Inning.Number && strings.EqualFold(play.About.HalfInning, s.Inning.Top_bottom) && play.Matchup.Batter.ID == runner {
+			return false
+		}
+	}
+	return true
+}
+
+// check whether the runner on second of a live game is the automatic runner of an extra inning
+// the scheduled innings default to regulation until they're known from the schedule
+func isAutomaticRunner(s State) bool {
+	final := s.ScheduledInnings
+	if final == 0 {
+		final = regulationInnings
+	}
+	return s.Status.General == StatusLive && s.Inning.Number > final && s.secondPlaced
+}
+
+// check whether a game is scheduled for fewer innings than regulation, or ended before its scheduled innings
+func isShortened(s State) bool {
+	return s.ScheduledInnings < regulationInnings ||
+		(s.Status.General == StatusFinal && s.Inning.Number > 0 && s.Inning.Number < s.ScheduledInnings)
+}
+
+// check whether a final game's detailed state means it was never played, e.g. "Postponed" or "Cancelled"
+func notPlayed(detailed string) bool {
+	lower := strings.ToLower(detailed)
+	return strings.HasPrefix(lower, "postponed") || strings.HasPrefix(lower, "cancel")
+}
+
+// the winner of a final game from its scores, "tie" if it ended level, or empty if it isn't over
+func winner(s State) string {
+	switch {
+	case s.Status.General != StatusFinal || s.Suspended || notPlayed(s.Status.Detailed):
+		return ""
+	case s.Tie:
+		return "tie"
+	case s.Teams.Home.Score > s.Teams.Away.Score:
+		return "home"
+	case s.Teams.Away.Score > s.Teams.Home.Score:
+		return "away"
+	}
+	return "tie"
+}
+
+// carry the lead history of a game over to its refreshed state, counting a change when the other team has taken the lead
+func trackLeadChanges(old State, s *State) {
+	s.LeadChanges = old.LeadChanges
+	switch {
+	case s.LastLeader == "":
+		s.LastLeader = old.LastLeader
+	case old.LastLeader != "" && s.LastLeader != old.LastLeader:
+		s.LeadChanges++
+	}
+}
+
+// set the team at bat and, late in live games, where its tying and go-ahead runs are
+// the away team bats in the top of each inning, unless the game's home and away are reversed
+func orientBatting(s *State) {
+	s.Batting, s.TyingRun, s.GoAheadRun, s.SaveSituation = "", "", "", false
+	if s.Status.General != StatusLive {
+		return
+	}
+
+	// only while a half inning is being played
+	switch s.Inning.InningState {
+	case "Top":
+		s.Batting = "away"
+	case "Bottom":
+		s.Batting = "home"
+	default:
+		return
+	}
+	if s.ReverseHomeAway {
+		if s.Batting == "away" {
+			s.Batting = "home"
+		} else {
+			s.Batting = "away"
+		}
+	}
+
+	if s.Inning.Number >= 7 {
+		s.TyingRun, s.GoAheadRun = locateTyingRuns(s)
+	}
+	s.SaveSituation = isSaveSituation(*s)
+}
+
+// check whether a save is on the line, with the fielding team ahead in the final scheduled inning or later,
+// by no more than 3 runs or with the tying run on base, at bat, or on deck
+func isSaveSituation(s State) bool {
+	final := s.ScheduledInnings
+	if final == 0 {
+		final = regulationInnings
+	}
+	if s.Batting == "" || s.Inning.Number < final {
+		return false
+	}
+
+	var batting, fielding uint8
+	if s.Batting == "away" {
+		batting, fielding = s.Teams.Away.Score, s.Teams.Home.Score
+	} else {
+		batting, fielding = s.Teams.Home.Score, s.Teams.Away.Score
+	}
+	if fielding <= batting {
+		return false
+	}
+	lead := int(fielding - batting)
+
+	runners := 0
+	for _, runner := range []Player{s.Diamond.First, s.Diamond.Second, s.Diamond.Third} {
+		if runner.ID != 0 {
+			runners++
+		}
+	}
+	// the runners, the batter, and the batter on deck could each bring a run home
+	return lead <= 3 || lead <= runners+2
+}
+
+// find where the batting team's tying and go-ahead runs are, by how many runs they trail
+// runners score in order from third base back to the batter, so the n-th run needed is the n-th of them
+func locateTyingRuns(s *State) (string, string) {
+	var batting, fielding uint8
+	switch s.Batting {
+	case "away":
+		batting, fielding = s.Teams.Away.Score, s.Teams.Home.Score
+	case "home":
+		batting, fielding = s.Teams.Home.Score, s.Teams.Away.Score
+	default:
+		return "", ""
+	}
+	if batting > fielding {
+		return "", ""
+	}
+	deficit := int(fielding - batting)
+
+	var runs []string
+	for _, base := range []struct {
+		name   string
+		player Player
+	}{{"third", s.Diamond.Third}, {"second", s.Diamond.Second}, {"first", s.Diamond.First}, {"plate", s.Diamond.Batter}} {
+		if base.player.ID != 0 {
+			runs = append(runs, base.name)
+		}
+	}
+
+	run := func(n int) string {
+		if n < 1 || n > len(runs) {
+			return ""
+		}
+		return runs[n-1]
+	}
+	return run(deficit), run(deficit + 1)
+}
+
+// list a game's umpires from the boxscore officials, with the home plate umpire first
+func listUmpires(lg *api_data.LiveGame) []Umpire {
+	var umpires []Umpire
+	for _, official := range lg.LiveData.Boxscore.Officials {
+		if official.Official.FullName == "" {
+			continue
+		}
+		umpire := Umpire{Name: official.Official.FullName, Position: official.OfficialType}
+		if umpire.Position == "Home Plate" {
+			umpires = append([]Umpire{umpire}, umpires...)
+		} else {
+			umpires = append(umpires, umpire)
+		}
+	}
+	return umpires
+}
+
+// get a game's attendance, falling back to the "Att" line of the boxscore info (e.g. "35,123.") for feeds without gameInfo
+func gameAttendance(lg *api_data.LiveGame) uint32 {
+	if lg.GameData.GameInfo.Attendance > 0 {
+		return lg.GameData.GameInfo.Attendance
+	}
+	for _, info := range lg.LiveData.Boxscore.Info {
+		if info.Label != "Att" {
+			continue
+		}
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, info.Value)
+		if attendance, err := strconv.ParseUint(digits, 10, 32); err == nil {
+			return uint32(attendance)
+		}
+	}
+	return 0
+}
+
+// add a batter's lineup spot, position, and game line, leaving them empty when unavailable
+// the boxscore's battingOrder is the spot times 100, plus one for each substitute in that spot ("300", "301"),
+// and its batting summary separates hits from notable events with a bar ("1-3 | 2B, K")
+func withLineupDetails(lg *api_data.LiveGame, batter Player) Player {
+	if batter.ID == 0 {
+		return batter
+	}
+
+	for _, team := range []api_data.BoxscoreTeam{lg.LiveData.Boxscore.Teams.Away, lg.LiveData.Boxscore.Teams.Home} {
+		for _, p := range team.Players {
+			if p.Person.ID == batter.ID {
+				if order, err := strconv.Atoi(p.BattingOrder); err == nil && order >= 100 && order < 1000 {
+					batter.LineupSpot = uint8(order / 100)
+				}
+				batter.Position = p.Position.Abbreviation
+				batter.BatterGameLine = strings.Replace(p.Stats.Batting.Summary, " | ", ", ", 1)
+				return batter
+			}
+		}
+	}
+
+	return batter
+}
+
+// add a probable starter's throwing hand and season W-L record, leaving them empty when unavailable
+func withStarterDetails(lg *api_data.LiveGame, pitcher Player) Player {
+	if pitcher.ID == 0 {
+		return pitcher
+	}
+
+	for _, p := range lg.GameData.Players {
+		if p.ID == pitcher.ID {
+			pitcher.Hand = p.PitchHand.Code
+			break
+		}
+	}
+
+	for _, team := range []api_data.BoxscoreTeam{lg.LiveData.Boxscore.Teams.Away, lg.LiveData.Boxscore.Teams.Home} {
+		for _, p := range team.Players {
+			if p.Person.ID == pitcher.ID {
+				pitcher.Record = fmt.Sprintf("%d-%d", p.SeasonStats.Pitching.Wins, p.SeasonStats.Pitching.Losses)
+				return pitcher
+			}
+		}
+	}
+
+	return pitcher
+}
+
+// order in which games are listed and refreshed, by status
+var statusPriority = map[GameStatus]int{
+	StatusLive:    0,
+	StatusFinal:   1,
+	StatusPreview: 2,
+}
+
+// sort games in-place
+func sortGames(games []*Game) {
 	sort.Slice(games, func(i, j int) bool {
 		g1, g2 := games[i], games[j]
 
-		statusComp := statusOrder[g1.State.Status.General] - statusOrder[g2.State.Status.General]
+		statusComp := statusPriority[g1.State.Status.General] - statusPriority[g2.State.Status.General]
 		if statusComp != 0 {
 			return statusComp < 0
 		}
 
+		// float featured games to the top of their status
+		if g1.Featured != g2.Featured {
+			return g1.Featured
+		}
+
 		// disabled because it causes games to jump around as they outpace others
 		// // if both games are live, sort by inning number (higher first)
-		// if g1.State.Status.General == "Live" {
+		// if g1.State.Status.General == StatusLive {
 		// 	return g2.State.Inning.Number < g1.State.Inning.Number
 		// }
 
@@ -521,6 +1940,13 @@ func sortGames(games []*Game) {
 	})
 }
 
+// sort games in-place by most recently changed first, since every fetch refreshes a game's timestamp
+func sortGamesRecent(games []*Game) {
+	sort.SliceStable(games, func(i, j int) bool {
+		return games[i].lastChanged().After(games[j].lastChanged())
+	})
+}
+
 // recursive function to extract field names using JSON tags
 func extractFieldsFromStruct(t reflect.Type, prefix string) []string {
 	var fields []string
@@ -565,6 +1991,9 @@ func extractFieldsFromStruct(t reflect.Type, prefix string) []string {
 		if field.Type == reflect.TypeOf(time.Time{}) {
 			// edge case to handle time as a basic value
 			fields = append(fields, fullPath)
+		} else if (field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Array) && field.Type.Elem().Kind() != reflect.Struct {
+			// a list of basic values, e.g. indices, is a value itself
+			fields = append(fields, fullPath)
 		} else if field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Ptr || field.Type.Kind() == reflect.Map || field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Array {
 			// recursively extract fields from a nested struct
 			fields = append(fields, extractFieldsFromStruct(field.Type, fullPath)...)
@@ -1,7 +1,20 @@
 package data
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/claycot/mlb-gameday-api/api_data"
 	"github.com/stretchr/testify/assert"
@@ -9,7 +22,7 @@ import (
 
 // generate a csv string representing a struct's fields (including nesting)
 func TestGenerateFieldsStringSchedule(t *testing.T) {
-	expected := "dates,games,gamePk,dates,games,link"
+	expected := "dates,games,gamePk,dates,games,link,dates,games,gameType,dates,games,teams,away,team,id,dates,games,teams,away,team,name,dates,games,teams,away,score,dates,games,teams,home,team,id,dates,games,teams,home,team,name,dates,games,teams,home,score,dates,games,isTie,dates,games,scheduledInnings,dates,games,reverseHomeAwayStatus,dates,games,seriesDescription"
 
 	actual := generateFieldsString(api_data.Schedule{})
 
@@ -17,9 +30,1717 @@ func TestGenerateFieldsStringSchedule(t *testing.T) {
 }
 
 func TestGenerateFieldsStringLiveGame(t *testing.T) {
-	expected := "gamePk,gameData,datetime,dateTime,gameData,status,abstractGameState,gameData,status,detailedState,gameData,teams,away,name,gameData,teams,away,abbreviation,gameData,teams,away,league,name,gameData,teams,home,name,gameData,teams,home,abbreviation,gameData,teams,home,league,name,gameData,players,id,gameData,players,fullName,gameData,players,primaryNumber,gameData,probablePitchers,away,id,gameData,probablePitchers,home,id,liveData,linescore,currentInning,liveData,linescore,inningHalf,liveData,linescore,teams,home,runs,liveData,linescore,teams,away,runs,liveData,linescore,defense,pitcher,id,liveData,linescore,defense,team,name,liveData,linescore,defense,team,abbreviation,liveData,linescore,defense,team,league,name,liveData,linescore,offense,batter,id,liveData,linescore,offense,first,id,liveData,linescore,offense,second,id,liveData,linescore,offense,third,id,liveData,linescore,offense,pitcher,id,liveData,linescore,offense,team,name,liveData,linescore,outs,liveData,decisions,winner,id,liveData,decisions,loser,id"
+	expected := "gamePk,gameData,datetime,dateTime,gameData,datetime,resumeDateTime,gameData,status,abstractGameState,gameData,status,detailedState,gameData,teams,away,name,gameData,teams,away,abbreviation,gameData,teams,away,league,name,gameData,teams,home,name,gameData,teams,home,abbreviation,gameData,teams,home,league,name,gameData,players,id,gameData,players,fullName,gameData,players,primaryNumber,gameData,players,pitchHand,code,gameData,probablePitchers,away,id,gameData,probablePitchers,home,id,gameData,gameInfo,attendance,gameData,weather,temp,liveData,linescore,currentInning,liveData,linescore,inningHalf,liveData,linescore,inningState,liveData,linescore,teams,home,runs,liveData,linescore,teams,home,hits,liveData,linescore,teams,away,runs,liveData,linescore,teams,away,hits,liveData,linescore,defense,pitcher,id,liveData,linescore,defense,team,name,liveData,linescore,defense,team,abbreviation,liveData,linescore,defense,team,league,name,liveData,linescore,offense,batter,id,liveData,linescore,offense,first,id,liveData,linescore,offense,second,id,liveData,linescore,offense,third,id,liveData,linescore,offense,pitcher,id,liveData,linescore,offense,team,name,liveData,linescore,outs,liveData,decisions,winner,id,liveData,decisions,loser,id,liveData,boxscore,teams,away,players,person,id,liveData,boxscore,teams,away,players,stats,pitching,pitchesThrown,liveData,boxscore,teams,away,players,stats,batting,summary,liveData,boxscore,teams,away,players,seasonStats,pitching,wins,liveData,boxscore,teams,away,players,seasonStats,pitching,losses,liveData,boxscore,teams,away,players,battingOrder,liveData,boxscore,teams,away,players,position,abbreviation,liveData,boxscore,teams,home,players,person,id,liveData,boxscore,teams,home,players,stats,pitching,pitchesThrown,liveData,boxscore,teams,home,players,stats,batting,summary,liveData,boxscore,teams,home,players,seasonStats,pitching,wins,liveData,boxscore,teams,home,players,seasonStats,pitching,losses,liveData,boxscore,teams,home,players,battingOrder,liveData,boxscore,teams,home,players,position,abbreviation,liveData,boxscore,officials,official,fullName,liveData,boxscore,officials,officialType,liveData,boxscore,info,label,liveData,boxscore,info,value,liveData,plays,currentPlay,reviewDetails,inProgress,liveData,plays,currentPlay,playEvents,details,eventType,liveData,plays,currentPlay,playEvents,isPitch,liveData,plays,allPlays,about,inning,liveData,plays,allPlays,about,halfInning,liveData,plays,allPlays,about,isComplete,liveData,plays,allPlays,result,eventType,liveData,plays,allPlays,matchup,batter,id,liveData,plays,allPlays,matchup,pitcher,id,liveData,plays,allPlays,playEvents,details,eventType,liveData,plays,allPlays,playEvents,isPitch"
 
 	actual := generateFieldsString(api_data.LiveGame{})
 
 	assert.Equal(t, expected, actual, "fields should be correct for livegame endpoint")
 }
+
+// decode a live game payload and build the game from it
+func buildGameFromJSON(t *testing.T, payload string) Game {
+	t.Helper()
+	lg := api_data.LiveGame{}
+	err := lg.FromJSON(strings.NewReader(payload))
+	assert.NoError(t, err, "payload should decode")
+	return buildGame(&lg, "", time.Now())
+}
+
+func TestBuildGamePitchCountLive(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+			"players": {
+				"ID100": {"id": 100, "fullName": "Away Pitcher", "primaryNumber": "45"},
+				"ID200": {"id": 200, "fullName": "Home Pitcher", "primaryNumber": "12"}
+			}
+		},
+		"liveData": {
+			"linescore": {
+				"currentInning": 4,
+				"inningHalf": "Top",
+				"defense": {"pitcher": {"id": 200}, "team": {"name": "Home Team"}},
+				"offense": {"pitcher": {"id": 100}, "team": {"name": "Away Team"}}
+			},
+			"boxscore": {
+				"teams": {
+					"away": {"players": {"ID100": {"person": {"id": 100}, "stats": {"pitching": {"pitchesThrown": 58}}}}},
+					"home": {"players": {"ID200": {"person": {"id": 200}, "stats": {"pitching": {"pitchesThrown": 71}}}}}
+				}
+			}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.Equal(t, uint16(71), game.State.Teams.Home.Pitcher.PitchCount, "home pitcher should carry their pitch count")
+	assert.Equal(t, uint16(58), game.State.Teams.Away.Pitcher.PitchCount, "away pitcher should carry their pitch count")
+	assert.Equal(t, uint16(0), game.State.Diamond.Batter.PitchCount, "batters should not have a pitch count")
+}
+
+func TestBuildGameLiveWithoutLinescoreTeams(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+			"players": {
+				"ID100": {"id": 100, "fullName": "Away Starter", "primaryNumber": "45"},
+				"ID200": {"id": 200, "fullName": "Home Starter", "primaryNumber": "12"}
+			},
+			"probablePitchers": {"away": {"id": 100}, "home": {"id": 200}}
+		},
+		"liveData": {
+			"linescore": {"currentInning": 1, "inningHalf": "Top", "defense": {}, "offense": {}}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+	assert.Equal(t, "Away Starter", game.State.Teams.Away.Pitcher.Name, "the away probable pitcher should be kept rather than misassigned")
+	assert.Equal(t, "Home Starter", game.State.Teams.Home.Pitcher.Name)
+
+	// without probable pitchers either, both are left as TBD
+	payload = strings.Replace(payload, `"probablePitchers": {"away": {"id": 100}, "home": {"id": 200}}`, `"probablePitchers": {}`, 1)
+	game = buildGameFromJSON(t, payload)
+	assert.Equal(t, "TBD", game.State.Teams.Away.Pitcher.Name)
+	assert.Equal(t, "TBD", game.State.Teams.Home.Pitcher.Name)
+
+	// the offense team alone is enough to tell the pitchers apart
+	payload = strings.Replace(payload, `"defense": {}, "offense": {}`, `"defense": {"pitcher": {"id": 200}}, "offense": {"pitcher": {"id": 100}, "team": {"name": "Away Team"}}`, 1)
+	game = buildGameFromJSON(t, payload)
+	assert.Equal(t, "Away Starter", game.State.Teams.Away.Pitcher.Name)
+	assert.Equal(t, "Home Starter", game.State.Teams.Home.Pitcher.Name)
+}
+
+func TestBuildGameAutomaticRunner(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+			"players": {
+				"ID300": {"id": 300, "fullName": "Placed Runner", "primaryNumber": "7"},
+				"ID400": {"id": 400, "fullName": "Away Hitter", "primaryNumber": "22"}
+			}
+		},
+		"liveData": {
+			"linescore": {
+				"currentInning": 10,
+				"inningHalf": "Top",
+				"offense": {"batter": {"id": 400}, "second": {"id": 300}, "team": {"name": "Away Team"}},
+				"defense": {"team": {"name": "Home Team"}}
+			},
+			"plays": {"allPlays": [
+				{"about": {"inning": 9, "halfInning": "bottom", "isComplete": true}, "matchup": {"batter": {"id": 500}}},
+				{"about": {"inning": 10, "halfInning": "top"}, "matchup": {"batter": {"id": 400}}}
+			]}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+	assert.Equal(t, "Placed Runner", game.State.Diamond.Second.Name)
+	assert.True(t, game.State.Diamond.Second.AutomaticRunner, "the runner on second to start the 10th should be the automatic runner")
+	assert.False(t, game.State.Diamond.Batter.AutomaticRunner)
+
+	// a runner who doubled in the 10th earned second base
+	doubled := strings.Replace(payload, `"allPlays": [`, `"allPlays": [{"about": {"inning": 10, "halfInning": "top", "isComplete": true}, "matchup": {"batter": {"id": 300}}},`, 1)
+	assert.False(t, buildGameFromJSON(t, doubled).State.Diamond.Second.AutomaticRunner)
+
+	// and there are no automatic runners in regulation
+	ninth := strings.Replace(payload, `"currentInning": 10`, `"currentInning": 9`, 1)
+	assert.False(t, buildGameFromJSON(t, ninth).State.Diamond.Second.AutomaticRunner)
+}
+
+func TestBuildGameBatterLineupSpot(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+			"players": {
+				"ID300": {"id": 300, "fullName": "Away Hitter", "primaryNumber": "7"},
+				"ID400": {"id": 400, "fullName": "Pinch Hitter", "primaryNumber": "22"}
+			}
+		},
+		"liveData": {
+			"linescore": {
+				"currentInning": 6,
+				"inningHalf": "Top",
+				"offense": {"batter": {"id": 300}, "first": {"id": 400}, "team": {"name": "Away Team"}},
+				"defense": {"team": {"name": "Home Team"}}
+			},
+			"boxscore": {
+				"teams": {
+					"away": {"players": {
+						"ID300": {"person": {"id": 300}, "battingOrder": "300", "position": {"abbreviation": "DH"}, "stats": {"batting": {"summary": "1-3 | 2B, K"}}},
+						"ID400": {"person": {"id": 400}, "battingOrder": "801", "position": {"abbreviation": "PH"}, "stats": {"batting": {"summary": "0-1"}}}
+					}}
+				}
+			}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.Equal(t, uint8(3), game.State.Diamond.Batter.LineupSpot, "batter should carry their lineup spot")
+	assert.Equal(t, "DH", game.State.Diamond.Batter.Position, "batter should carry their position")
+	assert.Equal(t, "1-3, 2B, K", game.State.Diamond.Batter.BatterGameLine, "batter should carry their line in the game")
+	assert.Equal(t, uint8(0), game.State.Diamond.First.LineupSpot, "runners should not carry a lineup spot")
+	assert.Empty(t, game.State.Diamond.First.Position)
+	assert.Empty(t, game.State.Diamond.First.BatterGameLine, "runners should not carry a game line")
+}
+
+func TestBuildGamePitchCountPreview(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Preview", "detailedState": "Scheduled"},
+			"players": {"ID100": {"id": 100, "fullName": "Away Pitcher", "primaryNumber": "45"}},
+			"probablePitchers": {"away": {"id": 100}}
+		},
+		"liveData": {
+			"boxscore": {"teams": {"away": {"players": {"ID100": {"person": {"id": 100}, "stats": {"pitching": {"pitchesThrown": 90}}}}}}}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.Equal(t, uint16(0), game.State.Teams.Away.Pitcher.PitchCount, "pitch count should be zero outside of live games")
+}
+
+func TestBuildGameTiedFinal(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {"status": {"abstractGameState": "Final", "detailedState": "Final: Tied"}},
+		"liveData": {"linescore": {"currentInning": 9, "teams": {"home": {"runs": 3}, "away": {"runs": 3}}}}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.True(t, game.State.Tie, "final game with level scores should be a tie")
+}
+
+func TestBuildGamePostponedIsNotTie(t *testing.T) {
+	for _, detailed := range []string{"Postponed", "Cancelled"} {
+		payload := fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {"status": {"abstractGameState": "Final", "detailedState": %q}},
+			"liveData": {"linescore": {"teams": {"home": {"runs": 0}, "away": {"runs": 0}}}}
+		}`, detailed)
+
+		game := buildGameFromJSON(t, payload)
+
+		assert.False(t, game.State.Tie, "a %s game was never played, so it is not a tie", detailed)
+		assert.Empty(t, game.State.Winner, "a %s game has no winner", detailed)
+	}
+}
+
+func TestBuildGameWinner(t *testing.T) {
+	cases := []struct {
+		name   string
+		state  string
+		home   int
+		away   int
+		winner string
+	}{
+		{"home win", "Final", 5, 3, "home"},
+		{"away win", "Final", 2, 4, "away"},
+		{"tie", "Final", 3, 3, "tie"},
+		{"live", "Live", 5, 3, ""},
+	}
+	for _, c := range cases {
+		payload := fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {"status": {"abstractGameState": %q, "detailedState": %q}},
+			"liveData": {"linescore": {"currentInning": 9, "teams": {"home": {"runs": %d}, "away": {"runs": %d}}}}
+		}`, c.state, c.state, c.home, c.away)
+
+		game := buildGameFromJSON(t, payload)
+
+		assert.Equal(t, c.winner, game.State.Winner, c.name)
+	}
+}
+
+func TestBuildGameLevelLiveIsNotTie(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {"status": {"abstractGameState": "Live", "detailedState": "In Progress"}},
+		"liveData": {"linescore": {"currentInning": 5, "teams": {"home": {"runs": 2}, "away": {"runs": 2}}}}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.False(t, game.State.Tie, "live game with level scores should not be a tie")
+}
+
+func TestListGamesByDateDropsDuplicates(t *testing.T) {
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(rw, `{"dates": [{"games": [
+			{"gamePk": 1, "link": "/api/v1.1/game/1/feed/live", "scheduledInnings": 9},
+			{"gamePk": 2, "link": "/api/v1.1/game/2/feed/live", "scheduledInnings": 9},
+			{"gamePk": 1, "link": "/api/v1.1/game/1/feed/live/duplicate", "scheduledInnings": 7}
+		]}]}`)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	var logs strings.Builder
+	games, err := ListGamesByDate(context.Background(), log.New(&logs, "", 0), SportID, "07/04/2024")
+	assert.NoError(t, err)
+	if assert.Len(t, games, 2, "duplicate listings should be dropped") {
+		assert.Equal(t, uint32(1), games[0].ID)
+		assert.Equal(t, uint8(9), games[0].ScheduledInnings, "the first listing should be kept")
+		assert.Equal(t, uint32(2), games[1].ID)
+	}
+	assert.Contains(t, logs.String(), "listed game 1 more than once")
+}
+
+func TestListGamesByDateOffDay(t *testing.T) {
+	status := http.StatusOK
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(status)
+		fmt.Fprint(rw, `{"totalGames": 0, "dates": []}`)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	breaker := NewBreaker(1, time.Minute)
+	list := breaker.List(ListGamesByDate)
+	games, err := list(context.Background(), log.New(io.Discard, "", 0), SportID, "12/25/2024")
+	assert.NoError(t, err, "an off-day is an empty listing, not a failure")
+	assert.Empty(t, games)
+	assert.Equal(t, BreakerClosed, breaker.State(), "off-days should not count against the breaker")
+
+	status = http.StatusServiceUnavailable
+	_, err = list(context.Background(), log.New(io.Discard, "", 0), SportID, "12/25/2024")
+	assert.Error(t, err, "HTTP failures should still be errors")
+}
+
+func TestListGamesInListsTodayInTheZone(t *testing.T) {
+	var date string
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		date = r.URL.Query().Get("date")
+		fmt.Fprint(rw, `{"totalGames": 0, "dates": []}`)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	// late on the 4th in California, it is already the 5th in New York
+	clock := NewFakeClock(time.Date(2024, 7, 5, 4, 30, 0, 0, time.UTC))
+	for zone, today := range map[string]string{"America/Los_Angeles": "07/04/2024", "America/New_York": "07/05/2024"} {
+		loc, err := time.LoadLocation(zone)
+		assert.NoError(t, err)
+		_, err = ListGamesIn(clock, loc)(context.Background(), log.New(io.Discard, "", 0), SportID, "")
+		assert.NoError(t, err)
+		assert.Equal(t, today, date, "today should be taken in %s", zone)
+	}
+
+	_, err := ListGamesByDate(context.Background(), log.New(io.Discard, "", 0), SportID, "")
+	assert.Error(t, err, "listing needs a date")
+}
+
+func TestListGamesByDateCustomBasePath(t *testing.T) {
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/statsapi/v1/schedule/", r.URL.Path, "the schedule should be requested under the base path")
+		fmt.Fprint(rw, `{"dates": [{"games": [
+			{"gamePk": 1, "link": "/api/v1.1/game/1/feed/live"},
+			{"gamePk": 2, "link": "/statsapi/v1.1/game/2/feed/live"},
+			{"gamePk": 3, "link": "https://mirror.example.com/mlb/v1.1/game/3/feed/live"}
+		]}]}`)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+	t.Setenv("MLB_API_PATH", "/statsapi/")
+
+	games, err := ListGamesByDate(context.Background(), log.New(io.Discard, "", 0), SportID, "07/04/2024")
+	assert.NoError(t, err)
+	if assert.Len(t, games, 3) {
+		fields := "?fields=" + generateFieldsString(api_data.LiveGame{})
+		assert.Equal(t, mlb.URL+"/statsapi/v1.1/game/1/feed/live"+fields, games[0].Link, "links on the standard path should be moved under the base path")
+		assert.Equal(t, mlb.URL+"/statsapi/v1.1/game/2/feed/live"+fields, games[1].Link, "links already under the base path should be kept")
+		assert.Equal(t, "https://mirror.example.com/mlb/v1.1/game/3/feed/live"+fields, games[2].Link, "absolute links should be kept")
+	}
+	assert.Equal(t, mlb.URL+"/statsapi/v1.1/game/4/feed/live"+"?fields="+generateFieldsString(api_data.LiveGame{}), GameLink(4))
+}
+
+func TestDiscoverTieFromSchedule(t *testing.T) {
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{ID: 1, Metadata: Metadata{Ready: true}}, nil
+	}, 0)
+
+	discovered, err := gc.Discover(ScheduledGame{ID: 1, Link: "link", Tie: true})
+	assert.NoError(t, err)
+	assert.True(t, discovered)
+
+	game, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+	assert.True(t, game.State.Tie, "tie from the schedule should survive a fetch")
+}
+
+// game ids are only unique within a sport, so the same id can be tracked for each
+func TestDiscoverSameIDInTwoSports(t *testing.T) {
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{ID: 1, Link: link, Metadata: Metadata{Ready: true}}, nil
+	}, 0)
+
+	for _, sg := range []ScheduledGame{{ID: 1, Link: "mlb", SportID: SportID}, {ID: 1, Link: "aaa", SportID: 11}} {
+		discovered, err := gc.Discover(sg)
+		assert.NoError(t, err)
+		assert.True(t, discovered)
+	}
+
+	mlb, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+	assert.Equal(t, "mlb", mlb.Link)
+	aaa, valid := gc.GetOne(context.Background(), KeyOf(11, 1))
+	assert.True(t, valid)
+	assert.Equal(t, "aaa", aaa.Link)
+	assert.Equal(t, 11, aaa.SportID)
+
+	gc.Delete(MLBKey(1))
+	_, valid = gc.GetOne(context.Background(), KeyOf(11, 1))
+	assert.True(t, valid, "deleting a game should leave the other sport's game alone")
+}
+
+func TestSortModes(t *testing.T) {
+	now := time.Now()
+	newGame := func(id uint32, status GameStatus, start time.Time, updated time.Time) *Game {
+		return &Game{
+			ID:       id,
+			Metadata: Metadata{Timestamp: updated, Ready: true},
+			State:    State{Status: Status{General: status, StartTime: api_data.Datetime{DateTime: start}}},
+		}
+	}
+	ids := func(games []*Game) []uint32 {
+		out := make([]uint32, len(games))
+		for i, g := range games {
+			out[i] = g.ID
+		}
+		return out
+	}
+
+	games := []*Game{
+		newGame(1, "Preview", now.Add(2*time.Hour), now.Add(-1*time.Minute)),
+		newGame(2, "Final", now.Add(-5*time.Hour), now.Add(-30*time.Minute)),
+		newGame(3, "Live", now.Add(-1*time.Hour), now.Add(-10*time.Second)),
+		newGame(4, "Live", now.Add(-2*time.Hour), now.Add(-5*time.Minute)),
+	}
+
+	sortGames(games)
+	assert.Equal(t, []uint32{4, 3, 2, 1}, ids(games), "default sort should order by status, then start time")
+
+	sortGamesRecent(games)
+	assert.Equal(t, []uint32{3, 1, 4, 2}, ids(games), "recent sort should order by last update, newest first")
+
+	// a game refetched just now without changing keeps its place
+	games[3].changedAt = games[3].Metadata.Timestamp
+	games[3].Metadata.Timestamp = now
+	sortGamesRecent(games)
+	assert.Equal(t, []uint32{3, 1, 4, 2}, ids(games), "recent sort should order by last change, not last fetch")
+}
+
+func TestGetInitialGamesUnknownSort(t *testing.T) {
+	_, err := GetInitialGames(NewGameCache(nil, 0), "bogus")
+	assert.Error(t, err, "unknown sort orders should be rejected")
+}
+
+func TestBuildGameReviewInProgress(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {"status": {"abstractGameState": "Live", "detailedState": "In Progress"}},
+		"liveData": {
+			"linescore": {"currentInning": 6},
+			"plays": {"currentPlay": {
+				"reviewDetails": {"inProgress": true},
+				"playEvents": [{"details": {"eventType": "mound_visit"}}]
+			}}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.True(t, game.State.ReviewInProgress, "review in progress should be flagged")
+	assert.True(t, game.State.MoundVisit, "mound visit as the latest event should be flagged")
+}
+
+func TestBuildGameReviewClearedWhenNotLive(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {"status": {"abstractGameState": "Final", "detailedState": "Final"}},
+		"liveData": {"plays": {"currentPlay": {"reviewDetails": {"inProgress": true}}}}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.False(t, game.State.ReviewInProgress, "reviews should only be flagged for live games")
+	assert.False(t, game.State.MoundVisit, "mound visits should only be flagged for live games")
+}
+
+func TestBuildGameInningState(t *testing.T) {
+	cases := []struct {
+		half     string
+		state    string
+		expected string
+	}{
+		{"Top", "Top", "Top"},
+		{"Top", "Middle", "Middle"},
+		{"Bottom", "Bottom", "Bottom"},
+		{"Bottom", "End", "End"},
+	}
+
+	for _, c := range cases {
+		payload := fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {"status": {"abstractGameState": "Live"}},
+			"liveData": {"linescore": {"currentInning": 5, "inningHalf": %q, "inningState": %q}}
+		}`, c.half, c.state)
+
+		game := buildGameFromJSON(t, payload)
+
+		assert.Equal(t, c.expected, game.State.Inning.InningState, "inning state should come from the linescore")
+		assert.Equal(t, c.half, game.State.Inning.Top_bottom, "inning half should be kept for compatibility")
+	}
+}
+
+func TestBuildGameInningLabel(t *testing.T) {
+	cases := []struct {
+		inning   int
+		state    string
+		expected string
+	}{
+		{1, "Top", "Top 1st"},
+		{2, "Bottom", "Bottom 2nd"},
+		{3, "Middle", "Middle 3rd"},
+		{5, "Top", "Top 5th"},
+		{9, "End", "End 9th"},
+		{11, "Top", "Top 11th"},
+		{12, "Bottom", "Bottom 12th"},
+		{13, "Middle", "Middle 13th"},
+		{21, "Top", "Top 21st"},
+		{22, "Bottom", "Bottom 22nd"},
+	}
+
+	for _, c := range cases {
+		payload := fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {"status": {"abstractGameState": "Live"}},
+			"liveData": {"linescore": {"currentInning": %d, "inningHalf": "Top", "inningState": %q}}
+		}`, c.inning, c.state)
+
+		game := buildGameFromJSON(t, payload)
+
+		assert.Equal(t, c.expected, game.State.Inning.InningLabel)
+	}
+
+	// games that aren't underway have no inning to label
+	preview := buildGameFromJSON(t, `{"gamePk": 1, "gameData": {"status": {"abstractGameState": "Preview"}}}`)
+	assert.Empty(t, preview.State.Inning.InningLabel)
+}
+
+func TestFetchGameRejectsUnusablePayloads(t *testing.T) {
+	cases := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{"garbage", `<html>503 Service Unavailable</html>`, "failed to decode live game"},
+		{"truncated", `{"gamePk": 1, "gameData": {"status": {"abstr`, "failed to decode live game"},
+		{"empty object", `{}`, "missing gamePk"},
+		{"missing teams", `{"gamePk": 1, "gameData": {"status": {"abstractGameState": "Live"}}}`, "missing gameData.teams.away.name"},
+	}
+
+	for _, c := range cases {
+		mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(rw, c.payload)
+		}))
+
+		_, err := FetchGame(context.Background(), mlb.URL)
+		if assert.Error(t, err, c.name) {
+			assert.Contains(t, err.Error(), c.expected, c.name)
+		}
+		mlb.Close()
+	}
+}
+
+func TestFetchGameLimitsResponseSize(t *testing.T) {
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(rw, `{"gamePk": 1, "padding": "%s"}`, strings.Repeat("x", maxResponseSize))
+	}))
+	defer mlb.Close()
+
+	_, err := FetchGame(context.Background(), mlb.URL)
+	if assert.Error(t, err, "oversized payloads should be cut off") {
+		assert.Contains(t, err.Error(), "failed to decode live game")
+	}
+}
+
+func TestBuildGameUnknownPlayers(t *testing.T) {
+	game := buildGameFromJSON(t, `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}
+		},
+		"liveData": {
+			"linescore": {
+				"defense": {"pitcher": {"id": 200}, "team": {"name": "Home Team"}},
+				"offense": {"pitcher": {"id": 100}, "batter": {"id": 300}, "first": {"id": 400}}
+			}
+		}
+	}`)
+
+	assert.Equal(t, "TBD", game.State.Teams.Home.Pitcher.Name, "players missing from the payload should be TBD")
+	assert.Equal(t, "TBD", game.State.Diamond.First.Name, "players missing from the payload should be TBD")
+}
+
+func TestFetchGameParsesMaxAge(t *testing.T) {
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Cache-Control", "public, max-age=600")
+		fmt.Fprint(rw, `{"gamePk": 1, "gameData": {"status": {"abstractGameState": "Final"}, "teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}}}`)
+	}))
+	defer mlb.Close()
+
+	game, err := FetchGame(context.Background(), mlb.URL)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(600*time.Second), game.refreshAfter, 5*time.Second, "refresh should wait for max-age")
+}
+
+func TestParseRefreshAfter(t *testing.T) {
+	now := time.Date(2024, 4, 1, 19, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("Expires", now.Add(time.Hour).Format(http.TimeFormat))
+	assert.Equal(t, now.Add(time.Hour), parseRefreshAfter(header, now), "expires should be used without max-age")
+
+	header.Set("Cache-Control", "max-age=30")
+	assert.Equal(t, now.Add(30*time.Second), parseRefreshAfter(header, now), "max-age should take precedence over expires")
+
+	header.Set("Cache-Control", "no-cache")
+	assert.True(t, parseRefreshAfter(header, now).IsZero(), "no-cache should allow refetching any time")
+}
+
+func TestAuditRespectsRefreshAfter(t *testing.T) {
+	fetches := 0
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		fetches++
+		return Game{
+			ID:           1,
+			Metadata:     Metadata{Timestamp: time.Now().Add(-time.Hour), Ready: true},
+			State:        State{Status: Status{General: "Final"}},
+			refreshAfter: time.Now().Add(10 * time.Minute),
+		}, nil
+	}, 0)
+
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+	_, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+	assert.Equal(t, 1, fetches)
+
+	// the game is due by status, but the caching hint hasn't expired
+	gc.Audit(context.Background())
+	assert.Equal(t, 1, fetches, "audit should not refetch before the caching hint expires")
+}
+
+func TestAuditRefreshBoundaries(t *testing.T) {
+	cases := []struct {
+		status   GameStatus
+		interval time.Duration
+	}{
+		{StatusLive, 5 * time.Second},
+		{StatusPreview, 15 * time.Minute},
+		{StatusFinal, 30 * time.Minute},
+	}
+
+	for _, c := range cases {
+		clock := NewFakeClock(time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC))
+		fetches := 0
+		gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+			fetches++
+			return Game{
+				ID:       1,
+				Link:     link,
+				Metadata: Metadata{Timestamp: clock.Now(), Ready: true},
+				// fetched games keep changing, so every refresh counts as an update
+				State: State{Status: Status{General: c.status, Detailed: strconv.Itoa(fetches), StartTime: api_data.Datetime{DateTime: clock.Now()}}},
+			}, nil
+		}, 0)
+		gc.SetClock(clock)
+
+		_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+		assert.NoError(t, err)
+		_, err = gc.Fetch(context.Background(), MLBKey(1))
+		assert.NoError(t, err)
+
+		clock.Advance(c.interval)
+		updated, _, _ := gc.Audit(context.Background())
+		assert.Empty(t, updated, "%s game should not be refreshed at exactly %v", c.status, c.interval)
+
+		clock.Advance(time.Nanosecond)
+		updated, _, _ = gc.Audit(context.Background())
+		assert.Equal(t, []GameKey{MLBKey(1)}, updated, "%s game should be refreshed after %v", c.status, c.interval)
+	}
+}
+
+func TestAuditPruneBoundaries(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC))
+	start := clock.Now()
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{
+			ID:       1,
+			Link:     link,
+			Metadata: Metadata{Timestamp: clock.Now(), Ready: true},
+			State:    State{Status: Status{General: StatusFinal, StartTime: api_data.Datetime{DateTime: start}}},
+		}, nil
+	}, 0)
+	gc.SetClock(clock)
+
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+	_, err = gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+
+	// keep the game fresh so it is considered for pruning rather than refreshing
+	clock.Advance(15 * time.Hour)
+	_, err = gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+
+	_, removed, _ := gc.Audit(context.Background())
+	assert.Empty(t, removed, "final game should be kept until 15 hours after it started")
+
+	clock.Advance(time.Nanosecond)
+	_, removed, _ = gc.Audit(context.Background())
+	assert.Equal(t, []GameKey{MLBKey(1)}, removed, "final game should be pruned 15 hours after it started")
+}
+
+func TestInitialGamesListRecentlyRemoved(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC))
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{Link: link, Metadata: Metadata{Timestamp: clock.Now(), Ready: true}, State: State{Status: Status{General: StatusLive}}}, nil
+	}, 0)
+	gc.SetClock(clock)
+	gc.SetRemovedRetention(time.Hour)
+
+	for _, id := range []uint32{1, 2, 3} {
+		_, err := gc.Discover(ScheduledGame{ID: id, Link: strconv.Itoa(int(id))})
+		assert.NoError(t, err)
+	}
+	gc.Warm(context.Background(), nil)
+	gc.Delete(MLBKey(3))
+	gc.Delete(MLBKey(1))
+
+	games, err := GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Len(t, games.Data, 1)
+	assert.Equal(t, []uint32{1, 3}, games.Removed, "recently removed games should be listed")
+
+	// a removed game can come back
+	_, err = gc.Discover(ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3}, gc.Removed(), "rediscovered games are no longer removed")
+
+	clock.Advance(time.Hour + time.Nanosecond)
+	games, err = GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Empty(t, games.Removed, "removed games should be forgotten after the retention window")
+}
+
+func TestAuditEvictsGamesThatKeepNotFound(t *testing.T) {
+	found := true
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		if !found {
+			return Game{}, ErrGameNotFound
+		}
+		return Game{
+			ID:       1,
+			Metadata: Metadata{Timestamp: time.Now().Add(-time.Minute), Ready: true},
+			State:    State{Status: Status{General: "Live"}},
+		}, nil
+	}, 0)
+
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+	_, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+
+	// the game disappears upstream
+	found = false
+	for attempt := 1; attempt < maxNotFound; attempt++ {
+		_, removed, failed := gc.Audit(context.Background())
+		assert.Empty(t, removed, "game should be kept after %d not found responses", attempt)
+		assert.Equal(t, []GameKey{MLBKey(1)}, failed)
+	}
+
+	_, removed, failed := gc.Audit(context.Background())
+	assert.Equal(t, []GameKey{MLBKey(1)}, removed, "game should be evicted after repeated not found responses")
+	assert.Empty(t, failed)
+	_, valid = gc.GetOne(context.Background(), MLBKey(1))
+	assert.False(t, valid)
+}
+
+func TestAuditResetsNotFoundOnOtherResponses(t *testing.T) {
+	var fetchErr error
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		if fetchErr != nil {
+			return Game{}, fetchErr
+		}
+		return Game{
+			ID:       1,
+			Metadata: Metadata{Timestamp: time.Now().Add(-time.Minute), Ready: true},
+			State:    State{Status: Status{General: "Live"}},
+		}, nil
+	}, 0)
+
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+	_, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+
+	// not found responses broken up by server errors are never consecutive
+	for attempt := 0; attempt < 2*maxNotFound; attempt++ {
+		fetchErr = ErrGameNotFound
+		if attempt%2 == 1 {
+			fetchErr = errors.New("unexpected response from MLB API: 500 Internal Server Error")
+		}
+		_, removed, failed := gc.Audit(context.Background())
+		assert.Empty(t, removed, "game should be kept after attempt %d", attempt)
+		assert.Equal(t, []GameKey{MLBKey(1)}, failed)
+	}
+	_, valid = gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+}
+
+func TestAuditKeepsSuspendedGames(t *testing.T) {
+	start := time.Now().Add(-30 * time.Hour)
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		game := Game{
+			Metadata:     Metadata{Timestamp: time.Now(), Ready: true},
+			State:        State{Status: Status{General: StatusFinal, StartTime: api_data.Datetime{DateTime: start}}},
+			refreshAfter: time.Now().Add(time.Hour),
+		}
+		if link == "suspended" {
+			game.ID = 1
+			game.State.Status.Detailed = "Suspended: Rain"
+			game.State.Suspended = true
+		} else {
+			game.ID = 2
+			game.State.Status.Detailed = "Final"
+		}
+		return game, nil
+	}, 0)
+
+	for _, sg := range []ScheduledGame{{ID: 1, Link: "suspended"}, {ID: 2, Link: "final"}} {
+		_, err := gc.Discover(sg)
+		assert.NoError(t, err)
+		_, valid := gc.GetOne(context.Background(), sg.Key())
+		assert.True(t, valid)
+	}
+
+	_, removed, _ := gc.Audit(context.Background())
+	assert.Equal(t, []GameKey{MLBKey(2)}, removed, "only the completed game should be pruned")
+	_, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid, "suspended game should be kept until it is resumed")
+}
+
+func TestBuildGameSuspended(t *testing.T) {
+	game := buildGameFromJSON(t, `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Final", "detailedState": "Suspended: Rain"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}
+		},
+		"liveData": {"linescore": {"teams": {"away": {"runs": 2}, "home": {"runs": 2}}}}
+	}`)
+
+	assert.True(t, game.State.Suspended)
+	assert.False(t, game.State.Tie, "a suspended game is not a tie")
+}
+
+func TestAuditRefreshesLiveGamesFirst(t *testing.T) {
+	statuses := map[string]GameStatus{"1": StatusFinal, "2": StatusLive, "3": StatusPreview, "4": StatusLive, "5": StatusFinal}
+	var order []string
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		order = append(order, link)
+		return Game{
+			Link:     link,
+			Metadata: Metadata{Timestamp: time.Now().Add(-time.Hour), Ready: true},
+			State:    State{Status: Status{General: statuses[link], StartTime: api_data.Datetime{DateTime: time.Now()}}},
+		}, nil
+	}, 0)
+
+	for link := range statuses {
+		id, _ := strconv.ParseUint(link, 10, 32)
+		_, err := gc.Discover(ScheduledGame{ID: uint32(id), Link: link})
+		assert.NoError(t, err)
+		gc.GetOne(context.Background(), MLBKey(uint32(id)))
+	}
+
+	order = nil
+	gc.Audit(context.Background())
+	assert.Len(t, order, 5)
+	for i, link := range order {
+		assert.GreaterOrEqual(t, statusPriority[statuses[link]], statusPriority[statuses[order[max(i-1, 0)]]],
+			"games should be refreshed live first, then final, then preview: %v", order)
+	}
+	assert.Equal(t, StatusLive, statuses[order[0]])
+	assert.Equal(t, StatusLive, statuses[order[1]])
+}
+
+func TestAuditRespectsFetchBudget(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC))
+	statuses := map[string]GameStatus{"1": StatusFinal, "2": StatusLive, "3": StatusLive, "4": StatusFinal, "5": StatusFinal}
+	var fetched []string
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		fetched = append(fetched, link)
+		return Game{
+			Link:     link,
+			Metadata: Metadata{Timestamp: clock.Now(), Ready: true},
+			State:    State{Status: Status{General: statuses[link], StartTime: api_data.Datetime{DateTime: clock.Now()}}},
+		}, nil
+	}, 0)
+	gc.SetClock(clock)
+	gc.SetFetchBudget(2)
+
+	for link := range statuses {
+		id, _ := strconv.ParseUint(link, 10, 32)
+		_, err := gc.Discover(ScheduledGame{ID: uint32(id), Link: link})
+		assert.NoError(t, err)
+		gc.GetOne(context.Background(), MLBKey(uint32(id)))
+	}
+
+	// every game is due, but only the budget is refreshed, live games first with a fetch kept for the others
+	clock.Advance(time.Hour)
+	fetched = nil
+	gc.Audit(context.Background())
+	if assert.Len(t, fetched, 2) {
+		assert.Equal(t, StatusLive, statuses[fetched[0]])
+		assert.Equal(t, StatusFinal, statuses[fetched[1]])
+	}
+
+	// the deferred games are refreshed on the following audits, ahead of games that were just refreshed
+	clock.Advance(time.Second)
+	gc.Audit(context.Background())
+	clock.Advance(time.Second)
+	gc.Audit(context.Background())
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4", "5"}, fetched, "no deferred game should be passed over")
+}
+
+// with as many live games as the budget, the other games still get refreshed
+func TestAuditFetchBudgetDoesNotStarvePreviews(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC))
+	statuses := map[string]GameStatus{"1": StatusLive, "2": StatusLive, "3": StatusLive, "4": StatusLive, "5": StatusPreview}
+	var fetched []string
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		fetched = append(fetched, link)
+		return Game{
+			Link:     link,
+			Metadata: Metadata{Timestamp: clock.Now(), Ready: true},
+			State:    State{Status: Status{General: statuses[link], StartTime: api_data.Datetime{DateTime: clock.Now().Add(time.Hour)}}},
+		}, nil
+	}, 0)
+	gc.SetClock(clock)
+	gc.SetFetchBudget(4)
+
+	for link := range statuses {
+		id, _ := strconv.ParseUint(link, 10, 32)
+		_, err := gc.Discover(ScheduledGame{ID: uint32(id), Link: link})
+		assert.NoError(t, err)
+		gc.GetOne(context.Background(), MLBKey(uint32(id)))
+	}
+
+	// the live games are due on every audit, and the preview is due too
+	clock.Advance(time.Hour)
+	fetched = nil
+	gc.Audit(context.Background())
+	assert.Len(t, fetched, 4)
+	assert.Contains(t, fetched, "5", "the preview should get a share of the budget")
+
+	// with the preview refreshed, the whole budget goes to live games again
+	clock.Advance(10 * time.Second)
+	fetched = nil
+	gc.Audit(context.Background())
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, fetched)
+}
+
+// run with -race to catch unsynchronized access to the cache length
+func TestGameCacheConcurrentDiscoverDelete(t *testing.T) {
+	gc := NewGameCache(nil, 0)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				id := uint32(i % 100)
+				if (i+worker)%3 == 0 {
+					gc.Delete(MLBKey(id))
+				} else {
+					_, err := gc.Discover(ScheduledGame{ID: id, Link: "link"})
+					assert.NoError(t, err)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	cached := 0
+	gc.cache.Range(func(key, value interface{}) bool {
+		cached++
+		return true
+	})
+	assert.Equal(t, int32(cached), gc.length.Load(), "length should match the games in the cache")
+}
+
+func TestGameCacheFull(t *testing.T) {
+	gc := NewGameCache(nil, 0)
+	for id := uint32(1); id <= maxGames; id++ {
+		_, err := gc.Discover(ScheduledGame{ID: id})
+		assert.NoError(t, err)
+	}
+
+	_, err := gc.Discover(ScheduledGame{ID: maxGames + 1})
+	assert.Error(t, err, "discovering past the limit should fail")
+	assert.Equal(t, int32(maxGames), gc.length.Load())
+}
+
+func TestBuildGameProbableStarters(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Preview", "detailedState": "Scheduled"},
+			"players": {
+				"ID100": {"id": 100, "fullName": "Away Starter", "primaryNumber": "45", "pitchHand": {"code": "L"}},
+				"ID200": {"id": 200, "fullName": "Home Starter", "primaryNumber": "12", "pitchHand": {"code": "R"}}
+			},
+			"probablePitchers": {"away": {"id": 100}, "home": {"id": 200}}
+		},
+		"liveData": {
+			"boxscore": {"teams": {
+				"away": {"players": {"ID100": {"person": {"id": 100}, "seasonStats": {"pitching": {"wins": 5, "losses": 3}}}}},
+				"home": {"players": {}}
+			}}
+		}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	assert.Equal(t, "L", game.State.Teams.Away.Pitcher.Hand)
+	assert.Equal(t, "5-3", game.State.Teams.Away.Pitcher.Record)
+	assert.Equal(t, "R", game.State.Teams.Home.Pitcher.Hand)
+	assert.Equal(t, "", game.State.Teams.Home.Pitcher.Record, "record should be empty when the boxscore lacks the pitcher")
+}
+
+func TestParseDelayReason(t *testing.T) {
+	cases := map[string]string{
+		"Delayed: Rain":                "Rain",
+		"Delayed Start: Tarp on Field": "Tarp on Field",
+		"Rain Delay":                   "Rain",
+		"Delayed":                      "",
+		"In Progress":                  "",
+		"Final":                        "",
+		"Scheduled":                    "",
+	}
+
+	for detailed, expected := range cases {
+		assert.Equal(t, expected, parseDelayReason(detailed), detailed)
+	}
+}
+
+func TestBuildGameResumptionTime(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"datetime": {"dateTime": "2024-07-04T23:05:00Z", "resumeDateTime": "2024-07-05T03:15:00Z"},
+			"status": {"abstractGameState": "Live", "detailedState": "Delayed: Rain"}
+		},
+		"liveData": {"linescore": {"currentInning": 5}}
+	}`
+
+	game := buildGameFromJSON(t, payload)
+
+	if assert.NotNil(t, game.State.ResumptionTime) {
+		assert.Equal(t, time.Date(2024, 7, 5, 3, 15, 0, 0, time.UTC), *game.State.ResumptionTime)
+	}
+	assert.Equal(t, "Rain", game.State.DelayReason)
+	js, err := json.Marshal(game.State.Status)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(js), "resume", "the start time shouldn't carry the resumption time")
+
+	// without a resumption time, or once the game is underway again, it's left empty
+	for _, payload := range []string{
+		`{"gamePk": 1, "gameData": {"status": {"abstractGameState": "Live", "detailedState": "Delayed: Rain"}}}`,
+		`{"gamePk": 1, "gameData": {"datetime": {"resumeDateTime": "2024-07-05T03:15:00Z"}, "status": {"abstractGameState": "Live", "detailedState": "In Progress"}}}`,
+	} {
+		assert.Nil(t, buildGameFromJSON(t, payload).State.ResumptionTime, payload)
+	}
+}
+
+func TestPayloadsCarryAPIVersion(t *testing.T) {
+	games := &Games{Data: []*Game{{ID: 1}}}
+	gamesJson, err := games.ToJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(gamesJson), `"metadata":{"timestamp":"0001-01-01T00:00:00Z","ready":false,"api_version":"`+APIVersion+`"}`)
+	assert.Equal(t, 1, strings.Count(string(gamesJson), "api_version"), "individual games should not carry the version")
+
+	id := uint32(1)
+	ids := &GameIDs{Data: []*uint32{&id}}
+	idsJson, err := ids.ToJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(idsJson), `"api_version":"`+APIVersion+`"`)
+}
+
+func TestInitialGamesNotReadyDuringWarmup(t *testing.T) {
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{Link: link, Metadata: Metadata{Ready: true}}, nil
+	}, 0)
+	for id := uint32(1); id <= 4; id++ {
+		_, err := gc.Discover(ScheduledGame{ID: id, Link: "link"})
+		assert.NoError(t, err)
+	}
+
+	// only half of the games have been fetched
+	gc.GetOne(context.Background(), MLBKey(1))
+	gc.GetOne(context.Background(), MLBKey(2))
+	games, err := GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Len(t, games.Data, 2)
+	assert.False(t, games.Metadata.Ready, "envelope should not be ready while games are still being fetched")
+
+	gc.GetOne(context.Background(), MLBKey(3))
+	gc.GetOne(context.Background(), MLBKey(4))
+	games, err = GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Len(t, games.Data, 4)
+	assert.True(t, games.Metadata.Ready, "envelope should be ready once every game is")
+}
+
+// a game that can't be fetched, or has gone missing from the schedule, shouldn't hold the envelope back
+func TestInitialGamesReadyDespiteFailedAndMissingGames(t *testing.T) {
+	down := true
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		if link == "2" && down {
+			return Game{}, errors.New("upstream is down")
+		}
+		return Game{Link: link, Metadata: Metadata{Ready: true}}, nil
+	}, 0)
+	gc.SetMissingThreshold(2)
+	for id := uint32(1); id <= 3; id++ {
+		_, err := gc.Discover(ScheduledGame{ID: id, Link: strconv.Itoa(int(id)), Date: "07/04/2024"})
+		assert.NoError(t, err)
+	}
+
+	_, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+	_, valid = gc.GetOne(context.Background(), MLBKey(2))
+	assert.False(t, valid)
+	games, err := GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.False(t, games.Metadata.Ready, "game 3 is still expected")
+
+	// game 3 drops off the schedule before it's fetched
+	assert.Empty(t, gc.Reconcile([]GameKey{MLBKey(1), MLBKey(2)}, []string{"07/04/2024"}))
+	games, err = GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Len(t, games.Data, 1)
+	assert.True(t, games.Metadata.Ready, "failed and missing games should count as settled")
+
+	// a failed game that is fetched later is simply ready
+	down = false
+	_, valid = gc.GetOne(context.Background(), MLBKey(2))
+	assert.True(t, valid)
+	games, err = GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Len(t, games.Data, 2)
+	assert.True(t, games.Metadata.Ready)
+}
+
+func TestBuildGameTyingRun(t *testing.T) {
+	payload := func(inning, away, home int, half string, runners string) string {
+		return fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {
+				"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+				"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+				"players": {
+					"ID1": {"id": 1, "fullName": "Batter"},
+					"ID2": {"id": 2, "fullName": "Runner One"},
+					"ID3": {"id": 3, "fullName": "Runner Two"}
+				}
+			},
+			"liveData": {"linescore": {
+				"currentInning": %d, "inningHalf": %q, "inningState": %q, "outs": 1,
+				"teams": {"away": {"runs": %d}, "home": {"runs": %d}},
+				"offense": {%s}
+			}}
+		}`, inning, half, half, away, home, runners)
+	}
+
+	cases := []struct {
+		name              string
+		payload           string
+		tying, goAheadRun string
+	}{
+		{"down one with a runner on second", payload(8, 3, 2, "Bottom", `"batter": {"id": 1}, "second": {"id": 2}`), "second", "plate"},
+		{"down two with runners on first and third", payload(9, 4, 2, "Bottom", `"batter": {"id": 1}, "first": {"id": 2}, "third": {"id": 3}`), "first", "plate"},
+		{"tie game with a runner on first", payload(7, 2, 2, "Top", `"batter": {"id": 1}, "first": {"id": 2}`), "", "first"},
+		{"tie game with the bases empty", payload(10, 5, 5, "Top", `"batter": {"id": 1}`), "", "plate"},
+		{"down three with the bases empty", payload(9, 5, 2, "Bottom", `"batter": {"id": 1}`), "", ""},
+		{"batting team leads", payload(8, 2, 3, "Bottom", `"batter": {"id": 1}, "second": {"id": 2}`), "", ""},
+		{"too early", payload(6, 3, 2, "Bottom", `"batter": {"id": 1}, "second": {"id": 2}`), "", ""},
+	}
+
+	for _, c := range cases {
+		game := buildGameFromJSON(t, c.payload)
+		assert.Equal(t, c.tying, game.State.TyingRun, c.name)
+		assert.Equal(t, c.goAheadRun, game.State.GoAheadRun, c.name)
+	}
+}
+
+func TestBuildGameSaveSituation(t *testing.T) {
+	payload := func(inning, away, home int, half string, runners string) string {
+		return fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {
+				"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+				"players": {
+					"ID1": {"id": 1, "fullName": "Batter"},
+					"ID2": {"id": 2, "fullName": "Runner One"},
+					"ID3": {"id": 3, "fullName": "Runner Two"}
+				}
+			},
+			"liveData": {"linescore": {
+				"currentInning": %d, "inningHalf": %q, "inningState": %q, "outs": 1,
+				"teams": {"away": {"runs": %d}, "home": {"runs": %d}},
+				"offense": {%s}
+			}}
+		}`, inning, half, half, away, home, runners)
+	}
+
+	cases := []struct {
+		name          string
+		payload       string
+		saveSituation bool
+	}{
+		{"up three in the 9th", payload(9, 5, 2, "Bottom", `"batter": {"id": 1}`), true},
+		{"up one in extras", payload(10, 4, 3, "Bottom", `"batter": {"id": 1}`), true},
+		{"up four with the tying run on deck", payload(9, 6, 2, "Bottom", `"batter": {"id": 1}, "first": {"id": 2}, "third": {"id": 3}`), true},
+		{"up five with the tying run in the hole", payload(9, 7, 2, "Bottom", `"batter": {"id": 1}, "first": {"id": 2}, "third": {"id": 3}`), false},
+		{"blowout", payload(9, 11, 2, "Bottom", `"batter": {"id": 1}, "first": {"id": 2}`), false},
+		{"tie game", payload(9, 3, 3, "Bottom", `"batter": {"id": 1}`), false},
+		{"batting team leads", payload(9, 3, 2, "Top", `"batter": {"id": 1}`), false},
+		{"too early", payload(8, 5, 2, "Bottom", `"batter": {"id": 1}`), false},
+		{"between halves", payload(9, 5, 2, "Middle", ``), false},
+	}
+
+	for _, c := range cases {
+		game := buildGameFromJSON(t, c.payload)
+		assert.Equal(t, c.saveSituation, game.State.SaveSituation, c.name)
+	}
+}
+
+func TestFetchSaveSituationInShortGame(t *testing.T) {
+	payload := `{
+		"gamePk": 1,
+		"gameData": {"status": {"abstractGameState": "Live", "detailedState": "In Progress"}},
+		"liveData": {"linescore": {
+			"currentInning": 7, "inningHalf": "Bottom", "inningState": "Bottom", "outs": 0,
+			"teams": {"away": {"runs": 2}, "home": {"runs": 1}},
+			"offense": {"batter": {"id": 1}}
+		}}
+	}`
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return buildGameFromJSON(t, payload), nil
+	}, 0)
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link", ScheduledInnings: 7})
+	assert.NoError(t, err)
+
+	game, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+	assert.True(t, game.State.SaveSituation, "the 7th is the final inning of a 7-inning game")
+}
+
+func TestFetchReversedHomeAway(t *testing.T) {
+	// the nominal home team bats first, and trails 3-2 with a runner on second
+	payload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+			"players": {
+				"ID1": {"id": 1, "fullName": "Batter"},
+				"ID2": {"id": 2, "fullName": "Runner"},
+				"ID10": {"id": 10, "fullName": "Away Pitcher"},
+				"ID20": {"id": 20, "fullName": "Home Pitcher"}
+			}
+		},
+		"liveData": {"linescore": {
+			"currentInning": 8, "inningHalf": "Top", "inningState": "Top", "outs": 1,
+			"teams": {"away": {"runs": 3}, "home": {"runs": 2}},
+			"defense": {"pitcher": {"id": 10}, "team": {"name": "Away Team"}},
+			"offense": {"pitcher": {"id": 20}, "team": {"name": "Home Team"}, "batter": {"id": 1}, "second": {"id": 2}}
+		}}
+	}`
+	assert.Equal(t, "away", buildGameFromJSON(t, payload).State.Batting, "the away team bats in the top of an inning by default")
+
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return buildGameFromJSON(t, payload), nil
+	}, 0)
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link", ReverseHomeAway: true})
+	assert.NoError(t, err)
+	game, _ := gc.GetOne(context.Background(), MLBKey(1))
+
+	assert.True(t, game.State.ReverseHomeAway)
+	assert.Equal(t, "home", game.State.Batting, "the home team should be shown batting in the top of a reversed game")
+	assert.Equal(t, "Home Pitcher", game.State.Teams.Home.Pitcher.Name, "pitchers should follow the teams, not the half inning")
+	assert.Equal(t, "Away Pitcher", game.State.Teams.Away.Pitcher.Name, "pitchers should follow the teams, not the half inning")
+	assert.Equal(t, "second", game.State.TyingRun, "tying run should be the home team's")
+	assert.Equal(t, "plate", game.State.GoAheadRun, "go-ahead run should be the home team's")
+}
+
+func TestFetchCountsLeadChanges(t *testing.T) {
+	scores := []struct{ away, home uint8 }{
+		{0, 0},
+		{1, 0}, // away takes the lead
+		{1, 1}, // tied, not a change
+		{1, 2}, // home takes it back
+		{1, 3},
+		{3, 3}, // tied again
+		{4, 3}, // away leads once more
+	}
+	var i int
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return buildGameFromJSON(t, fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {
+				"status": {"abstractGameState": "Live", "detailedState": "In Progress"},
+				"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}
+			},
+			"liveData": {"linescore": {"teams": {"away": {"runs": %d}, "home": {"runs": %d}}}}
+		}`, scores[i].away, scores[i].home)), nil
+	}, 0)
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+
+	expected := []uint8{0, 0, 0, 1, 1, 1, 2}
+	for i = range scores {
+		_, err := gc.Fetch(context.Background(), MLBKey(1))
+		assert.NoError(t, err)
+		game, _ := gc.GetOne(context.Background(), MLBKey(1))
+		assert.Equal(t, expected[i], game.State.LeadChanges, "after %d-%d", scores[i].away, scores[i].home)
+		assert.Equal(t, int(scores[i].home)-int(scores[i].away), game.State.RunDifferential)
+	}
+}
+
+func TestBuildGameUmpires(t *testing.T) {
+	payload := func(status string) string {
+		return fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {
+				"status": {"abstractGameState": %q},
+				"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}
+			},
+			"liveData": {"boxscore": {"officials": [
+				{"official": {"id": 1, "fullName": "First Umpire"}, "officialType": "First Base"},
+				{"official": {"id": 2, "fullName": "Plate Umpire"}, "officialType": "Home Plate"},
+				{"official": {"id": 3, "fullName": "Second Umpire"}, "officialType": "Second Base"},
+				{"official": {"id": 4, "fullName": "Third Umpire"}, "officialType": "Third Base"}
+			]}}
+		}`, status)
+	}
+
+	expected := []Umpire{
+		{Name: "Plate Umpire", Position: "Home Plate"},
+		{Name: "First Umpire", Position: "First Base"},
+		{Name: "Second Umpire", Position: "Second Base"},
+		{Name: "Third Umpire", Position: "Third Base"},
+	}
+	assert.Equal(t, expected, buildGameFromJSON(t, payload("Live")).State.Umpires, "live games should list the crew, home plate first")
+	assert.Equal(t, expected, buildGameFromJSON(t, payload("Final")).State.Umpires, "final games should list the crew, home plate first")
+	assert.Empty(t, buildGameFromJSON(t, payload("Preview")).State.Umpires, "preview games should not list a crew")
+}
+
+func TestBuildGameAttendanceAndTemperature(t *testing.T) {
+	payload := func(status string, gameInfo string) string {
+		return fmt.Sprintf(`{
+			"gamePk": 1,
+			"gameData": {
+				"status": {"abstractGameState": %q},
+				"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}},
+				"gameInfo": %s,
+				"weather": {"condition": "Sunny", "temp": "78", "wind": "8 mph, Out To CF"}
+			},
+			"liveData": {"boxscore": {"info": [
+				{"label": "Weather", "value": "78 degrees, Sunny."},
+				{"label": "Att", "value": "41,215."}
+			]}}
+		}`, status, gameInfo)
+	}
+
+	final := buildGameFromJSON(t, payload("Final", `{"attendance": 41215, "firstPitch": "2024-07-04T23:05:00.000Z"}`)).State
+	assert.Equal(t, uint32(41215), final.Attendance)
+	assert.Equal(t, 78, final.FirstPitchTempF)
+
+	live := buildGameFromJSON(t, payload("Live", `{}`)).State
+	assert.Equal(t, uint32(41215), live.Attendance, "attendance should fall back to the boxscore info")
+	assert.Equal(t, 78, live.FirstPitchTempF)
+
+	preview := buildGameFromJSON(t, payload("Preview", `{}`)).State
+	assert.Zero(t, preview.Attendance, "preview games should not report attendance")
+	assert.Zero(t, preview.FirstPitchTempF, "preview games should not report a temperature")
+
+	missing := buildGameFromJSON(t, `{
+		"gamePk": 1,
+		"gameData": {"status": {"abstractGameState": "Final"}, "teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}}
+	}`).State
+	assert.Zero(t, missing.Attendance)
+	assert.Zero(t, missing.FirstPitchTempF)
+}
+
+func TestShortenedGames(t *testing.T) {
+	cases := []struct {
+		name             string
+		scheduled        uint8
+		inning           uint8
+		scheduledInnings uint8
+		shortened        bool
+	}{
+		{"seven-inning doubleheader final", 7, 7, 7, true},
+		{"standard nine-inning final", 0, 9, 9, false},
+		{"extra-inning final", 9, 11, 9, false},
+		{"rain-shortened final", 9, 5, 9, true},
+	}
+
+	for _, c := range cases {
+		gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+			return Game{
+				Link:     link,
+				Metadata: Metadata{Ready: true},
+				State:    State{Status: Status{General: StatusFinal}, Inning: Inning{Number: c.inning}},
+			}, nil
+		}, 0)
+		_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link", ScheduledInnings: c.scheduled})
+		assert.NoError(t, err)
+
+		game, valid := gc.GetOne(context.Background(), MLBKey(1))
+		assert.True(t, valid)
+		assert.Equal(t, c.scheduledInnings, game.State.ScheduledInnings, c.name)
+		assert.Equal(t, c.shortened, game.State.Shortened, c.name)
+	}
+}
+
+func TestEmptyPayloadsHaveEmptyData(t *testing.T) {
+	payloads := map[string]interface{ ToJSON() ([]byte, error) }{
+		"games":      &Games{},
+		"game ids":   &GameIDs{},
+		"team games": &TeamGames{},
+		"standings":  &Standings{},
+	}
+	for name, payload := range payloads {
+		js, err := payload.ToJSON()
+		assert.NoError(t, err, name)
+		assert.Contains(t, string(js), `"data":[]`, name)
+	}
+}
+
+func TestReconcileSkipsFinalAndOnDemandGames(t *testing.T) {
+	statuses := map[string]GameStatus{GameLink(1): StatusFinal, GameLink(2): StatusLive, GameLink(3): StatusPreview}
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{Link: link, Metadata: Metadata{Ready: true}, State: State{Status: Status{General: statuses[link]}}}, nil
+	}, 0)
+	gc.SetMissingThreshold(1)
+
+	for _, id := range []uint32{1, 3} {
+		_, err := gc.Discover(ScheduledGame{ID: id, Link: GameLink(id), Date: "07/04/2024"})
+		assert.NoError(t, err)
+		gc.GetOne(context.Background(), MLBKey(id))
+	}
+	_, _, err := gc.Track(context.Background(), MLBKey(2))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []GameKey{MLBKey(3)}, gc.Reconcile(nil, []string{"07/04/2024"}), "only the scheduled preview game should be removed")
+
+	gc.SetMissingThreshold(0)
+	assert.Empty(t, gc.Reconcile(nil, []string{"07/04/2024"}), "reconciling should do nothing without a threshold")
+}
+
+func TestReconcileKeepsGamesFromUnlistedDates(t *testing.T) {
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{Link: link, Metadata: Metadata{Ready: true}, State: State{Status: Status{General: StatusLive}}}, nil
+	}, 0)
+	gc.SetMissingThreshold(1)
+
+	// yesterday's game is still being played after the date rolled over
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: GameLink(1), Date: "07/04/2024"})
+	assert.NoError(t, err)
+	gc.GetOne(context.Background(), MLBKey(1))
+
+	assert.Empty(t, gc.Reconcile(nil, []string{"07/05/2024"}), "a game can't be missing from a listing of another date")
+	assert.Equal(t, []GameKey{MLBKey(1)}, gc.Reconcile(nil, []string{"07/04/2024", "07/05/2024"}))
+}
+
+func TestGameEqual(t *testing.T) {
+	game := func() Game {
+		return Game{
+			ID:       1,
+			Link:     "link",
+			Metadata: Metadata{Timestamp: time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC), Ready: true},
+			State: State{
+				Teams:   Teams{Away: Team{Score: 2}, Home: Team{Score: 1}},
+				Diamond: Diamond{Batter: Player{ID: 10}},
+				Outs:    1,
+				Status:  Status{General: StatusLive},
+				Umpires: []Umpire{{Name: "Pat Hoberg", Position: "Home Plate"}},
+			},
+		}
+	}
+
+	refetched := game()
+	refetched.Metadata.Timestamp = refetched.Metadata.Timestamp.Add(time.Minute)
+	refetched.refreshAfter = refetched.Metadata.Timestamp.Add(10 * time.Second)
+	refetched.State.Umpires = []Umpire{{Name: "Pat Hoberg", Position: "Home Plate"}}
+	assert.True(t, game().Equal(refetched), "games differing only in when they were fetched should be equal")
+
+	cases := map[string]func(*Game){
+		"score":    func(g *Game) { g.State.Teams.Home.Score++ },
+		"status":   func(g *Game) { g.State.Status.General = StatusFinal },
+		"outs":     func(g *Game) { g.State.Outs++ },
+		"diamond":  func(g *Game) { g.State.Diamond.First = g.State.Diamond.Batter },
+		"ready":    func(g *Game) { g.Metadata.Ready = false },
+		"featured": func(g *Game) { g.Featured = true },
+		"umpires":  func(g *Game) { g.State.Umpires = nil },
+	}
+	for name, change := range cases {
+		changed := game()
+		change(&changed)
+		assert.False(t, game().Equal(changed), "games with a different %s should not be equal", name)
+	}
+}
+
+func TestGameTypeFromSchedule(t *testing.T) {
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{ID: 1, Link: link, Metadata: Metadata{Ready: true}, State: State{Status: Status{General: StatusLive}}}, nil
+	}, 0)
+
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link", GameType: "D", SeriesDescription: "AL Division Series"})
+	assert.NoError(t, err)
+	game, ok := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, ok)
+
+	gameJson, err := json.Marshal(game)
+	assert.NoError(t, err)
+	var rendered struct {
+		GameType          string `json:"game_type"`
+		SeriesDescription string `json:"series_description"`
+	}
+	assert.NoError(t, json.Unmarshal(gameJson, &rendered))
+	assert.Equal(t, "Division Series", rendered.GameType, "the game type should be kept from the schedule through a live fetch")
+	assert.Equal(t, "AL Division Series", rendered.SeriesDescription)
+
+	assert.Equal(t, "X", gameTypeLabel("X"), "unknown codes should be passed through")
+}
+
+func TestFetchCountsChanges(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC))
+	outs := uint8(0)
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		return Game{ID: 1, Link: link, Metadata: Metadata{Timestamp: clock.Now(), Ready: true}, State: State{Outs: outs, Status: Status{General: StatusLive}}}, nil
+	}, 0)
+	gc.SetClock(clock)
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+
+	counts := func() UpdateCount {
+		t.Helper()
+		all := gc.UpdateCounts()
+		assert.Len(t, all, 1)
+		return all[0]
+	}
+	assert.Equal(t, UpdateCount{ID: 1, SportID: SportID, Discovered: clock.Now()}, counts(), "a discovered game hasn't been fetched")
+
+	changed, err := gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, uint32(1), counts().Changes, "the first fetch fills in the game")
+
+	// a refetch with nothing new is only a fetch, even though the game was refreshed
+	clock.Advance(time.Minute)
+	changed, err = gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, uint32(2), counts().Fetches)
+	assert.Equal(t, uint32(1), counts().Changes, "a no-op fetch should not count as a change")
+
+	outs = 1
+	changed, err = gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, UpdateCount{ID: 1, SportID: SportID, Discovered: clock.Now().Add(-time.Minute), Fetches: 3, Changes: 2}, counts(), "a detected change should be counted")
+}
+
+// previews days away are refreshed hourly and kept until they are played
+func TestAuditKeepsUpcomingPreviews(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC))
+	start := clock.Now().Add(72 * time.Hour)
+	fetches := 0
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		fetches++
+		return Game{
+			Link:     link,
+			Metadata: Metadata{Timestamp: clock.Now(), Ready: true},
+			State:    State{Status: Status{General: StatusPreview, StartTime: api_data.Datetime{DateTime: start}}},
+		}, nil
+	}, 0)
+	gc.SetClock(clock)
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+	gc.GetOne(context.Background(), MLBKey(1))
+
+	for i := 0; i < 8; i++ {
+		clock.Advance(20 * time.Minute)
+		_, removed, _ := gc.Audit(context.Background())
+		assert.Empty(t, removed, "a game days away should not be pruned")
+	}
+	assert.Equal(t, 1+2, fetches, "a game days away should only be refreshed hourly")
+	games, err := gc.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, games, 1)
+}
+
+func TestBuildGamePartialTeamData(t *testing.T) {
+	game := buildGameFromJSON(t, `{"gamePk":1,"gameData":{"status":{"abstractGameState":"Preview","detailedState":"Scheduled"},`+
+		`"teams":{"away":{"name":"Toledo Mud Hens","league":{"name":"International League"}},"home":{"abbreviation":"COL"}}}}`)
+
+	assert.Equal(t, Info{Name: "Toledo Mud Hens", Abbreviation: "TMH", League: "International League"}, game.State.Teams.Away.Info, "a missing abbreviation should be derived from the name")
+	assert.Equal(t, Info{Name: "COL", Abbreviation: "COL"}, game.State.Teams.Home.Info, "a missing name should fall back to the abbreviation")
+
+	assert.Equal(t, "ATH", abbreviate("Athletics"))
+	assert.Equal(t, "TBD", teamInfo(api_data.Team2{}).Name, "a team should never be nameless")
+}
+
+func TestFetchWarnsOnMissingLeague(t *testing.T) {
+	var logs strings.Builder
+	outs := uint8(0)
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		game := Game{Link: link, Metadata: Metadata{Ready: true}, State: State{Outs: outs, Status: Status{General: StatusLive}}}
+		game.State.Teams.Away.Info = Info{Name: "Away Team", League: "American League"}
+		game.State.Teams.Home.Info = Info{Name: "Home Team"}
+		return game, nil
+	}, 0)
+	gc.SetLogger(log.New(&logs, "", 0))
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+
+	_, err = gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "[WARN] Game 1 has no league for the Home Team\r\n", logs.String())
+
+	outs = 1
+	_, err = gc.Fetch(context.Background(), MLBKey(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(logs.String(), "[WARN]"), "the warning should only be logged when the game is first filled in")
+}
+
+func TestBuildGameMatchup(t *testing.T) {
+	game := buildGameFromJSON(t, `{"gamePk":1,"gameData":{"status":{"abstractGameState":"Preview","detailedState":"Scheduled"},`+
+		`"teams":{"away":{"name":"New York Yankees","abbreviation":"NYY"},"home":{"name":"Boston Red Sox","abbreviation":"BOS"}}}}`)
+
+	assert.Equal(t, "NYY @ BOS", game.Matchup)
+	assert.Equal(t, "New York Yankees", game.State.Teams.Away.Info.Name, "the full teams should be kept alongside the matchup")
+}
+
+func TestDiscoverPrefetchesGames(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var fetches atomic.Int32
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		fetches.Add(1)
+		return Game{Link: link, Metadata: Metadata{Ready: true}}, nil
+	}, 2)
+	gc.SetPrefetch(ctx)
+	for id := uint32(1); id <= 4; id++ {
+		_, err := gc.Discover(ScheduledGame{ID: id, Link: "link"})
+		assert.NoError(t, err)
+	}
+
+	// the games get ready without anyone asking for them
+	assert.Eventually(t, func() bool { return gc.Pending() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(4), fetches.Load())
+
+	// and asking for them doesn't fetch them again
+	_, valid := gc.GetOne(context.Background(), MLBKey(1))
+	assert.True(t, valid)
+	assert.Equal(t, int32(4), fetches.Load())
+}
+
+func TestConcurrentGetOneOfUnreadyGame(t *testing.T) {
+	// both fetches are in flight at once, so whichever stores the game second finds it unchanged
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		arrived.Done()
+		arrived.Wait()
+		return Game{ID: 1, Link: link, Metadata: Metadata{Ready: true}, State: State{Status: Status{General: StatusLive}}}, nil
+	}, 0)
+	_, err := gc.Discover(ScheduledGame{ID: 1, Link: "link"})
+	assert.NoError(t, err)
+
+	valid := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, ok := gc.GetOne(context.Background(), MLBKey(1))
+			valid <- ok
+		}()
+	}
+	assert.True(t, <-valid)
+	assert.True(t, <-valid, "the caller whose fetch lost the race should still get the game")
+}
+
+func TestColdLoadRespectsFetchConcurrency(t *testing.T) {
+	const limit = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var inFlight, maxInFlight atomic.Int32
+	gc := NewGameCache(func(ctx context.Context, link string) (Game, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return Game{Link: link, Metadata: Metadata{Ready: true}}, nil
+	}, limit)
+	gc.SetPrefetch(ctx)
+
+	// games are prefetched as they're discovered while warming and initial requests pile on
+	for id := uint32(1); id <= 15; id++ {
+		_, err := gc.Discover(ScheduledGame{ID: id, Link: "link"})
+		assert.NoError(t, err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			gc.Warm(ctx, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := GetInitialGames(gc, SortDefault)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	games, err := GetInitialGames(gc, SortDefault)
+	assert.NoError(t, err)
+	assert.Len(t, games.Data, 15)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(limit), "no more than the limit of games should be fetched at once")
+}
@@ -0,0 +1,12 @@
+package data
+
+import (
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+// create a least recently used cache holding up to size entries
+// golang-lru only fails for sizes below one, so a cache always holds at least one entry
+func newLRU[K comparable, V any](size int) *lru.Cache[K, V] {
+	cache, _ := lru.New[K, V](max(size, 1))
+	return cache
+}
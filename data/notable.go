@@ -0,0 +1,256 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+)
+
+// kinds of notable events found in a game's plays
+const (
+	// a pitcher striking out the side on nine pitches
+	NotableImmaculateInning = "immaculate_inning"
+	// a team that hasn't allowed a hit through at least 7 innings, or all game
+	NotableNoHitter = "no_hitter"
+	// a batter with a single, double, triple, and home run in the game
+	NotableCycle = "cycle"
+	// a batter in a live game who is one kind of hit away from the cycle
+	NotableCycleWatch = "cycle_watch"
+)
+
+// innings a team's batters have to go without a hit before it is a notable no-hitter
+const noHitterInnings = 7
+
+// a rare occurrence in a live or final game, for alerts and trivia
+type Notable struct {
+	Kind string `json:"kind"`
+	// the team credited with it ("away" or "home"), and the player if it was one player's doing
+	Team     string `json:"team"`
+	PlayerID uint32 `json:"player_id"`
+	// the inning it happened in, 0 for no-hitters, whose description says how far along they are
+	Inning      uint8  `json:"inning"`
+	Description string `json:"description"`
+	// set on no-hitters once the game is over, so the completed no-hitter is announced after the one in progress
+	Complete bool `json:"complete"`
+}
+
+// notable events newly found in games, each alongside the game it happened in
+type Notables struct {
+	Metadata Metadata      `json:"metadata"`
+	Data     []GameNotable `json:"data"`
+}
+
+// a notable event and the game it happened in, with the game's fields at the top level so clients can filter it like a game
+type GameNotable struct {
+	*Game
+	Notable Notable `json:"notable"`
+}
+
+func (ns *Notables) ToJSON() ([]byte, error) {
+	ns.Metadata.APIVersion = APIVersion
+	if ns.Data == nil {
+		ns.Data = []GameNotable{}
+	}
+	js, err := json.Marshal(ns)
+	return js, err
+}
+
+// identify a notable across refreshes of its game, even as its description changes
+// a no-hitter in progress and the completed one are told apart, so both are announced
+func (n Notable) Key() string {
+	return fmt.Sprintf("%s/%s/%d/%d/%t", n.Kind, n.Team, n.PlayerID, n.Inning, n.Complete)
+}
+
+// hits that make up the cycle, in the order they are listed when one is missing
+var cycleHits = []string{"single", "double", "triple", "home_run"}
+
+// find the notable events of a live or final game from its plays and linescore
+func findNotables(lg *api_data.LiveGame, s State, name func(uint32) string) []Notable {
+	if s.Status.General != StatusLive && s.Status.General != StatusFinal {
+		return nil
+	}
+
+	var notables []Notable
+	notables = append(notables, immaculateInnings(lg.LiveData.Plays.AllPlays, name)...)
+	notables = append(notables, noHitters(lg.LiveData.Linescore, s)...)
+	notables = append(notables, cycles(lg.LiveData.Plays.AllPlays, s, name)...)
+	return notables
+}
+
+// a half inning of a game, e.g. the top of the 3rd
+type halfInning struct {
+	inning uint8
+	half   string
+}
+
+// find half innings of exactly three plays, each a three-pitch strikeout by the same pitcher
+func immaculateInnings(plays []api_data.Play, name func(uint32) string) []Notable {
+	var order []halfInning
+	halves := make(map[halfInning][]api_data.Play)
+	for _, play := range plays {
+		half := halfInning{play.About.Inning, play.About.HalfInning}
+		if _, seen := halves[half]; !seen {
+			order = append(order, half)
+		}
+		halves[half] = append(halves[half], play)
+	}
+
+	var notables []Notable
+	for _, half := range order {
+		halfPlays := halves[half]
+		if len(halfPlays) != 3 {
+			continue
+		}
+
+		pitcher := halfPlays[0].Matchup.Pitcher.ID
+		immaculate := true
+		for _, play := range halfPlays {
+			if !play.About.IsComplete || play.Result.EventType != "strikeout" || play.Matchup.Pitcher.ID != pitcher || countPitches(play) != 3 {
+				immaculate = false
+				break
+			}
+		}
+		if immaculate {
+			notables = append(notables, Notable{
+				Kind:        NotableImmaculateInning,
+				Team:        fieldingTeam(half.half),
+				PlayerID:    pitcher,
+				Inning:      half.inning,
+				Description: fmt.Sprintf("%s threw an immaculate inning in the %s of the %s", name(pitcher), half.half, ordinal(half.inning)),
+			})
+		}
+	}
+	return notables
+}
+
+// find teams whose opponents are hitless through enough innings
+func noHitters(linescore api_data.Linescore, s State) []Notable {
+	if linescore.CurrentInning == 0 {
+		return nil
+	}
+
+	// innings each team has finished batting, counting the current half inning once it is over
+	awayDone, homeDone := linescore.CurrentInning-1, linescore.CurrentInning-1
+	switch linescore.InningState {
+	case "Middle", "Bottom":
+		awayDone++
+	case "End":
+		awayDone++
+		homeDone++
+	}
+
+	var notables []Notable
+	noHitter := func(team string, pitching Team, hits uint8, innings uint8) {
+		if hits > 0 || innings < noHitterInnings {
+			return
+		}
+		description := fmt.Sprintf("The %s haven't allowed a hit through %d innings", pitching.Info.Name, innings)
+		complete := s.Status.General == StatusFinal
+		if complete {
+			description = fmt.Sprintf("The %s threw a no-hitter", pitching.Info.Name)
+		}
+		notables = append(notables, Notable{Kind: NotableNoHitter, Team: team, Description: description, Complete: complete})
+	}
+	noHitter("home", s.Teams.Home, linescore.Teams.Away.Hits, awayDone)
+	noHitter("away", s.Teams.Away, linescore.Teams.Home.Hits, homeDone)
+	return notables
+}
+
+// find batters who have hit for the cycle, or in a live game are one kind of hit away from it
+func cycles(plays []api_data.Play, s State, name func(uint32) string) []Notable {
+	var batters []uint32
+	hits := make(map[uint32]map[string]bool)
+	// where each batter's latest new kind of hit came, which is where a cycle or watch started
+	latest := make(map[uint32]halfInning)
+	for _, play := range plays {
+		if !play.About.IsComplete || !slices.Contains(cycleHits, play.Result.EventType) {
+			continue
+		}
+		batter := play.Matchup.Batter.ID
+		if hits[batter] == nil {
+			hits[batter] = make(map[string]bool)
+			batters = append(batters, batter)
+		}
+		if !hits[batter][play.Result.EventType] {
+			hits[batter][play.Result.EventType] = true
+			latest[batter] = halfInning{play.About.Inning, play.About.HalfInning}
+		}
+	}
+
+	var notables []Notable
+	for _, batter := range batters {
+		half := latest[batter]
+		switch {
+		case len(hits[batter]) == len(cycleHits):
+			notables = append(notables, Notable{
+				Kind:        NotableCycle,
+				Team:        battingTeam(half.half),
+				PlayerID:    batter,
+				Inning:      half.inning,
+				Description: fmt.Sprintf("%s hit for the cycle", name(batter)),
+			})
+		case len(hits[batter]) == len(cycleHits)-1 && s.Status.General == StatusLive:
+			var missing string
+			for _, hit := range cycleHits {
+				if !hits[batter][hit] {
+					missing = strings.ReplaceAll(hit, "_", " ")
+				}
+			}
+			notables = append(notables, Notable{
+				Kind:        NotableCycleWatch,
+				Team:        battingTeam(half.half),
+				PlayerID:    batter,
+				Inning:      half.inning,
+				Description: fmt.Sprintf("%s is a %s away from the cycle", name(batter), missing),
+			})
+		}
+	}
+	return notables
+}
+
+// count the pitches thrown during a play
+func countPitches(play api_data.Play) int {
+	pitches := 0
+	for _, event := range play.PlayEvents {
+		if event.IsPitch {
+			pitches++
+		}
+	}
+	return pitches
+}
+
+// the team at bat in a half inning ("top" or "bottom")
+func battingTeam(half string) string {
+	if half == "bottom" {
+		return "home"
+	}
+	return "away"
+}
+
+// the team in the field in a half inning ("top" or "bottom")
+func fieldingTeam(half string) string {
+	if half == "bottom" {
+		return "away"
+	}
+	return "home"
+}
+
+// format a number as an ordinal, e.g. 3 as "3rd"
+func ordinal(n uint8) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(int(n)) + suffix
+}
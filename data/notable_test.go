@@ -0,0 +1,109 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a plate appearance in the allPlays of a live game payload, with one play event per pitch
+func playJSON(inning int, half, eventType string, batter, pitcher uint32, pitches int) string {
+	events := make([]string, pitches)
+	for i := range events {
+		events[i] = `{"isPitch":true}`
+	}
+	return fmt.Sprintf(`{"about":{"inning":%d,"halfInning":"%s","isComplete":true},"result":{"eventType":"%s"},"matchup":{"batter":{"id":%d},"pitcher":{"id":%d}},"playEvents":[%s]}`,
+		inning, half, eventType, batter, pitcher, strings.Join(events, ","))
+}
+
+// a live game in the 4th with the given plays
+func notableGameJSON(plays ...string) string {
+	return `{"gamePk":1,"gameData":{"status":{"abstractGameState":"Live","detailedState":"In Progress"},` +
+		`"teams":{"away":{"name":"Away Team"},"home":{"name":"Home Team"}},` +
+		`"players":{"ID10":{"id":10,"fullName":"Ace Pitcher"},"ID20":{"id":20,"fullName":"Other Pitcher"},"ID30":{"id":30,"fullName":"Big Bat"}}},` +
+		`"liveData":{"linescore":{"currentInning":4,"inningHalf":"Top","inningState":"Top","teams":{"away":{"hits":2},"home":{"hits":3}}},` +
+		`"plays":{"allPlays":[` + strings.Join(plays, ",") + `]}}}`
+}
+
+func TestImmaculateInning(t *testing.T) {
+	game := buildGameFromJSON(t, notableGameJSON(
+		// a walk spoils the top of the 2nd
+		playJSON(2, "top", "strikeout", 1, 10, 3),
+		playJSON(2, "top", "walk", 2, 10, 4),
+		playJSON(2, "top", "strikeout", 3, 10, 3),
+		playJSON(2, "top", "strikeout", 4, 10, 3),
+		// nine pitches, three strikeouts in the top of the 3rd
+		playJSON(3, "top", "strikeout", 5, 10, 3),
+		playJSON(3, "top", "strikeout", 6, 10, 3),
+		playJSON(3, "top", "strikeout", 7, 10, 3),
+		// three strikeouts on ten pitches in the bottom of the 3rd
+		playJSON(3, "bottom", "strikeout", 8, 20, 3),
+		playJSON(3, "bottom", "strikeout", 9, 20, 4),
+		playJSON(3, "bottom", "strikeout", 11, 20, 3),
+	))
+
+	assert.Equal(t, []Notable{{
+		Kind:        NotableImmaculateInning,
+		Team:        "home",
+		PlayerID:    10,
+		Inning:      3,
+		Description: "Ace Pitcher threw an immaculate inning in the top of the 3rd",
+	}}, game.State.Notables)
+}
+
+func TestNoHitterThroughSeven(t *testing.T) {
+	payload := func(status string, inning int, state string) string {
+		return fmt.Sprintf(`{"gamePk":1,"gameData":{"status":{"abstractGameState":"%s","detailedState":"In Progress"},`, status) +
+			`"teams":{"away":{"name":"Away Team"},"home":{"name":"Home Team"}}},` +
+			fmt.Sprintf(`"liveData":{"linescore":{"currentInning":%d,"inningState":"%s","teams":{"away":{"hits":0},"home":{"hits":5}}}}}`, inning, state)
+	}
+
+	game := buildGameFromJSON(t, payload("Live", 7, "Top"))
+	assert.Empty(t, game.State.Notables, "the away team hasn't finished the 7th yet")
+
+	game = buildGameFromJSON(t, payload("Live", 7, "Middle"))
+	if !assert.Len(t, game.State.Notables, 1) {
+		return
+	}
+	inProgress := game.State.Notables[0]
+	assert.Equal(t, NotableNoHitter, inProgress.Kind)
+	assert.Equal(t, "home", inProgress.Team, "the no-hitter belongs to the pitching team")
+	assert.Equal(t, "The Home Team haven't allowed a hit through 7 innings", inProgress.Description)
+	assert.False(t, inProgress.Complete)
+
+	// the finished no-hitter is a notable of its own, so it is announced too
+	game = buildGameFromJSON(t, payload("Final", 9, "End"))
+	if assert.Len(t, game.State.Notables, 1) {
+		assert.Equal(t, "The Home Team threw a no-hitter", game.State.Notables[0].Description)
+		assert.True(t, game.State.Notables[0].Complete)
+		assert.NotEqual(t, inProgress.Key(), game.State.Notables[0].Key())
+	}
+}
+
+func TestCycle(t *testing.T) {
+	game := buildGameFromJSON(t, notableGameJSON(
+		playJSON(1, "top", "single", 30, 20, 2),
+		playJSON(2, "top", "home_run", 30, 20, 1),
+		playJSON(3, "top", "triple", 30, 20, 5),
+	))
+	if assert.Len(t, game.State.Notables, 1) {
+		assert.Equal(t, NotableCycleWatch, game.State.Notables[0].Kind)
+		assert.Equal(t, "Big Bat is a double away from the cycle", game.State.Notables[0].Description)
+	}
+
+	game = buildGameFromJSON(t, notableGameJSON(
+		playJSON(1, "top", "single", 30, 20, 2),
+		playJSON(2, "top", "home_run", 30, 20, 1),
+		playJSON(3, "top", "triple", 30, 20, 5),
+		playJSON(4, "top", "double", 30, 20, 3),
+	))
+	assert.Equal(t, []Notable{{Kind: NotableCycle, Team: "away", PlayerID: 30, Inning: 4, Description: "Big Bat hit for the cycle"}}, game.State.Notables)
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, expected := range map[uint8]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd"} {
+		assert.Equal(t, expected, ordinal(n))
+	}
+}
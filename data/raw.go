@@ -0,0 +1,64 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// a response body that releases its request's timeout when closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// open the unprocessed live feed at a game's link, for passing through to clients
+// the link's field selection is kept unless full is set, in which case the whole feed is requested
+// the caller must close the returned body
+func OpenLiveFeed(ctx context.Context, link string, full bool) (io.ReadCloser, error) {
+	if full {
+		u, err := url.Parse(link)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Del("fields")
+		u.RawQuery = query.Encode()
+		link = u.String()
+	}
+
+	// limit each fetch to 10 seconds, including reading the body
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, link, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// don't pass error responses through as games
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrGameNotFound, link)
+		}
+		return nil, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
+	return cancelOnClose{resp.Body, cancel}, nil
+}
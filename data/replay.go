@@ -0,0 +1,139 @@
+package data
+
+// replay mode serves recorded live game feeds from disk instead of the MLB API
+// this is useful for frontend development and demos when there are no live games
+//
+// snapshot format: the replay directory has one subdirectory per game, named by its gamePk.
+// each subdirectory holds timestamped JSON snapshots of the live game endpoint, in the same
+// shape the MLB API returns (see api_data.LiveGame). snapshots are replayed in filename order,
+// so name them with a sortable timestamp, e.g.
+//
+//	replay/
+//	  746123/
+//	    20240401T190500Z.json
+//	    20240401T191000Z.json
+//
+// each fetch of a game advances it to its next snapshot, so every audit tick moves the game
+// forward. once the last snapshot is reached, the game stays on it.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+)
+
+type Replay struct {
+	dir      string
+	mu       sync.Mutex
+	position map[string]int
+	clock    Clock
+}
+
+func NewReplay(dir string) (*Replay, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("replay path is not a directory: %s", dir)
+	}
+
+	return &Replay{
+		dir:      dir,
+		position: make(map[string]int),
+		clock:    RealClock,
+	}, nil
+}
+
+// list the recorded games, using each game's snapshot directory as its link (the date is ignored)
+// recordings are treated as MLB games, so other sports have none
+func (rp *Replay) ListGames(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]ScheduledGame, error) {
+	if sportID != SportID {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(rp.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var games []ScheduledGame
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// skip directories that aren't named by a gamePk
+		id, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			logger.Printf("[WARN] Skipping replay directory with invalid game id: %s\r\n", entry.Name())
+			continue
+		}
+
+		games = append(games, ScheduledGame{
+			ID:      uint32(id),
+			Link:    filepath.Join(rp.dir, entry.Name()),
+			SportID: SportID,
+		})
+	}
+
+	if len(games) == 0 {
+		return nil, fmt.Errorf("replay directory has no recorded games: %s", rp.dir)
+	}
+
+	return games, nil
+}
+
+// read the next snapshot for the game at the given link
+func (rp *Replay) FetchGame(ctx context.Context, link string) (Game, error) {
+	snapshots, err := filepath.Glob(filepath.Join(link, "*.json"))
+	if err != nil {
+		return Game{}, err
+	}
+	if len(snapshots) == 0 {
+		return Game{}, fmt.Errorf("no snapshots recorded for game: %s", link)
+	}
+	sort.Strings(snapshots)
+
+	// advance the game to its next snapshot, holding on the last one
+	rp.mu.Lock()
+	i := rp.position[link]
+	if i >= len(snapshots) {
+		i = len(snapshots) - 1
+	}
+	rp.position[link] = i + 1
+	rp.mu.Unlock()
+
+	file, err := os.Open(snapshots[i])
+	if err != nil {
+		return Game{}, err
+	}
+	defer file.Close()
+
+	lg := api_data.LiveGame{}
+	err = lg.FromJSON(file)
+	if err != nil {
+		return Game{}, fmt.Errorf("failed to parse snapshot %s: %w", strings.TrimPrefix(snapshots[i], rp.dir), err)
+	}
+
+	err = lg.Validate()
+	if err != nil {
+		return Game{}, fmt.Errorf("invalid snapshot %s: %w", strings.TrimPrefix(snapshots[i], rp.dir), err)
+	}
+
+	return buildGame(&lg, link, rp.clock.Now()), nil
+}
+
+// replace the clock used to stamp replayed games
+// must be called before the replay is shared between goroutines
+func (rp *Replay) SetClock(clock Clock) {
+	rp.clock = clock
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// replay two snapshots of a live game, advancing once per fetch and holding on the last
+func TestReplayTwoSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	gameDir := filepath.Join(dir, "746123")
+	assert.NoError(t, os.Mkdir(gameDir, 0755))
+
+	first := `{"gamePk":746123,"gameData":{"status":{"abstractGameState":"Live","detailedState":"In Progress"},"teams":{"away":{"name":"Away Team"},"home":{"name":"Home Team"}}},"liveData":{"linescore":{"currentInning":1,"inningHalf":"Top","teams":{"home":{"runs":0},"away":{"runs":0}}}}}`
+	second := `{"gamePk":746123,"gameData":{"status":{"abstractGameState":"Live","detailedState":"In Progress"},"teams":{"away":{"name":"Away Team"},"home":{"name":"Home Team"}}},"liveData":{"linescore":{"currentInning":2,"inningHalf":"Bottom","teams":{"home":{"runs":1},"away":{"runs":0}}}}}`
+	assert.NoError(t, os.WriteFile(filepath.Join(gameDir, "20240401T190500Z.json"), []byte(first), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(gameDir, "20240401T191000Z.json"), []byte(second), 0644))
+
+	replay, err := NewReplay(dir)
+	assert.NoError(t, err)
+
+	games, err := replay.ListGames(context.Background(), log.New(io.Discard, "", 0), SportID, "")
+	assert.NoError(t, err)
+	assert.Len(t, games, 1)
+	assert.Equal(t, uint32(746123), games[0].ID)
+
+	gc := NewGameCache(replay.FetchGame, 0)
+	discovered, err := gc.Discover(games[0])
+	assert.NoError(t, err)
+	assert.True(t, discovered)
+
+	game, valid := gc.GetOne(context.Background(), games[0].Key())
+	assert.True(t, valid)
+	assert.Equal(t, uint8(1), game.State.Inning.Number)
+	assert.Equal(t, uint8(0), game.State.Teams.Home.Score)
+
+	changed, err := gc.Fetch(context.Background(), games[0].Key())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	game, _ = gc.GetOne(context.Background(), games[0].Key())
+	assert.Equal(t, uint8(2), game.State.Inning.Number)
+	assert.Equal(t, uint8(1), game.State.Teams.Home.Score)
+
+	// the replay holds on the last snapshot
+	game, err = replay.FetchGame(context.Background(), games[0].Link)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(2), game.State.Inning.Number)
+}
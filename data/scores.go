@@ -0,0 +1,34 @@
+package data
+
+// find the games in both sets whose runs changed going from old to new, returning their state in new
+// only games the diff finds updated are compared, so games new to the cache have no score change
+func ScoreChanges(old, new *Games) []*Game {
+	if old == nil || new == nil {
+		return nil
+	}
+	_, updated, _ := Diff(old, new)
+	if len(updated) == 0 {
+		return nil
+	}
+
+	oldGames := make(map[GameKey]*Game, len(old.Data))
+	for _, game := range old.Data {
+		oldGames[game.Key()] = game
+	}
+	isUpdated := make(map[GameKey]bool, len(updated))
+	for _, key := range updated {
+		isUpdated[key] = true
+	}
+
+	var scored []*Game
+	for _, game := range new.Data {
+		if !isUpdated[game.Key()] {
+			continue
+		}
+		oldGame := oldGames[game.Key()]
+		if oldGame.State.Teams.Away.Score != game.State.Teams.Away.Score || oldGame.State.Teams.Home.Score != game.State.Teams.Home.Score {
+			scored = append(scored, game)
+		}
+	}
+	return scored
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreChanges(t *testing.T) {
+	game := func(id uint32, awayScore, homeScore, outs uint8) *Game {
+		return &Game{ID: id, State: State{Outs: outs, Teams: Teams{Away: Team{Score: awayScore}, Home: Team{Score: homeScore}}}}
+	}
+
+	old := &Games{Data: []*Game{game(1, 0, 0, 0), game(2, 0, 0, 0), game(3, 2, 1, 2)}}
+	new := &Games{Data: []*Game{game(1, 0, 0, 1), game(2, 1, 0, 0), game(3, 2, 1, 2), game(4, 1, 0, 0)}}
+
+	scored := ScoreChanges(old, new)
+	if assert.Len(t, scored, 1, "only the game where a run scored should be returned") {
+		assert.Equal(t, uint32(2), scored[0].ID)
+	}
+	assert.Empty(t, ScoreChanges(nil, new), "games new to the cache have no score change")
+}
+
+func TestScoreChangesKeepsSportsApart(t *testing.T) {
+	game := func(sportID int, homeScore uint8) *Game {
+		return &Game{ID: 1, SportID: sportID, State: State{Teams: Teams{Home: Team{Score: homeScore}}}}
+	}
+
+	// an MLB game and a minor league game share an id, and only the MLB game scores
+	old := &Games{Data: []*Game{game(SportID, 0), game(11, 4)}}
+	new := &Games{Data: []*Game{game(SportID, 1), game(11, 4)}}
+
+	scored := ScoreChanges(old, new)
+	if assert.Len(t, scored, 1) {
+		assert.Equal(t, SportID, scored[0].SportID, "the score change should be reported against the MLB game")
+	}
+}
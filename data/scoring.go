@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+// the plays on which runs scored in a game, in the order they happened
+type Scoring struct {
+	ID     uint32        `json:"id"`
+	Status GameStatus    `json:"status"`
+	Plays  []ScoringPlay `json:"plays"`
+}
+
+type ScoringPlay struct {
+	Inning uint8 `json:"inning"`
+	// "Top" or "Bottom"
+	Top_bottom string `json:"top_bottom"`
+	// what happened, e.g. "Aaron Judge homers (30) on a fly ball to left field."
+	Description string `json:"description"`
+	// the score once the play was over
+	AwayScore uint8 `json:"away_score"`
+	HomeScore uint8 `json:"home_score"`
+}
+
+// function used to retrieve a game's scoring plays
+type ScoringFunc func(ctx context.Context, id uint32) (Scoring, error)
+
+// scoring plays of final games never change, so they are kept in a small LRU
+// preview and live scoring plays are always fetched fresh
+type ScoringCache struct {
+	final *lru.Cache[uint32, Scoring]
+	fetch ScoringFunc
+}
+
+// create a scoring plays cache holding up to size final games, using FetchScoring if fetch is nil
+func NewScoringCache(fetch ScoringFunc, size int) *ScoringCache {
+	if fetch == nil {
+		fetch = FetchScoring
+	}
+	return &ScoringCache{
+		final: newLRU[uint32, Scoring](size),
+		fetch: fetch,
+	}
+}
+
+// get a game's scoring plays, from the cache if the game is final
+func (sc *ScoringCache) Get(ctx context.Context, id uint32) (Scoring, error) {
+	if scoring, cached := sc.final.Get(id); cached {
+		return scoring, nil
+	}
+
+	scoring, err := sc.fetch(ctx, id)
+	if err != nil {
+		return Scoring{}, err
+	}
+
+	if scoring.Status == StatusFinal {
+		sc.final.Add(id, scoring)
+	}
+	return scoring, nil
+}
+
+// get a game's scoring plays from the MLB API
+func FetchScoring(ctx context.Context, id uint32) (Scoring, error) {
+	link := fmt.Sprintf("%s?fields=%s", apiURL(fmt.Sprintf("/v1.1/game/%d/feed/live", id)), generateFieldsString(api_data.LiveScoring{}))
+
+	// limit each fetch to 10 seconds
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, link, nil)
+	if err != nil {
+		return Scoring{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Scoring{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Scoring{}, fmt.Errorf("%w: %s", ErrGameNotFound, link)
+	} else if resp.StatusCode != http.StatusOK {
+		return Scoring{}, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
+	ls := api_data.LiveScoring{}
+	err = ls.FromJSON(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return Scoring{}, fmt.Errorf("failed to decode scoring plays from %s: %w", link, err)
+	}
+
+	// an unknown status is passed through as-is and is never cached
+	status, _ := ParseGameStatus(ls.GameData.Status.AbstractGameState)
+
+	plays := ls.LiveData.Plays
+	scoring := Scoring{ID: uint32(ls.GamePk), Status: status, Plays: []ScoringPlay{}}
+	for _, index := range plays.ScoringPlays {
+		if index < 0 || index >= len(plays.AllPlays) {
+			return Scoring{}, fmt.Errorf("scoring play %d of game %d is not among its %d plays", index, id, len(plays.AllPlays))
+		}
+		play := plays.AllPlays[index]
+		// plays have "top" or "bottom", capitalized to match the linescore's half inning
+		half := play.About.HalfInning
+		if half != "" {
+			half = strings.ToUpper(half[:1]) + half[1:]
+		}
+		scoring.Plays = append(scoring.Plays, ScoringPlay{
+			Inning:      play.About.Inning,
+			Top_bottom:  half,
+			Description: play.Result.Description,
+			AwayScore:   play.Result.AwayScore,
+			HomeScore:   play.Result.HomeScore,
+		})
+	}
+	return scoring, nil
+}
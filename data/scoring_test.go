@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchScoring(t *testing.T) {
+	payload := `{
+		"gamePk": 745001,
+		"gameData": {"status": {"abstractGameState": "Final"}},
+		"liveData": {"plays": {
+			"scoringPlays": [1, 3],
+			"allPlays": [
+				{"about": {"inning": 1, "halfInning": "top"}, "result": {"description": "Juan Soto grounds out.", "awayScore": 0, "homeScore": 0}},
+				{"about": {"inning": 1, "halfInning": "top"}, "result": {"description": "Aaron Judge homers (30) on a fly ball to left field.", "awayScore": 1, "homeScore": 0}},
+				{"about": {"inning": 4, "halfInning": "bottom"}, "result": {"description": "Rafael Devers strikes out swinging.", "awayScore": 1, "homeScore": 0}},
+				{"about": {"inning": 4, "halfInning": "bottom"}, "result": {"description": "Triston Casas doubles (12), Rafael Devers scores.", "awayScore": 1, "homeScore": 1}}
+			]
+		}}
+	}`
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1.1/game/745001/feed/live", r.URL.Path)
+		assert.True(t, strings.Contains(r.URL.Query().Get("fields"), "liveData,plays,scoringPlays"), "the scoring play indices should be requested")
+		fmt.Fprint(rw, payload)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	scoring, err := FetchScoring(context.Background(), 745001)
+	assert.NoError(t, err)
+	assert.Equal(t, Scoring{
+		ID:     745001,
+		Status: StatusFinal,
+		Plays: []ScoringPlay{
+			{Inning: 1, Top_bottom: "Top", Description: "Aaron Judge homers (30) on a fly ball to left field.", AwayScore: 1, HomeScore: 0},
+			{Inning: 4, Top_bottom: "Bottom", Description: "Triston Casas doubles (12), Rafael Devers scores.", AwayScore: 1, HomeScore: 1},
+		},
+	}, scoring)
+}
+
+func TestScoringCacheServesFinalFromCache(t *testing.T) {
+	fetches := map[uint32]int{}
+	statuses := map[uint32]GameStatus{1: StatusFinal, 2: StatusLive}
+	sc := NewScoringCache(func(ctx context.Context, id uint32) (Scoring, error) {
+		fetches[id]++
+		return Scoring{ID: id, Status: statuses[id]}, nil
+	}, 8)
+
+	for i := 0; i < 2; i++ {
+		_, err := sc.Get(context.Background(), 1)
+		assert.NoError(t, err)
+		_, err = sc.Get(context.Background(), 2)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, fetches[1], "second request for a final game's scoring plays should be served from cache")
+	assert.Equal(t, 2, fetches[2], "live scoring plays should not be cached")
+}
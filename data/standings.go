@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+)
+
+type Standings struct {
+	Metadata Metadata   `json:"metadata"`
+	Data     []Division `json:"data"`
+}
+
+type Division struct {
+	Name   string         `json:"name"`
+	League string         `json:"league"`
+	Teams  []TeamStanding `json:"teams"`
+}
+
+type TeamStanding struct {
+	Name         string `json:"name"`
+	Wins         uint16 `json:"wins"`
+	Losses       uint16 `json:"losses"`
+	Pct          string `json:"pct"`
+	GamesBack    string `json:"games_back"`
+	DivisionRank string `json:"division_rank"`
+	Streak       string `json:"streak"`
+}
+
+func (s *Standings) ToJSON() ([]byte, error) {
+	if s.Data == nil {
+		s.Data = []Division{}
+	}
+	js, err := json.Marshal(s)
+	return js, err
+}
+
+// function used to retrieve the current standings
+type StandingsFunc func(ctx context.Context) (*Standings, error)
+
+// standings only change after games finalize, so they are kept for a while instead of refetched per request
+type StandingsCache struct {
+	mu        sync.Mutex
+	fetch     StandingsFunc
+	ttl       time.Duration
+	standings *Standings
+	fetchedAt time.Time
+	clock     Clock
+}
+
+// create a standings cache that keeps standings for ttl, using FetchStandings if fetch is nil
+func NewStandingsCache(fetch StandingsFunc, ttl time.Duration) *StandingsCache {
+	if fetch == nil {
+		fetch = FetchStandings
+	}
+	return &StandingsCache{fetch: fetch, ttl: ttl, clock: RealClock}
+}
+
+// replace the clock used to expire the cached standings
+// must be called before the cache is shared between goroutines
+func (sc *StandingsCache) SetClock(clock Clock) {
+	sc.clock = clock
+}
+
+// get the standings, refetching them if the cached copy has expired
+func (sc *StandingsCache) Get(ctx context.Context) (*Standings, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.standings != nil && sc.clock.Now().Sub(sc.fetchedAt) < sc.ttl {
+		return sc.standings, nil
+	}
+
+	standings, err := sc.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sc.standings = standings
+	sc.fetchedAt = sc.clock.Now()
+	return standings, nil
+}
+
+// get the regular season division standings for both leagues from the MLB API
+func FetchStandings(ctx context.Context) (*Standings, error) {
+	fieldsStandings := generateFieldsString(api_data.Standings{})
+	apiUrl := fmt.Sprintf("%s?leagueId=103,104&standingsTypes=regularSeason&hydrate=league,division&fields=%s", apiURL("/v1/standings"), fieldsStandings)
+
+	// limit each fetch to 10 seconds
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
+	raw := api_data.Standings{}
+	err = raw.FromJSON(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode standings: %w", err)
+	}
+
+	divisions := make([]Division, len(raw.Records))
+	for i, record := range raw.Records {
+		teams := make([]TeamStanding, len(record.TeamRecords))
+		for j, tr := range record.TeamRecords {
+			teams[j] = TeamStanding{
+				Name:         tr.Team.Name,
+				Wins:         tr.Wins,
+				Losses:       tr.Losses,
+				Pct:          tr.WinningPercentage,
+				GamesBack:    tr.GamesBack,
+				DivisionRank: tr.DivisionRank,
+				Streak:       tr.Streak.StreakCode,
+			}
+		}
+		divisions[i] = Division{
+			Name:   record.Division.Name,
+			League: record.League.Name,
+			Teams:  teams,
+		}
+	}
+
+	return &Standings{
+		Metadata: Metadata{
+			Timestamp:  time.Now(),
+			Ready:      true,
+			APIVersion: APIVersion,
+		},
+		Data: divisions,
+	}, nil
+}
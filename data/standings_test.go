@@ -0,0 +1,67 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchStandings(t *testing.T) {
+	payload := `{"records": [{
+		"league": {"name": "American League"},
+		"division": {"name": "American League East"},
+		"teamRecords": [
+			{"team": {"name": "New York Yankees"}, "wins": 94, "losses": 68, "winningPercentage": ".580", "gamesBack": "-", "divisionRank": "1", "streak": {"streakCode": "W2"}},
+			{"team": {"name": "Baltimore Orioles"}, "wins": 91, "losses": 71, "winningPercentage": ".562", "gamesBack": "3.0", "divisionRank": "2", "streak": {"streakCode": "L1"}}
+		]
+	}]}`
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/standings", r.URL.Path)
+		fmt.Fprint(rw, payload)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	standings, err := FetchStandings(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, standings.Data, 1)
+
+	division := standings.Data[0]
+	assert.Equal(t, "American League East", division.Name)
+	assert.Equal(t, "American League", division.League)
+	assert.Equal(t, TeamStanding{
+		Name:         "Baltimore Orioles",
+		Wins:         91,
+		Losses:       71,
+		Pct:          ".562",
+		GamesBack:    "3.0",
+		DivisionRank: "2",
+		Streak:       "L1",
+	}, division.Teams[1])
+}
+
+func TestStandingsCacheTTL(t *testing.T) {
+	fetches := 0
+	sc := NewStandingsCache(func(ctx context.Context) (*Standings, error) {
+		fetches++
+		return &Standings{}, nil
+	}, time.Hour)
+	clock := NewFakeClock(time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC))
+	sc.SetClock(clock)
+
+	_, err := sc.Get(context.Background())
+	assert.NoError(t, err)
+	_, err = sc.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fetches, "standings should be served from cache within the ttl")
+
+	clock.Advance(time.Hour)
+	_, err = sc.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetches, "standings should be refetched after the ttl")
+}
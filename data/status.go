@@ -0,0 +1,38 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// the general state of a game, as reported by the MLB API's abstractGameState
+type GameStatus string
+
+const (
+	StatusPreview GameStatus = "Preview"
+	StatusLive    GameStatus = "Live"
+	StatusFinal   GameStatus = "Final"
+)
+
+// parse a game status, ignoring surrounding whitespace
+// an unrecognized status is returned as-is alongside the error so it can still be passed through
+func ParseGameStatus(s string) (GameStatus, error) {
+	status := GameStatus(strings.TrimSpace(s))
+	switch status {
+	case StatusPreview, StatusLive, StatusFinal:
+		return status, nil
+	}
+	return status, fmt.Errorf("unknown game status %q", s)
+}
+
+// decode a game status, keeping unknown values as-is like the games built from the MLB API do
+func (gs *GameStatus) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	*gs, _ = ParseGameStatus(s)
+	return nil
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGameStatus(t *testing.T) {
+	tests := []struct {
+		input string
+		want  GameStatus
+		valid bool
+	}{
+		{"Preview", StatusPreview, true},
+		{"Live", StatusLive, true},
+		{"Final", StatusFinal, true},
+		{"Live ", StatusLive, true},
+		{"live", GameStatus("live"), false},
+		{"Postponed", GameStatus("Postponed"), false},
+		{"", GameStatus(""), false},
+	}
+
+	for _, tt := range tests {
+		status, err := ParseGameStatus(tt.input)
+		assert.Equal(t, tt.want, status, "input %q", tt.input)
+		if tt.valid {
+			assert.NoError(t, err, "input %q", tt.input)
+		} else {
+			assert.Error(t, err, "input %q", tt.input)
+		}
+	}
+}
+
+func TestGameStatusJSONRoundTrip(t *testing.T) {
+	for _, status := range []GameStatus{StatusPreview, StatusLive, StatusFinal} {
+		b, err := json.Marshal(Status{General: status})
+		assert.NoError(t, err)
+		assert.Contains(t, string(b), `"general":"`+string(status)+`"`, "status should marshal to its plain string")
+
+		var decoded Status
+		assert.NoError(t, json.Unmarshal(b, &decoded))
+		assert.Equal(t, status, decoded.General)
+	}
+
+	var decoded Status
+	assert.NoError(t, json.Unmarshal([]byte(`{"general":"Other"}`), &decoded), "unknown statuses should be passed through")
+	assert.Equal(t, GameStatus("Other"), decoded.General)
+	assert.Error(t, json.Unmarshal([]byte(`{"general":1}`), &decoded), "statuses that aren't strings should be rejected")
+}
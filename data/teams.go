@@ -0,0 +1,243 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+// format of the dates accepted by the MLB schedule endpoint
+const DateFormat = "2006-01-02"
+
+// number of days after today searched for a team's next game
+const NextGameWindow = 10
+
+// returned when a team has no game left to play within the next game window
+var ErrNoNextGame = errors.New("no upcoming game found")
+
+type TeamGames struct {
+	Metadata Metadata   `json:"metadata"`
+	Data     []TeamGame `json:"data"`
+}
+
+// a lightweight view of one of a team's games, straight from the schedule
+type TeamGame struct {
+	ID        uint32     `json:"id"`
+	Opponent  string     `json:"opponent"`
+	Home      bool       `json:"home"`
+	StartTime time.Time  `json:"start_time"`
+	Status    GameStatus `json:"status"`
+	Detailed  string     `json:"detailed"`
+}
+
+func (tg *TeamGames) ToJSON() ([]byte, error) {
+	tg.Metadata.APIVersion = APIVersion
+	if tg.Data == nil {
+		tg.Data = []TeamGame{}
+	}
+	js, err := json.Marshal(tg)
+	return js, err
+}
+
+type TeamNextGame struct {
+	Metadata Metadata `json:"metadata"`
+	Data     NextGame `json:"data"`
+}
+
+// a team's next game, along with its date in the cache's timezone
+type NextGame struct {
+	Date string `json:"date"`
+	TeamGame
+}
+
+func (tn *TeamNextGame) ToJSON() ([]byte, error) {
+	tn.Metadata.APIVersion = APIVersion
+	js, err := json.Marshal(tn)
+	return js, err
+}
+
+// function used to list a team's games between two dates (inclusive, formatted as DateFormat)
+type TeamScheduleFunc func(ctx context.Context, teamID uint32, start, end string) ([]TeamGame, error)
+
+type teamScheduleKey struct {
+	teamID     uint32
+	start, end string
+}
+
+// schedules for date ranges that are entirely in the past won't change, so they are kept in a small LRU
+// ranges including today or later are always fetched fresh
+type TeamScheduleCache struct {
+	past  *lru.Cache[teamScheduleKey, []TeamGame]
+	fetch TeamScheduleFunc
+	loc   *time.Location
+	clock Clock
+}
+
+// create a team schedule cache holding up to size past ranges, deciding what "today" is in loc,
+// using FetchTeamSchedule if fetch is nil
+func NewTeamScheduleCache(fetch TeamScheduleFunc, size int, loc *time.Location) *TeamScheduleCache {
+	if fetch == nil {
+		fetch = FetchTeamSchedule
+	}
+	return &TeamScheduleCache{
+		past:  newLRU[teamScheduleKey, []TeamGame](size),
+		fetch: fetch,
+		loc:   loc,
+		clock: RealClock,
+	}
+}
+
+// replace the clock used to decide what "today" is
+// must be called before the cache is shared between goroutines
+func (tc *TeamScheduleCache) SetClock(clock Clock) {
+	tc.clock = clock
+}
+
+// get a team's games between two dates, from the cache if the range is over
+func (tc *TeamScheduleCache) Get(ctx context.Context, teamID uint32, start, end string) ([]TeamGame, error) {
+	key := teamScheduleKey{teamID, start, end}
+
+	if games, cached := tc.past.Get(key); cached {
+		return games, nil
+	}
+
+	games, err := tc.fetch(ctx, teamID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// dates in DateFormat sort chronologically as strings
+	if end < tc.clock.Now().In(tc.loc).Format(DateFormat) {
+		tc.past.Add(key, games)
+	}
+	return games, nil
+}
+
+// a team's next game as of fetchedAt, nil if it had none within the window
+type nextGameEntry struct {
+	game      *NextGame
+	fetchedAt time.Time
+}
+
+// teams' next games only change as games finish, so each is kept for a short ttl
+type NextGameCache struct {
+	entries *lru.Cache[uint32, nextGameEntry]
+	fetch   TeamScheduleFunc
+	ttl     time.Duration
+	loc     *time.Location
+	clock   Clock
+}
+
+// create a next game cache holding up to size teams for ttl, deciding what "today" is in loc,
+// using FetchTeamSchedule if fetch is nil
+func NewNextGameCache(fetch TeamScheduleFunc, size int, ttl time.Duration, loc *time.Location) *NextGameCache {
+	if fetch == nil {
+		fetch = FetchTeamSchedule
+	}
+	return &NextGameCache{
+		entries: newLRU[uint32, nextGameEntry](size),
+		fetch:   fetch,
+		ttl:     ttl,
+		loc:     loc,
+		clock:   RealClock,
+	}
+}
+
+// replace the clock used to decide what "today" is and to expire entries
+// must be called before the cache is shared between goroutines
+func (nc *NextGameCache) SetClock(clock Clock) {
+	nc.clock = clock
+}
+
+// get a team's next game that isn't final, searching from today through the next NextGameWindow days
+// returns ErrNoNextGame if the team doesn't play in that window
+func (nc *NextGameCache) Get(ctx context.Context, teamID uint32) (*NextGame, error) {
+	entry, cached := nc.entries.Get(teamID)
+	now := nc.clock.Now()
+	if !cached || now.Sub(entry.fetchedAt) >= nc.ttl {
+		today := now.In(nc.loc)
+		games, err := nc.fetch(ctx, teamID, today.Format(DateFormat), today.AddDate(0, 0, NextGameWindow).Format(DateFormat))
+		if err != nil {
+			return nil, err
+		}
+
+		entry = nextGameEntry{fetchedAt: now}
+		for _, game := range games {
+			if game.Status != StatusFinal {
+				entry.game = &NextGame{
+					Date:     game.StartTime.In(nc.loc).Format(DateFormat),
+					TeamGame: game,
+				}
+				break
+			}
+		}
+		nc.entries.Add(teamID, entry)
+	}
+
+	if entry.game == nil {
+		return nil, fmt.Errorf("%w for team %d in the next %d days", ErrNoNextGame, teamID, NextGameWindow)
+	}
+	return entry.game, nil
+}
+
+// get a team's games between two dates from the MLB API schedule
+func FetchTeamSchedule(ctx context.Context, teamID uint32, start, end string) ([]TeamGame, error) {
+	fieldsSchedule := generateFieldsString(api_data.TeamSchedule{})
+	apiUrl := fmt.Sprintf("%s?sportId=%d&teamId=%d&startDate=%s&endDate=%s&fields=%s", apiURL("/v1/schedule"), SportID, teamID, start, end, fieldsSchedule)
+
+	// limit each fetch to 10 seconds
+	fetchCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from MLB API: %s", resp.Status)
+	}
+
+	schedule := api_data.TeamSchedule{}
+	err = schedule.FromJSON(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode team schedule from %s: %w", apiUrl, err)
+	}
+
+	games := []TeamGame{}
+	for _, date := range schedule.Dates {
+		for _, game := range date.Games {
+			// an unknown status is passed through as-is
+			status, _ := ParseGameStatus(game.Status.AbstractGameState)
+
+			home := game.Teams.Home.Team.ID == teamID
+			opponent := game.Teams.Home.Team.Name
+			if home {
+				opponent = game.Teams.Away.Team.Name
+			}
+
+			games = append(games, TeamGame{
+				ID:        game.GamePk,
+				Opponent:  opponent,
+				Home:      home,
+				StartTime: game.GameDate,
+				Status:    status,
+				Detailed:  game.Status.DetailedState,
+			})
+		}
+	}
+	return games, nil
+}
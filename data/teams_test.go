@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchTeamSchedule(t *testing.T) {
+	payload := `{"dates": [
+		{"games": [{
+			"gamePk": 745001,
+			"gameDate": "2024-04-01T23:05:00Z",
+			"status": {"abstractGameState": "Final", "detailedState": "Final"},
+			"teams": {"away": {"team": {"id": 147, "name": "New York Yankees"}}, "home": {"team": {"id": 111, "name": "Boston Red Sox"}}}
+		}]},
+		{"games": [{
+			"gamePk": 745002,
+			"gameDate": "2024-04-02T23:05:00Z",
+			"status": {"abstractGameState": "Preview", "detailedState": "Scheduled"},
+			"teams": {"away": {"team": {"id": 110, "name": "Baltimore Orioles"}}, "home": {"team": {"id": 147, "name": "New York Yankees"}}}
+		}]}
+	]}`
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/schedule", r.URL.Path)
+		assert.Equal(t, "147", r.URL.Query().Get("teamId"))
+		assert.Equal(t, "2024-04-01", r.URL.Query().Get("startDate"))
+		assert.Equal(t, "2024-04-02", r.URL.Query().Get("endDate"))
+		fmt.Fprint(rw, payload)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	games, err := FetchTeamSchedule(context.Background(), 147, "2024-04-01", "2024-04-02")
+	assert.NoError(t, err)
+	assert.Equal(t, []TeamGame{
+		{
+			ID:        745001,
+			Opponent:  "Boston Red Sox",
+			Home:      false,
+			StartTime: time.Date(2024, 4, 1, 23, 5, 0, 0, time.UTC),
+			Status:    StatusFinal,
+			Detailed:  "Final",
+		},
+		{
+			ID:        745002,
+			Opponent:  "Baltimore Orioles",
+			Home:      true,
+			StartTime: time.Date(2024, 4, 2, 23, 5, 0, 0, time.UTC),
+			Status:    StatusPreview,
+			Detailed:  "Scheduled",
+		},
+	}, games)
+}
+
+func TestTeamScheduleCacheOnlyCachesPastRanges(t *testing.T) {
+	fetches := 0
+	tc := NewTeamScheduleCache(func(ctx context.Context, teamID uint32, start, end string) ([]TeamGame, error) {
+		fetches++
+		return []TeamGame{{ID: 1}}, nil
+	}, 8, time.UTC)
+
+	today := time.Now().UTC().Format(DateFormat)
+	for i := 0; i < 2; i++ {
+		_, err := tc.Get(context.Background(), 147, "2024-04-01", "2024-04-30")
+		assert.NoError(t, err)
+		_, err = tc.Get(context.Background(), 147, "2024-04-01", today)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, fetches, "only the range that's over should be served from cache")
+}
+
+func TestNextGameCacheFindsUpcomingGame(t *testing.T) {
+	today := time.Now().UTC()
+	fetches := 0
+	nc := NewNextGameCache(func(ctx context.Context, teamID uint32, start, end string) ([]TeamGame, error) {
+		fetches++
+		assert.Equal(t, today.Format(DateFormat), start, "search should start today")
+		assert.Equal(t, today.AddDate(0, 0, NextGameWindow).Format(DateFormat), end, "search should be limited to the window")
+		return []TeamGame{
+			{ID: 1, Opponent: "Boston Red Sox", StartTime: today, Status: StatusFinal},
+			{ID: 2, Opponent: "Baltimore Orioles", StartTime: today.AddDate(0, 0, 3), Status: StatusPreview},
+		}, nil
+	}, 8, time.Minute, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		game, err := nc.Get(context.Background(), 147)
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint32(2), game.ID, "final games should be skipped")
+			assert.Equal(t, "Baltimore Orioles", game.Opponent)
+			assert.Equal(t, today.AddDate(0, 0, 3).Format(DateFormat), game.Date)
+		}
+	}
+	assert.Equal(t, 1, fetches, "the next game should be cached")
+}
+
+func TestNextGameCacheNoGame(t *testing.T) {
+	nc := NewNextGameCache(func(ctx context.Context, teamID uint32, start, end string) ([]TeamGame, error) {
+		return []TeamGame{}, nil
+	}, 8, time.Minute, time.UTC)
+
+	_, err := nc.Get(context.Background(), 147)
+	assert.ErrorIs(t, err, ErrNoNextGame)
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// the outcome of a probe of the MLB API
+type UpstreamProbe struct {
+	URL string `json:"url"`
+	OK  bool   `json:"ok"`
+	// HTTP status of the response, 0 if none came back
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// make a lightweight schedule request to the MLB API at baseURL and report how it went
+// the probe bypasses the cache and the circuit breaker, so it shows the upstream as it is right now
+func ProbeUpstream(ctx context.Context, baseURL string) UpstreamProbe {
+	probe := UpstreamProbe{URL: fmt.Sprintf("%s%s/v1/schedule?sportId=%d&fields=totalGames", baseURL, APIPath(), SportID)}
+
+	probeCtx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, probe.URL, nil)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	probe.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	resp.Body.Close()
+
+	probe.Status = resp.StatusCode
+	probe.OK = resp.StatusCode == http.StatusOK
+	if !probe.OK {
+		probe.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return probe
+}
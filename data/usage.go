@@ -0,0 +1,74 @@
+package data
+
+import (
+	"io"
+	"log"
+	"sync/atomic"
+)
+
+// how many requests of a kind were made to the MLB API, and how many response bytes they read
+type UpstreamUsage struct {
+	Requests uint64 `json:"requests"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+// running totals of a kind of request, safe for concurrent use
+type usageCounter struct {
+	requests atomic.Uint64
+	bytes    atomic.Uint64
+}
+
+// totals for schedule listings and live game fetches since startup
+var scheduleUsage, gameUsage usageCounter
+
+// logs the size of each response read from the MLB API when set, nil to only keep the totals
+var usageLogger *log.Logger
+
+// log the size of every response read from the MLB API, as a debugging aid for bandwidth (nil to stop)
+// must be called before any requests are made
+func SetUsageLogger(logger *log.Logger) {
+	usageLogger = logger
+}
+
+// get the running totals of requests to the MLB API by kind, for capacity planning
+func UpstreamUsageTotals() map[string]UpstreamUsage {
+	return map[string]UpstreamUsage{
+		"schedule": scheduleUsage.load(),
+		"game":     gameUsage.load(),
+	}
+}
+
+func (u *usageCounter) load() UpstreamUsage {
+	return UpstreamUsage{Requests: u.requests.Load(), Bytes: u.bytes.Load()}
+}
+
+// a response body that counts the bytes read from it, since Content-Length is missing from chunked responses
+type countingReader struct {
+	r     io.Reader
+	n     uint64
+	usage *usageCounter
+	// where the body was read from, for the usage log
+	url string
+}
+
+// wrap a response body from url so what is read from it is added to the totals once it is done
+func (u *usageCounter) count(r io.Reader, url string) *countingReader {
+	return &countingReader{r: r, usage: u, url: url}
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += uint64(n)
+	return n, err
+}
+
+// read whatever the decoder left of the body and add the request to the totals, returning its size
+func (cr *countingReader) done() uint64 {
+	io.Copy(io.Discard, cr)
+	cr.usage.requests.Add(1)
+	cr.usage.bytes.Add(cr.n)
+	if usageLogger != nil {
+		usageLogger.Printf("[INFO] Read %d bytes from %s", cr.n, cr.url)
+	}
+	return cr.n
+}
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstreamUsageIsRecorded(t *testing.T) {
+	gamePayload := `{
+		"gamePk": 1,
+		"gameData": {
+			"status": {"abstractGameState": "Preview", "detailedState": "Scheduled"},
+			"teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}
+		}
+	}`
+	schedulePayload := `{"dates": [{"games": [{"gamePk": 1, "link": "/api/v1.1/game/1/feed/live"}]}]}`
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// flushing before writing the body sends it chunked, without a Content-Length
+		rw.(http.Flusher).Flush()
+		if r.URL.Path == "/api/v1/schedule/" {
+			io.WriteString(rw, schedulePayload)
+		} else {
+			io.WriteString(rw, gamePayload)
+		}
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	before := UpstreamUsageTotals()
+
+	_, err := FetchGame(context.Background(), mlb.URL+"/api/v1.1/game/1/feed/live")
+	assert.NoError(t, err)
+	_, err = ListGamesByDate(context.Background(), log.New(io.Discard, "", 0), SportID, "07/04/2024")
+	assert.NoError(t, err)
+
+	after := UpstreamUsageTotals()
+	assert.Equal(t, before["game"].Requests+1, after["game"].Requests)
+	assert.Equal(t, before["game"].Bytes+uint64(len(gamePayload)), after["game"].Bytes, "the whole game response should be counted")
+	assert.Equal(t, before["schedule"].Requests+1, after["schedule"].Requests)
+	assert.Equal(t, before["schedule"].Bytes+uint64(len(schedulePayload)), after["schedule"].Bytes, "the whole schedule response should be counted")
+}
+
+func TestUpstreamSizesAreOnlyLoggedWhenAsked(t *testing.T) {
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, `{"dates": []}`)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	var logs strings.Builder
+	logger := log.New(&logs, "", 0)
+	_, err := ListGamesByDate(context.Background(), logger, SportID, "07/04/2024")
+	assert.NoError(t, err)
+	assert.NotContains(t, logs.String(), "bytes", "sizes should not be logged by default")
+
+	SetUsageLogger(logger)
+	t.Cleanup(func() { SetUsageLogger(nil) })
+	_, err = ListGamesByDate(context.Background(), logger, SportID, "07/04/2024")
+	assert.NoError(t, err)
+	assert.Contains(t, logs.String(), "[INFO] Read 13 bytes from "+mlb.URL+"/api/v1/schedule/")
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+)
+
+// event of an update holding several others, e.g. everything that changed in one audit cycle
+const BatchEvent = "updates"
+
+// an event in a batch, with its data embedded as JSON
+type batchedEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// combine updates into a single batch update, so clients receive them in one frame
+// the batch's data lists each update's event and data in order, and it concerns every game they do
+// it is stamped with now, which callers take from the game cache's clock
+func NewBatch(updates []Update, now time.Time) (*Update, error) {
+	payload := struct {
+		Metadata data.Metadata  `json:"metadata"`
+		Data     []batchedEvent `json:"data"`
+	}{
+		Metadata: data.Metadata{Timestamp: now, APIVersion: data.APIVersion},
+		Data:     make([]batchedEvent, len(updates)),
+	}
+
+	var ids []data.GameKey
+	for i, update := range updates {
+		payload.Data[i] = batchedEvent{Event: update.Event, Data: json.RawMessage(update.Data)}
+		for _, id := range update.IDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	batchJson, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Update{Event: BatchEvent, Data: string(batchJson), IDs: ids, Batch: updates}, nil
+}
+
+// rebuild a batch from what f keeps of each of its updates, or nil if it keeps none of them
+func (u *Update) mapBatch(f func(*Update) (*Update, error)) (*Update, error) {
+	var kept []Update
+	for i := range u.Batch {
+		update, err := f(&u.Batch[i])
+		if err != nil {
+			return nil, err
+		}
+		if update != nil {
+			kept = append(kept, *update)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil
+	}
+
+	// the rebuilt batch keeps the time the batch was made
+	var batch struct {
+		Metadata data.Metadata `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(u.Data), &batch); err != nil {
+		return nil, err
+	}
+	return NewBatch(kept, batch.Metadata.Timestamp)
+}
+
+// keep an update if it is one of the given events, or just those events if it is a batch, or nil if there are none
+func (u *Update) withEvents(events []string) (*Update, error) {
+	if u.Batch != nil {
+		return u.mapBatch(func(update *Update) (*Update, error) {
+			return update.withEvents(events)
+		})
+	}
+	if slices.Contains(events, u.Event) {
+		return u, nil
+	}
+	return nil, nil
+}
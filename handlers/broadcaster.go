@@ -3,23 +3,71 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/claycot/mlb-gameday-api/data"
 	"github.com/google/uuid"
 )
 
 type Broadcaster struct {
 	clients sync.Map
 	Count   int32
+	// consecutive dropped messages after which a client is disconnected, 0 to never disconnect
+	maxDrops uint64
+	// held while broadcasting, while a client is caught up and added, and while a client is removed,
+	// so a catch-up is never older than what follows it and no update is sent on a closed channel
+	sending sync.Mutex
+	// zone the timestamps in updates are converted to before they are sent, nil to send them as they are
+	location *time.Location
 }
 
-func NewBroadcaster() *Broadcaster {
-	return &Broadcaster{}
+// a registered client and what it subscribed to
+type client struct {
+	channel   chan *Update
+	connected time.Time
+	filters   url.Values
+	dropped   atomic.Uint64
+	// messages dropped since the client last had room for one
+	consecutiveDrops atomic.Uint64
+	// closed when the client is disconnected for not reading its messages
+	done chan struct{}
+	// the only game the client wants updates on, 0 for every game
+	game uint32
+	// the only sport whose games the client wants updates on, MLB unless another is requested
+	sport int
+	// the only status of games the client wants updates on, empty for every status
+	status data.GameStatus
+	// the only events the client wants, e.g. just "score" for a score bug, nil for every event
+	events []string
 }
 
-// register a client's channel to the broadcaster and return their uuid
-func (b *Broadcaster) Register(channel chan *Update, logger *log.Logger) (uuid.UUID, error) {
+// debugging information on a registered client
+type ClientInfo struct {
+	ID        uuid.UUID  `json:"id"`
+	Connected time.Time  `json:"connected"`
+	Filters   url.Values `json:"filters"`
+	Dropped   uint64     `json:"dropped"`
+}
+
+// create a broadcaster that disconnects clients after maxDrops consecutive dropped messages (or never if 0),
+// and sends the timestamps in updates in loc (or as they are if nil)
+func NewBroadcaster(maxDrops uint64, loc *time.Location) *Broadcaster {
+	return &Broadcaster{maxDrops: maxDrops, location: loc}
+}
+
+// provides the latest state of the tracked games, e.g. as a snapshot, for clients that just registered
+type CatchUpFunc func() (*Update, error)
+
+// register a client's channel and subscription filters to the broadcaster and return their uuid
+// an optional catch-up provider is asked for the current state, which is sent to the client (through its filters) right away
+func (b *Broadcaster) Register(channel chan *Update, filters url.Values, logger *log.Logger, catchUp ...CatchUpFunc) (uuid.UUID, error) {
 	var id uuid.UUID
 	var err error
 	exists := true
@@ -34,8 +82,58 @@ func (b *Broadcaster) Register(channel chan *Update, logger *log.Logger) (uuid.U
 		_, exists = b.clients.Load(id)
 	}
 
-	// store the channel in the map
-	b.clients.Store(id, channel)
+	// a client can subscribe to a single game with an id filter
+	var game uint64
+	if filters.Has("id") {
+		game, err = strconv.ParseUint(filters.Get("id"), 10, 32)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("invalid game id filter: %s", filters.Get("id"))
+		}
+	}
+
+	// games of one sport are sent, MLB unless another is requested with a sportId filter, as with GetInitial
+	sport := data.SportID
+	if filters.Has("sportId") {
+		sport, err = strconv.Atoi(filters.Get("sportId"))
+		if err != nil || sport <= 0 {
+			return uuid.Nil, fmt.Errorf("invalid sport id filter: %s", filters.Get("sportId"))
+		}
+	}
+
+	// or to games in a single status, e.g. a ticker of live games
+	var status data.GameStatus
+	if filters.Has("status") {
+		status, err = data.ParseGameStatus(filters.Get("status"))
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("invalid game status filter: %w", err)
+		}
+	}
+
+	// or to only some events, as a comma-separated list
+	var events []string
+	if filters.Has("events") {
+		events = strings.Split(filters.Get("events"), ",")
+	}
+
+	// store the client in the map
+	c := &client{
+		channel:   channel,
+		connected: time.Now(),
+		filters:   filters,
+		done:      make(chan struct{}),
+		game:      uint32(game),
+		sport:     sport,
+		status:    status,
+		events:    events,
+	}
+	// catch the client up before storing it, with broadcasts held off in between,
+	// so everything broadcast before the catch-up is in it and everything after it is sent to the client
+	b.sending.Lock()
+	for _, provide := range catchUp {
+		b.catchUp(c, provide, logger)
+	}
+	b.clients.Store(id, c)
+	b.sending.Unlock()
 	atomic.AddInt32(&b.Count, 1)
 
 	logger.Printf("[INFO] Registered client with ID %v. Now serving %d clients\r\n", id, b.Count)
@@ -43,22 +141,45 @@ func (b *Broadcaster) Register(channel chan *Update, logger *log.Logger) (uuid.U
 	return id, nil
 }
 
+// send a newly registered client the current state from a catch-up provider, if any of it is meant for the client
+// a client without room for it goes without, as with any other update
+func (b *Broadcaster) catchUp(c *client, provide CatchUpFunc, logger *log.Logger) {
+	message, err := provide()
+	if err != nil {
+		logger.Printf("[ERROR] Failed to get catch-up state for new client: %v\r\n", err)
+		return
+	}
+	if message == nil {
+		return
+	}
+	message = b.convert(message, logger)
+	update := c.filter(message, newFilterMemo(), logger)
+	if update == nil {
+		return
+	}
+
+	select {
+	case c.channel <- update:
+	default:
+		c.dropped.Add(1)
+		logger.Printf("[ERROR] Dropping catch-up %s for new client: channel is full", message.Event)
+	}
+}
+
 // deregister a client's channel from the broadcaster and delete all references
 func (b *Broadcaster) Deregister(clientId uuid.UUID, logger *log.Logger) (bool, error) {
-	// attempt to load the client's channel from the broadcaster
-	channelRaw, exists := b.clients.Load(clientId)
+	// hold off broadcasts, so the channel is never closed while an update is being sent on it
+	b.sending.Lock()
+	defer b.sending.Unlock()
 
-	// if it doesn't exist, return an err
+	// remove the client from the broadcaster, unless it is already gone (e.g. disconnected for dropping updates)
+	clientRaw, exists := b.clients.LoadAndDelete(clientId)
 	if !exists {
 		return false, fmt.Errorf("client with given ID did not exist: %s", clientId)
 	}
 
-	// otherwise, close the channel
-	channel := channelRaw.(chan *Update)
-	close(channel)
-
-	// delete the client from the broadcaster and decrement the counter
-	b.clients.Delete(clientId)
+	// close the channel and decrement the counter
+	close(clientRaw.(*client).channel)
 	atomic.AddInt32(&b.Count, -1)
 
 	logger.Printf("[INFO] Deregistered client with ID %v. Now serving %d clients\r\n", clientId, b.Count)
@@ -66,21 +187,133 @@ func (b *Broadcaster) Deregister(clientId uuid.UUID, logger *log.Logger) (bool,
 	return true, nil
 }
 
+// games kept in an update for clients with the same game, sport, and status filters
+type filterKey struct {
+	game   uint32
+	sport  int
+	status data.GameStatus
+}
+
+// updates kept for clients with the same game, status, and events filters
+type eventsKey struct {
+	filterKey
+	events string
+}
+
+// updates already filtered for clients during one broadcast, so clients with the same filters share the work
+type filterMemo struct {
+	narrowed map[data.GameKey]*Update
+	sports   map[filterKey]*Update
+	filtered map[filterKey]*Update
+	selected map[eventsKey]*Update
+}
+
+func newFilterMemo() *filterMemo {
+	return &filterMemo{
+		narrowed: make(map[data.GameKey]*Update),
+		sports:   make(map[filterKey]*Update),
+		filtered: make(map[filterKey]*Update),
+		selected: make(map[eventsKey]*Update),
+	}
+}
+
+// apply the client's filters to an update, returning nil if nothing in it is meant for the client
+func (c *client) filter(message *Update, memo *filterMemo, logger *log.Logger) *Update {
+	update := message
+	if c.game != 0 {
+		game := data.KeyOf(c.sport, c.game)
+		if !slices.Contains(message.IDs, game) {
+			return nil
+		}
+		if _, done := memo.narrowed[game]; !done {
+			narrow, err := message.narrow(game)
+			if err != nil {
+				logger.Printf("[ERROR] Failed to narrow %s update to game %d: %v", message.Event, c.game, err)
+			}
+			memo.narrowed[game] = narrow
+		}
+		if update = memo.narrowed[game]; update == nil {
+			return nil
+		}
+	}
+	sportKey := filterKey{game: c.game, sport: c.sport}
+	if _, done := memo.sports[sportKey]; !done {
+		sport, err := update.withSport(c.sport)
+		if err != nil {
+			logger.Printf("[ERROR] Failed to filter %s update to sport %d games: %v", message.Event, c.sport, err)
+		}
+		memo.sports[sportKey] = sport
+	}
+	if update = memo.sports[sportKey]; update == nil {
+		return nil
+	}
+	if c.status != "" {
+		key := filterKey{c.game, c.sport, c.status}
+		if _, done := memo.filtered[key]; !done {
+			filter, err := update.withStatus(c.status)
+			if err != nil {
+				logger.Printf("[ERROR] Failed to filter %s update to %s games: %v", message.Event, c.status, err)
+			}
+			memo.filtered[key] = filter
+		}
+		if update = memo.filtered[key]; update == nil {
+			return nil
+		}
+	}
+	if c.events != nil {
+		key := eventsKey{filterKey{c.game, c.sport, c.status}, strings.Join(c.events, ",")}
+		if _, done := memo.selected[key]; !done {
+			selection, err := update.withEvents(c.events)
+			if err != nil {
+				logger.Printf("[ERROR] Failed to select %s events from %s update: %v", key.events, message.Event, err)
+			}
+			memo.selected[key] = selection
+		}
+		if update = memo.selected[key]; update == nil {
+			return nil
+		}
+	}
+	return update
+}
+
 // broadcast an update to all clients
+// clients subscribed to a single game only receive updates concerning it, narrowed to that game's data
+// clients only receive the games of their sport, MLB by default
+// clients subscribed to a status only receive the games currently in it, judged by each game's state in the update
+// clients subscribed to some events only receive those, picked out of batches
 func (b *Broadcaster) Broadcast(message *Update, logger *log.Logger) (int, error) {
+	b.sending.Lock()
+	defer b.sending.Unlock()
+
+	// converted once here, rather than for each client
+	message = b.convert(message, logger)
+
 	i := 0
+	memo := newFilterMemo()
 	b.clients.Range(func(key, value interface{}) bool {
-		channel, ok := value.(chan *Update)
+		c, ok := value.(*client)
 		if !ok {
-			logger.Printf("[ERROR] Client %s has an invalid channel type", key)
+			logger.Printf("[ERROR] Client %s has an invalid client type", key)
+			return true
+		}
+
+		update := c.filter(message, memo, logger)
+		if update == nil {
 			return true
 		}
 
 		select {
-		case channel <- message:
+		case c.channel <- update:
+			c.consecutiveDrops.Store(0)
 			i++
 		default:
+			c.dropped.Add(1)
 			logger.Printf("[ERROR] Dropping message for client %s: channel is full", key)
+
+			// a client that has stopped reading is cut off, rather than held open forever
+			if b.maxDrops > 0 && c.consecutiveDrops.Add(1) >= b.maxDrops {
+				b.disconnect(key.(uuid.UUID), logger)
+			}
 		}
 
 		return true
@@ -92,3 +325,122 @@ func (b *Broadcaster) Broadcast(message *Update, logger *log.Logger) (int, error
 
 	return i, nil
 }
+
+// convert the timestamps in an update to the broadcaster's zone, if it has one
+// an update that can't be converted is logged and sent as it is, since its timestamps are still correct
+func (b *Broadcaster) convert(message *Update, logger *log.Logger) *Update {
+	if b.location == nil {
+		return message
+	}
+	update, err := message.inZone(b.location)
+	if err != nil {
+		logger.Printf("[ERROR] Failed to convert %s update timestamps: %v\r\n", message.Event, err)
+		return message
+	}
+	return update
+}
+
+// stop broadcasting to a client and signal its stream to close
+// the client's channel is left open, since its handler may still be reading from it
+func (b *Broadcaster) disconnect(clientId uuid.UUID, logger *log.Logger) {
+	clientRaw, exists := b.clients.LoadAndDelete(clientId)
+	if !exists {
+		return
+	}
+	close(clientRaw.(*client).done)
+	atomic.AddInt32(&b.Count, -1)
+
+	logger.Printf("[WARN] Disconnected client with ID %v for not reading updates. Now serving %d clients\r\n", clientId, b.Count)
+}
+
+// get a channel that is closed if the client is disconnected by the broadcaster
+func (b *Broadcaster) Done(clientId uuid.UUID) <-chan struct{} {
+	clientRaw, exists := b.clients.Load(clientId)
+	if !exists {
+		return nil
+	}
+	return clientRaw.(*client).done
+}
+
+// list the registered clients, oldest connection first
+func (b *Broadcaster) Clients() []ClientInfo {
+	clients := []ClientInfo{}
+	b.clients.Range(func(key, value interface{}) bool {
+		c := value.(*client)
+		clients = append(clients, ClientInfo{
+			ID:        key.(uuid.UUID),
+			Connected: c.connected,
+			Filters:   c.filters,
+			Dropped:   c.dropped.Load(),
+		})
+		return true
+	})
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Connected.Before(clients[j].Connected)
+	})
+
+	return clients
+}
+
+// collect an update along with any others already waiting in the channel, oldest first
+func drainUpdates(first *Update, channel chan *Update) []*Update {
+	pending := []*Update{first}
+	for {
+		select {
+		case update, ok := <-channel:
+			if !ok {
+				return pending
+			}
+			pending = append(pending, update)
+		default:
+			return pending
+		}
+	}
+}
+
+// drop pending updates that are superseded by a later update for the same game, keeping the order of the rest
+// batched updates are narrowed to the games that are still current, and other events are never dropped
+// or coalesced across, so a game's updates stay ordered around e.g. its removal
+func coalesceUpdates(pending []*Update, logger *log.Logger) []*Update {
+	if len(pending) < 2 {
+		return pending
+	}
+
+	// walk from newest to oldest, remembering which games already have a newer update queued
+	superseded := make(map[data.GameKey]bool)
+	coalesced := make([]*Update, 0, len(pending))
+	for i := len(pending) - 1; i >= 0; i-- {
+		update := pending[i]
+		if update.Event != "update" {
+			for _, key := range update.IDs {
+				delete(superseded, key)
+			}
+			coalesced = append(coalesced, update)
+			continue
+		}
+
+		var current []data.GameKey
+		for _, key := range update.IDs {
+			if !superseded[key] {
+				current = append(current, key)
+			}
+			superseded[key] = true
+		}
+		if len(current) == 0 {
+			continue
+		}
+		if len(current) < len(update.IDs) {
+			narrowed, err := update.narrow(current...)
+			if err != nil {
+				logger.Printf("[ERROR] Failed to coalesce %s update: %v", update.Event, err)
+			} else {
+				update = narrowed
+			}
+		}
+		coalesced = append(coalesced, update)
+	}
+
+	slices.Reverse(coalesced)
+	return coalesced
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/stretchr/testify/assert"
+)
+
+// the keys of MLB games with the given ids
+func mlbKeys(ids ...uint32) []data.GameKey {
+	keys := make([]data.GameKey, len(ids))
+	for i, id := range ids {
+		keys[i] = data.MLBKey(id)
+	}
+	return keys
+}
+
+func TestPendingUpdatesAreCoalescedPerGame(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	channel := make(chan *Update, 16)
+	_, err := broadcaster.Register(channel, url.Values{}, logger)
+	assert.NoError(t, err)
+
+	// the client falls behind while a game changes twice
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"outs":1},{"id":2,"outs":0}]}`, IDs: mlbKeys(1, 2)}, logger)
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"outs":2}]}`, IDs: mlbKeys(1)}, logger)
+
+	pending := coalesceUpdates(drainUpdates(<-channel, channel), logger)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, `{"metadata":{},"data":[{"id":2,"outs":0}]}`, pending[0].Data, "the outdated game should be dropped from the batch")
+	assert.Equal(t, `{"metadata":{},"data":[{"id":1,"outs":2}]}`, pending[1].Data, "only the newer update for the game should be delivered")
+}
+
+func TestCoalesceKeepsGamesOfOtherSports(t *testing.T) {
+	pending := []*Update{
+		{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"sport_id":1,"outs":1}]}`, IDs: mlbKeys(1)},
+		{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"sport_id":11,"outs":2}]}`, IDs: []data.GameKey{data.KeyOf(11, 1)}},
+	}
+
+	assert.Equal(t, pending, coalesceUpdates(pending, log.New(io.Discard, "", 0)), "games sharing an id in different sports should not supersede each other")
+}
+
+func TestCoalesceKeepsUpdatesAroundOtherEvents(t *testing.T) {
+	pending := []*Update{
+		{Event: "update", Data: `{"metadata":{},"data":[{"id":1}]}`, IDs: mlbKeys(1)},
+		{Event: "remove", Data: `{"metadata":{},"data":[1]}`, IDs: mlbKeys(1)},
+		{Event: "update", Data: `{"metadata":{},"data":[{"id":1}]}`, IDs: mlbKeys(1)},
+	}
+
+	assert.Equal(t, pending, coalesceUpdates(pending, log.New(io.Discard, "", 0)), "updates should not be coalesced across other events for the game")
+}
+
+func TestNonReadingClientIsDisconnected(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(3, nil)
+
+	// a client whose channel is never read
+	stalled := make(chan *Update, 2)
+	stalledID, err := broadcaster.Register(stalled, url.Values{}, logger)
+	assert.NoError(t, err)
+	done := broadcaster.Done(stalledID)
+
+	// a client that keeps up
+	reading := make(chan *Update, 2)
+	_, err = broadcaster.Register(reading, url.Values{}, logger)
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		broadcaster.Broadcast(&Update{Event: "update"}, logger)
+		<-reading
+		select {
+		case <-done:
+			t.Fatalf("client was disconnected after only %d broadcasts", i+1)
+		default:
+		}
+	}
+
+	// the buffer is full and the third consecutive drop cuts the client off
+	broadcaster.Broadcast(&Update{Event: "update"}, logger)
+	<-reading
+	select {
+	case <-done:
+	default:
+		t.Fatal("client should be disconnected after repeated drops")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&broadcaster.Count), "only the reading client should remain")
+	assert.Len(t, broadcaster.Clients(), 1)
+}
+
+func TestDeregisterAfterDisconnect(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(1, nil)
+
+	// a client with no room for anything is disconnected on the first drop
+	stalled := make(chan *Update)
+	stalledID, err := broadcaster.Register(stalled, url.Values{}, logger)
+	assert.NoError(t, err)
+	done := broadcaster.Done(stalledID)
+	broadcaster.Broadcast(&Update{Event: "update"}, logger)
+	<-done
+
+	// its handler leaving afterwards shouldn't count it twice
+	removed, err := broadcaster.Deregister(stalledID, logger)
+	assert.False(t, removed)
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&broadcaster.Count))
+}
+
+func TestStatusFilteredClient(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	live := make(chan *Update, 16)
+	_, err := broadcaster.Register(live, url.Values{"status": {"Live"}}, logger)
+	assert.NoError(t, err)
+
+	// the game went final, so a live-only ticker should not hear about it
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"state":{"status":{"general":"Final"}}}]}`, IDs: mlbKeys(1)}, logger)
+	assert.Len(t, live, 0, "final game update should be filtered out")
+
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"state":{"status":{"general":"Final"}}},{"id":2,"state":{"status":{"general":"Live"}}}]}`, IDs: mlbKeys(1, 2)}, logger)
+	if assert.Len(t, live, 1) {
+		update := <-live
+		assert.Equal(t, `{"metadata":{},"data":[{"id":2,"state":{"status":{"general":"Live"}}}]}`, update.Data, "only the live game should be kept")
+		assert.Equal(t, mlbKeys(2), update.IDs)
+	}
+
+	// removals only list ids, so they are passed on
+	broadcaster.Broadcast(&Update{Event: "remove", Data: `{"metadata":{},"data":[1]}`, IDs: mlbKeys(1)}, logger)
+	assert.Len(t, live, 1, "removals should not be filtered")
+
+	_, err = broadcaster.Register(make(chan *Update), url.Values{"status": {"Halftime"}}, logger)
+	assert.Error(t, err, "unknown statuses should be rejected")
+}
+
+func TestSportFilteredClient(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	mlb := make(chan *Update, 16)
+	aaa := make(chan *Update, 16)
+	_, err := broadcaster.Register(mlb, url.Values{}, logger)
+	assert.NoError(t, err)
+	_, err = broadcaster.Register(aaa, url.Values{"sportId": {"11"}}, logger)
+	assert.NoError(t, err)
+
+	// games without a sport count as MLB
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1},{"id":2,"sport_id":1},{"id":3,"sport_id":11}]}`, IDs: []data.GameKey{data.MLBKey(1), data.MLBKey(2), data.KeyOf(11, 3)}}, logger)
+	if assert.Len(t, mlb, 1) {
+		update := <-mlb
+		assert.Equal(t, `{"metadata":{},"data":[{"id":1},{"id":2,"sport_id":1}]}`, update.Data, "clients should only get MLB games by default")
+		assert.Equal(t, mlbKeys(1, 2), update.IDs)
+	}
+	if assert.Len(t, aaa, 1) {
+		update := <-aaa
+		assert.Equal(t, `{"metadata":{},"data":[{"id":3,"sport_id":11}]}`, update.Data, "only games of the requested sport should be kept")
+		assert.Equal(t, []data.GameKey{data.KeyOf(11, 3)}, update.IDs)
+	}
+
+	// a catch-up is filtered to the client's sport too
+	_, err = broadcaster.Register(aaa, url.Values{"sportId": {"11"}}, logger, func() (*Update, error) {
+		return &Update{Event: "snapshot", Data: `{"metadata":{},"data":[{"id":1},{"id":3,"sport_id":11}]}`, IDs: []data.GameKey{data.MLBKey(1), data.KeyOf(11, 3)}}, nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, aaa, 1) {
+		assert.Equal(t, []data.GameKey{data.KeyOf(11, 3)}, (<-aaa).IDs, "the snapshot should only hold the client's sport")
+	}
+
+	_, err = broadcaster.Register(make(chan *Update), url.Values{"sportId": {"mlb"}}, logger)
+	assert.Error(t, err, "invalid sport ids should be rejected")
+}
+
+func TestGameFilteredClientOfAnotherSport(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	aaa := make(chan *Update, 16)
+	_, err := broadcaster.Register(aaa, url.Values{"id": {"1"}, "sportId": {"11"}}, logger)
+	assert.NoError(t, err)
+
+	// the MLB game with the same id isn't the one the client follows
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"sport_id":1}]}`, IDs: mlbKeys(1)}, logger)
+	assert.Len(t, aaa, 0)
+
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"sport_id":1},{"id":1,"sport_id":11}]}`, IDs: []data.GameKey{data.MLBKey(1), data.KeyOf(11, 1)}}, logger)
+	if assert.Len(t, aaa, 1) {
+		update := <-aaa
+		assert.Equal(t, `{"metadata":{},"data":[{"id":1,"sport_id":11}]}`, update.Data, "only the game of the client's sport should be kept")
+		assert.Equal(t, []data.GameKey{data.KeyOf(11, 1)}, update.IDs)
+	}
+}
+
+func TestBatchIsFilteredPerClient(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	game := make(chan *Update, 16)
+	_, err := broadcaster.Register(game, url.Values{"id": {"2"}}, logger)
+	assert.NoError(t, err)
+	live := make(chan *Update, 16)
+	_, err = broadcaster.Register(live, url.Values{"status": {"Live"}}, logger)
+	assert.NoError(t, err)
+
+	made := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	batch, err := NewBatch([]Update{
+		{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"state":{"status":{"general":"Final"}}},{"id":2,"state":{"status":{"general":"Live"}}}]}`, IDs: mlbKeys(1, 2)},
+		{Event: "fail", Data: `{"metadata":{},"data":[3]}`, IDs: mlbKeys(3)},
+	}, made)
+	assert.NoError(t, err)
+	assert.Equal(t, BatchEvent, batch.Event)
+	assert.Equal(t, mlbKeys(1, 2, 3), batch.IDs)
+	broadcaster.Broadcast(batch, logger)
+
+	type batched struct {
+		Metadata struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"metadata"`
+		Data []struct {
+			Event string          `json:"event"`
+			Data  json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+
+	if assert.Len(t, game, 1) {
+		update := <-game
+		var payload batched
+		assert.NoError(t, json.Unmarshal([]byte(update.Data), &payload))
+		assert.True(t, made.Equal(payload.Metadata.Timestamp), "a filtered batch should keep the time it was made")
+		assert.Len(t, payload.Data, 1, "only the update concerning the game should be kept")
+		assert.Equal(t, "update", payload.Data[0].Event)
+		assert.JSONEq(t, `{"metadata":{},"data":[{"id":2,"state":{"status":{"general":"Live"}}}]}`, string(payload.Data[0].Data))
+	}
+
+	if assert.Len(t, live, 1) {
+		update := <-live
+		var payload batched
+		assert.NoError(t, json.Unmarshal([]byte(update.Data), &payload))
+		assert.Len(t, payload.Data, 2)
+		assert.JSONEq(t, `{"metadata":{},"data":[{"id":2,"state":{"status":{"general":"Live"}}}]}`, string(payload.Data[0].Data), "only the live game should be kept")
+		assert.Equal(t, "fail", payload.Data[1].Event, "id-only events should be passed on")
+	}
+}
+
+func TestEventsFilteredClient(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	scores := make(chan *Update, 16)
+	_, err := broadcaster.Register(scores, url.Values{"events": {"score"}}, logger)
+	assert.NoError(t, err)
+
+	// an out was recorded, which a score bug doesn't care about
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"state":{"outs":1}}]}`, IDs: mlbKeys(1)}, logger)
+	assert.Len(t, scores, 0, "non-scoring changes should be filtered out")
+
+	// a run scored, which also comes with a score event
+	batch, err := NewBatch([]Update{
+		{Event: "update", Data: `{"metadata":{},"data":[{"id":1,"state":{"teams":{"home":{"score":1}}}}]}`, IDs: mlbKeys(1)},
+		{Event: "score", Data: `{"metadata":{},"data":[{"id":1,"state":{"teams":{"home":{"score":1}}}}]}`, IDs: mlbKeys(1)},
+	}, time.Now())
+	assert.NoError(t, err)
+	broadcaster.Broadcast(batch, logger)
+	if assert.Len(t, scores, 1) {
+		update := <-scores
+		var payload struct {
+			Data []struct {
+				Event string `json:"event"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(update.Data), &payload))
+		if assert.Len(t, payload.Data, 1, "only the score event should be kept from the batch") {
+			assert.Equal(t, "score", payload.Data[0].Event)
+		}
+	}
+
+	broadcaster.Broadcast(&Update{Event: "score", Data: `{"metadata":{},"data":[{"id":2}]}`, IDs: mlbKeys(2)}, logger)
+	if assert.Len(t, scores, 1) {
+		assert.Equal(t, "score", (<-scores).Event)
+	}
+}
+
+func TestRegisterCatchesUpNewClients(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	current := func() (*Update, error) {
+		return &Update{Event: "snapshot", Data: `{"metadata":{},"data":[{"id":1,"state":{"status":{"general":"Final"}}},{"id":2,"state":{"status":{"general":"Live"}}}]}`, IDs: mlbKeys(1, 2)}, nil
+	}
+
+	everything := make(chan *Update, 16)
+	_, err := broadcaster.Register(everything, url.Values{}, logger, current)
+	assert.NoError(t, err)
+	if assert.Len(t, everything, 1, "new clients should get the current state right away") {
+		update := <-everything
+		assert.Equal(t, "snapshot", update.Event)
+		assert.Equal(t, mlbKeys(1, 2), update.IDs)
+	}
+
+	live := make(chan *Update, 16)
+	_, err = broadcaster.Register(live, url.Values{"status": {"Live"}}, logger, current)
+	assert.NoError(t, err)
+	if assert.Len(t, live, 1) {
+		assert.JSONEq(t, `{"metadata":{},"data":[{"id":2,"state":{"status":{"general":"Live"}}}]}`, (<-live).Data, "the catch-up state should go through the client's filters")
+	}
+
+	// nothing is sent if the provider fails, and the client is still registered
+	failing := make(chan *Update, 16)
+	_, err = broadcaster.Register(failing, url.Values{}, logger, func() (*Update, error) { return nil, fmt.Errorf("no games") })
+	assert.NoError(t, err)
+	assert.Len(t, failing, 0)
+	assert.Equal(t, int32(3), broadcaster.Count)
+}
+
+func TestCatchUpComesBeforeLaterBroadcasts(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+	channel := make(chan *Update, 16)
+
+	// an update is broadcast while the catch-up is being put together
+	broadcast := make(chan struct{})
+	_, err := broadcaster.Register(channel, url.Values{}, logger, func() (*Update, error) {
+		go func() {
+			broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":1}]}`, IDs: mlbKeys(1)}, logger)
+			close(broadcast)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		return &Update{Event: "snapshot", Data: `{"metadata":{},"data":[{"id":1}]}`, IDs: mlbKeys(1)}, nil
+	})
+	assert.NoError(t, err)
+	<-broadcast
+
+	if assert.Len(t, channel, 2) {
+		assert.Equal(t, "snapshot", (<-channel).Event, "the catch-up should be sent first")
+		assert.Equal(t, "update", (<-channel).Event, "updates broadcast during the catch-up should follow it")
+	}
+}
+
+// timestamps are converted once per update, for every client and every update in a batch
+func TestBroadcastConvertsTimestamps(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, newYork)
+	all := make(chan *Update, 16)
+	scores := make(chan *Update, 16)
+	_, err = broadcaster.Register(all, url.Values{}, logger)
+	assert.NoError(t, err)
+	_, err = broadcaster.Register(scores, url.Values{"events": {"score"}}, logger)
+	assert.NoError(t, err)
+
+	update := &Update{Event: "score", Data: `{"metadata":{"timestamp":"2024-07-04T23:05:00Z"},"data":[{"id":1}]}`, IDs: mlbKeys(1)}
+	batch, err := NewBatch([]Update{*update, {Event: "fail", Data: `{"metadata":{},"data":[2]}`, IDs: mlbKeys(2)}}, time.Now())
+	assert.NoError(t, err)
+	broadcaster.Broadcast(update, logger)
+	broadcaster.Broadcast(batch, logger)
+
+	if assert.Len(t, all, 2) {
+		assert.Contains(t, (<-all).Data, `"timestamp":"2024-07-04T19:05:00-04:00"`)
+		assert.Contains(t, (<-all).Data, `"timestamp":"2024-07-04T19:05:00-04:00"`, "updates in a batch should be converted")
+	}
+	if assert.Len(t, scores, 2) {
+		<-scores
+		assert.Contains(t, (<-scores).Data, `"timestamp":"2024-07-04T19:05:00-04:00"`, "updates picked out of a batch should stay converted")
+	}
+	assert.Equal(t, `{"metadata":{"timestamp":"2024-07-04T23:05:00Z"},"data":[{"id":1}]}`, update.Data, "the broadcast update should be left as it is")
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// the body of an SSE stream, gzipped when the client accepts it
+// update payloads repeat the same keys in every event, so they compress well across the stream
+type eventStream struct {
+	w       io.Writer
+	zw      *gzip.Writer
+	flusher http.Flusher
+}
+
+// start the body of an SSE stream, gzipping it if the request accepts gzip
+// must be called before anything is written, since it sets the Content-Encoding header
+func newEventStream(rw http.ResponseWriter, r *http.Request, flusher http.Flusher) *eventStream {
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r) {
+		return &eventStream{w: rw, flusher: flusher}
+	}
+
+	rw.Header().Set("Content-Encoding", "gzip")
+	zw := gzip.NewWriter(rw)
+	return &eventStream{w: zw, zw: zw, flusher: flusher}
+}
+
+func (s *eventStream) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// send everything written so far to the client
+func (s *eventStream) Flush() {
+	if s.zw != nil {
+		s.zw.Flush()
+	}
+	s.flusher.Flush()
+}
+
+// end the stream, writing the gzip footer if it is gzipped
+func (s *eventStream) Close() error {
+	if s.zw != nil {
+		return s.zw.Close()
+	}
+	return nil
+}
+
+// check whether a request's Accept-Encoding lists gzip, without a zero quality
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		rawQuality, weighted := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !weighted {
+			return true
+		}
+		quality, err := strconv.ParseFloat(rawQuality, 64)
+		return err == nil && quality > 0
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUpdatesGzipsForClientsThatAcceptIt(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{SSERetry: 2500 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stream := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/update", nil).WithContext(ctx)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rw := httptest.NewRecorder()
+		gh.GetUpdates(rw, req, nil, NewBroadcaster(0, nil))
+		return rw
+	}
+
+	rw := stream("br, gzip;q=0.8")
+	assert.Equal(t, "gzip", rw.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rw.Header().Get("Vary"))
+	zr, err := gzip.NewReader(rw.Body)
+	if assert.NoError(t, err) {
+		body, err := io.ReadAll(zr)
+		assert.NoError(t, err, "the gzip stream should be complete once the client is gone")
+		assert.True(t, strings.HasPrefix(string(body), "retry: 2500\n\n"), "the gzipped stream should hold the events")
+	}
+
+	for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+		rw := stream(acceptEncoding)
+		assert.Empty(t, rw.Header().Get("Content-Encoding"), "accept %q", acceptEncoding)
+		assert.True(t, strings.HasPrefix(rw.Body.String(), "retry: 2500\n\n"), "accept %q", acceptEncoding)
+	}
+}
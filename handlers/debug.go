@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+)
+
+type Debug struct {
+	logger  *log.Logger
+	cfg     *config.Config
+	breaker *data.Breaker
+}
+
+// the server's effective configuration and what it is tracking
+type ServerStatus struct {
+	Timezone         string   `json:"timezone"`
+	Today            string   `json:"today"`
+	TrackedDates     []string `json:"tracked_dates"`
+	AuditInterval    string   `json:"audit_interval"`
+	FindInterval     string   `json:"find_interval"`
+	FetchTimeout     string   `json:"fetch_timeout"`
+	FetchConcurrency int      `json:"fetch_concurrency"`
+	SSERetry         string   `json:"sse_retry"`
+	MLBAPIURL        string   `json:"mlb_api_url"`
+	MLBAPIPath       string   `json:"mlb_api_path"`
+	SportIDs         []int    `json:"sport_ids"`
+	ReplayDir        string   `json:"replay_dir"`
+	APIKey           string   `json:"api_key"`
+	Breaker          string   `json:"breaker"`
+	// requests made to the MLB API since startup and the bytes they read, by kind
+	Upstream map[string]data.UpstreamUsage `json:"upstream"`
+}
+
+func NewDebug(l *log.Logger, cfg *config.Config, breaker *data.Breaker) *Debug {
+	return &Debug{l, cfg, breaker}
+}
+
+// handler listing the clients connected to the update stream
+func (d *Debug) GetClients(rw http.ResponseWriter, r *http.Request, broadcaster *Broadcaster) {
+	d.logger.Println("[INFO] GET debug clients called")
+
+	clients, err := json.Marshal(broadcaster.Clients())
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, clients)
+}
+
+// handler listing how often each game has been fetched and found changed, to see which games churn the most
+func (d *Debug) GetUpdateCounts(rw http.ResponseWriter, r *http.Request, store *data.GameCache) {
+	d.logger.Println("[INFO] GET debug games called")
+
+	counts, err := json.Marshal(store.UpdateCounts())
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, counts)
+}
+
+// handler reporting the effective config and the schedule dates being tracked
+// dates are taken from the store's clock, so they agree with the dates the workers track
+func (d *Debug) GetStatus(rw http.ResponseWriter, r *http.Request, store *data.GameCache) {
+	d.logger.Println("[INFO] GET debug status called")
+
+	trackedDates := make([]string, len(d.cfg.DateOffsets))
+	for i, offset := range d.cfg.DateOffsets {
+		trackedDates[i] = data.ScheduleDate(store.Clock(), d.cfg.Timezone, offset)
+	}
+
+	// never expose secrets, only whether they are set
+	apiKey := ""
+	if d.cfg.APIKey != "" {
+		apiKey = "[redacted]"
+	}
+
+	status, err := json.Marshal(ServerStatus{
+		Timezone:         d.cfg.Timezone.String(),
+		Today:            data.ScheduleDate(store.Clock(), d.cfg.Timezone, 0),
+		TrackedDates:     trackedDates,
+		AuditInterval:    d.cfg.AuditInterval.String(),
+		FindInterval:     d.cfg.FindInterval.String(),
+		FetchTimeout:     data.FetchTimeout.String(),
+		FetchConcurrency: d.cfg.FetchConcurrency,
+		SSERetry:         d.cfg.SSERetry.String(),
+		MLBAPIURL:        d.cfg.MLBAPIURL,
+		MLBAPIPath:       data.APIPath(),
+		SportIDs:         d.cfg.SportIDs,
+		ReplayDir:        d.cfg.ReplayDir,
+		APIKey:           apiKey,
+		Breaker:          string(d.breaker.State()),
+		Upstream:         data.UpstreamUsageTotals(),
+	})
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, status)
+}
+
+// handler probing the MLB API directly, to tell upstream outages apart from problems with this server
+// responds 502 Bad Gateway when the probe fails, so monitors can alert on the status alone
+func (d *Debug) GetUpstream(rw http.ResponseWriter, r *http.Request) {
+	d.logger.Println("[INFO] GET debug upstream called")
+
+	probe := data.ProbeUpstream(r.Context(), d.cfg.MLBAPIURL)
+	body, err := json.Marshal(probe)
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if !probe.OK {
+		d.logger.Printf("[WARN] Upstream probe failed: %s\r\n", probe.Error)
+		status = http.StatusBadGateway
+	}
+	writeJSON(rw, r, status, body)
+}
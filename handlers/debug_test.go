@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetClientsListsRegisteredClient(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	broadcaster := NewBroadcaster(0, nil)
+
+	id, err := broadcaster.Register(make(chan *Update, 1), url.Values{"game": {"746123"}}, logger)
+	assert.NoError(t, err)
+
+	rw := httptest.NewRecorder()
+	NewDebug(logger, &config.Config{}, data.NewBreaker(5, time.Minute)).GetClients(rw, httptest.NewRequest(http.MethodGet, "/api/debug/clients", nil), broadcaster)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	var clients []ClientInfo
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &clients))
+	assert.Len(t, clients, 1)
+	assert.Equal(t, id, clients[0].ID)
+	assert.Equal(t, "746123", clients[0].Filters.Get("game"))
+	assert.Equal(t, uint64(0), clients[0].Dropped)
+}
+
+func TestGetStatusReflectsConfig(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	cfg := &config.Config{
+		Timezone:      tokyo,
+		DateOffsets:   []int{-1, 0},
+		AuditInterval: 30 * time.Second,
+		FindInterval:  15 * time.Minute,
+		APIKey:        "secret",
+	}
+
+	// the dates come from the store's clock, which is just past midnight in Tokyo
+	store := data.NewGameCache(nil, 0)
+	store.SetClock(data.NewFakeClock(time.Date(2024, 7, 4, 15, 30, 0, 0, time.UTC)))
+
+	rw := httptest.NewRecorder()
+	NewDebug(log.New(io.Discard, "", 0), cfg, data.NewBreaker(5, time.Minute)).GetStatus(rw, httptest.NewRequest(http.MethodGet, "/api/debug/status", nil), store)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	var status ServerStatus
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+	assert.Equal(t, "Asia/Tokyo", status.Timezone)
+	assert.Equal(t, "07/05/2024", status.Today)
+	assert.Equal(t, []string{"07/04/2024", "07/05/2024"}, status.TrackedDates)
+	assert.Equal(t, "30s", status.AuditInterval)
+	assert.Equal(t, "closed", status.Breaker)
+	assert.NotContains(t, rw.Body.String(), "secret", "the api key should be redacted")
+}
+
+func TestGetUpstream(t *testing.T) {
+	// the upstream handler runs on the server's goroutines, so what it shares with the test is guarded
+	var mu sync.Mutex
+	delay := time.Duration(0)
+	var paths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		wait := delay
+		mu.Unlock()
+		select {
+		case <-time.After(wait):
+			rw.Write([]byte(`{"totalGames": 15}`))
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstream.Close()
+
+	d := NewDebug(log.New(io.Discard, "", 0), &config.Config{MLBAPIURL: upstream.URL}, data.NewBreaker(5, time.Minute))
+	probe := func(timeout time.Duration) (int, data.UpstreamProbe) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		rw := httptest.NewRecorder()
+		d.GetUpstream(rw, httptest.NewRequest(http.MethodGet, "/api/debug/upstream", nil).WithContext(ctx))
+
+		var result data.UpstreamProbe
+		assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+		return rw.Code, result
+	}
+
+	code, fast := probe(time.Second)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, fast.OK)
+	assert.Equal(t, http.StatusOK, fast.Status)
+	assert.Empty(t, fast.Error)
+	mu.Lock()
+	assert.Equal(t, []string{"/api/v1/schedule"}, paths, "the probe should make a schedule request")
+	delay = 200 * time.Millisecond
+	mu.Unlock()
+
+	code, slow := probe(50 * time.Millisecond)
+	assert.Equal(t, http.StatusBadGateway, code, "an upstream too slow to answer should be reported as failing")
+	assert.False(t, slow.OK)
+	assert.Zero(t, slow.Status)
+	assert.NotEmpty(t, slow.Error)
+	assert.GreaterOrEqual(t, slow.LatencyMs, float64(50))
+
+	code, slowButOK := probe(time.Second)
+	assert.Equal(t, http.StatusOK, code)
+	assert.GreaterOrEqual(t, slowButOK.LatencyMs, float64(200), "latency should cover the round trip")
+}
+
+func TestGetUpdateCounts(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	for _, id := range []uint32{1, 2} {
+		_, err := store.Discover(data.ScheduledGame{ID: id, Link: "link"})
+		assert.NoError(t, err)
+	}
+	store.GetOne(context.Background(), data.MLBKey(2))
+
+	rw := httptest.NewRecorder()
+	NewDebug(logger, &config.Config{}, data.NewBreaker(5, time.Minute)).GetUpdateCounts(rw, httptest.NewRequest(http.MethodGet, "/api/debug/games", nil), store)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	var counts []data.UpdateCount
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &counts))
+	if assert.Len(t, counts, 2) {
+		assert.Equal(t, uint32(2), counts[0].ID, "the most changed game should be listed first")
+		assert.Equal(t, uint32(1), counts[0].Changes)
+		assert.Equal(t, uint32(0), counts[1].Fetches)
+	}
+}
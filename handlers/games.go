@@ -1,59 +1,320 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
 )
 
 type Games struct {
 	logger *log.Logger
+	cfg    *config.Config
+	// identifies this server instance to stream clients, so one that reconnects to another instance knows to reload
+	instance string
 }
 
 type Update struct {
 	Event string
 	Data  string
+	// the games the update concerns, by sport and id, used to route it to single-game subscribers and coalesce it
+	IDs []data.GameKey
+	// the updates a batch is made of, nil for any other event
+	Batch []Update
 }
 
-func NewGames(l *log.Logger) *Games {
-	return &Games{l}
+func NewGames(l *log.Logger, cfg *config.Config) *Games {
+	return &Games{l, cfg, newInstanceToken()}
+}
+
+// the first event on every stream, naming the server instance it is connected to
+// clients that see a different instance after reconnecting may have missed updates, and should reload the initial games
+type instanceEvent struct {
+	Metadata data.Metadata `json:"metadata"`
+	Instance string        `json:"instance"`
+}
+
+// make a random token identifying a server instance
+func newInstanceToken() string {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		// fall back to the start time, which still tells instances apart
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(token)
 }
 
 // handler for when a user first visits and the existing games should be ready on page load
 func (g *Games) GetInitial(rw http.ResponseWriter, r *http.Request, store *data.GameCache) {
-	g.logger.Println("[INFO] GET initial called")
+	g.logger.Printf("[INFO] %s initial called", r.Method)
+
+	// validate the requested ordering before touching the cache
+	order := r.URL.Query().Get("sort")
+	if order != data.SortDefault && order != data.SortRecent {
+		http.Error(rw, fmt.Sprintf("Unknown sort order: %s", order), http.StatusBadRequest)
+		return
+	}
+
+	// only one sport's games are sent, MLB unless another is requested with ?sportId
+	sportID, err := parseSportID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	gameList, err := data.GetInitialGames(store)
+	// finals that started longer ago than ?maxFinalAge (e.g. 3h) can be left out, all cached games are sent by default
+	var maxFinalAge time.Duration
+	if raw := r.URL.Query().Get("maxFinalAge"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(rw, fmt.Sprintf("Invalid max final age: %s", raw), http.StatusBadRequest)
+			return
+		}
+		maxFinalAge = parsed
+	}
+
+	lookupDone := timeLookup(r)
+	gameList, err := data.GetInitialGames(store, order)
 	if err != nil {
 		http.Error(rw, fmt.Sprintf("Unable to fetch games: %s", err), http.StatusBadGateway)
 		return
 	}
+	gameList.ForSport(sportID)
+	if maxFinalAge > 0 {
+		gameList.WithoutFinalsOlderThan(store.Clock().Now(), maxFinalAge)
+	}
+	lookupDone()
 
+	serialize := time.Now()
 	games, err := gameList.ToJSON()
 	if err != nil {
 		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
 		return
 	}
+	recordTiming(r, TimingSerialize, serialize)
 
-	rw.Header().Set("Content-Type", "application/json")
+	// let caches check freshness, e.g. with a HEAD request
+	if modified := gameList.LastModified(); !modified.IsZero() {
+		rw.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	writeJSON(rw, r, http.StatusOK, games)
+}
+
+// handler for a CSV export of the cached games, for spreadsheets
+func (g *Games) GetExport(rw http.ResponseWriter, r *http.Request, store *data.GameCache) {
+	g.logger.Println("[INFO] GET export called")
+
+	gameList, err := data.GetInitialGames(store, data.SortDefault)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch games: %s", err), http.StatusBadGateway)
+		return
+	}
+	gameList.ForSport(data.SportID)
+
+	var export bytes.Buffer
+	if err := gameList.ToCSV(&export); err != nil {
+		http.Error(rw, "Unable to write CSV", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	rw.Header().Set("Content-Disposition", `attachment; filename="games.csv"`)
+	rw.Header().Set("Content-Length", strconv.Itoa(export.Len()))
 	rw.WriteHeader(http.StatusOK)
-	rw.Write(games)
+	rw.Write(export.Bytes())
+}
+
+// how long clients and proxies may reuse a badge
+const badgeMaxAge = 30 * time.Second
+
+// handler for an SVG badge showing a ?team='s (by abbreviation) current game, e.g. to embed in a dashboard
+func (g *Games) GetBadge(rw http.ResponseWriter, r *http.Request, store *data.GameCache) {
+	g.logger.Println("[INFO] GET badge called")
+
+	team := r.URL.Query().Get("team")
+	if team == "" {
+		http.Error(rw, "No team given", http.StatusBadRequest)
+		return
+	}
+
+	gameList, err := data.GetInitialGames(store, data.SortDefault)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch games: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	var badge bytes.Buffer
+	if err := data.BadgeSVG(&badge, gameList.ForBadge(team)); err != nil {
+		http.Error(rw, "Unable to render badge", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "image/svg+xml")
+	rw.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeMaxAge.Seconds())))
+	rw.Header().Set("Content-Length", strconv.Itoa(badge.Len()))
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(badge.Bytes())
+}
+
+// handler for the games on each of the ?dates= (MM/DD/YYYY, comma-separated, at most data.MaxDates), merged and sorted
+func (g *Games) GetGamesByDates(rw http.ResponseWriter, r *http.Request, datedGames *data.DateGamesCache) {
+	g.logger.Println("[INFO] GET games by dates called")
+
+	raw := r.URL.Query().Get("dates")
+	if raw == "" {
+		http.Error(rw, "No dates given", http.StatusBadRequest)
+		return
+	}
+	var dates []string
+	for _, date := range strings.Split(raw, ",") {
+		if _, err := time.Parse(data.ScheduleDateFormat, date); err != nil {
+			http.Error(rw, fmt.Sprintf("Invalid date: %q", date), http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(dates, date) {
+			dates = append(dates, date)
+		}
+	}
+	if len(dates) > data.MaxDates {
+		http.Error(rw, fmt.Sprintf("Too many dates: at most %d can be requested at once", data.MaxDates), http.StatusBadRequest)
+		return
+	}
+
+	games, err := datedGames.Get(r.Context(), dates)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch games: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	dated := &data.DatedGames{
+		Metadata: data.Metadata{
+			Timestamp: time.Now(),
+			Ready:     true,
+		},
+		Data: games,
+	}
+	gamesJson, err := dated.ToJSON()
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, gamesJson)
 }
 
 // handler for SSE updates to the games on the site
-func (g *Games) GetUpdates(rw http.ResponseWriter, r *http.Request, broadcaster *Broadcaster) {
+// new clients are caught up with a snapshot of the ready games in the store, if one is given
+func (g *Games) GetUpdates(rw http.ResponseWriter, r *http.Request, store *data.GameCache, broadcaster *Broadcaster) {
 	g.logger.Println("[INFO] GET updates called")
 
+	var catchUp []CatchUpFunc
+	clock := data.RealClock
+	if store != nil {
+		catchUp = append(catchUp, func() (*Update, error) {
+			return snapshotUpdate(store)
+		})
+		clock = store.Clock()
+	}
+	g.streamUpdates(rw, r, clock, broadcaster, r.URL.Query(), nil, catchUp...)
+}
+
+// a snapshot of every ready game in the store, or nil if there are none yet
+// clients are sent the games of their own sport from it, as GetInitial would send them
+func snapshotUpdate(store *data.GameCache) (*Update, error) {
+	snapshot, err := data.GetInitialGames(store, data.SortDefault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get games for snapshot: %w", err)
+	}
+	if len(snapshot.Data) == 0 {
+		return nil, nil
+	}
+
+	snapshotJson, err := snapshot.ToJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot to json: %w", err)
+	}
+	ids := make([]data.GameKey, len(snapshot.Data))
+	for i, game := range snapshot.Data {
+		ids[i] = game.Key()
+	}
+	return &Update{Event: "snapshot", Data: string(snapshotJson), IDs: ids}, nil
+}
+
+// handler for SSE updates to a single game, starting with a snapshot of it
+func (g *Games) GetGameUpdates(rw http.ResponseWriter, r *http.Request, store *data.GameCache, broadcaster *Broadcaster) {
+	g.logger.Println("[INFO] GET game updates called")
+
+	key, err := parseGameKey(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	game, tracked := store.GetOne(r.Context(), key)
+	if !tracked {
+		http.Error(rw, fmt.Sprintf("No tracked game with id %d", key.ID), http.StatusNotFound)
+		return
+	}
+
+	snapshot := &data.Games{
+		Metadata: data.Metadata{
+			Timestamp: store.Clock().Now(),
+		},
+		Data: []*data.Game{&game},
+	}
+	snapshotJson, err := snapshot.ToJSON()
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	filters := url.Values{"id": {strconv.FormatUint(uint64(key.ID), 10)}, "sportId": {strconv.Itoa(key.SportID)}}
+	g.streamUpdates(rw, r, store.Clock(), broadcaster, filters, &Update{Event: "snapshot", Data: string(snapshotJson), IDs: []data.GameKey{key}})
+}
+
+// updates queued per client when the config doesn't set a buffer
+const defaultSSEBuffer = 16
+
+// register with the broadcaster and stream its updates as SSE events until the client disconnects
+// the initial update, if any, is sent before anything from the broadcaster, including its catch-up state
+// payloads are JSON unless ?format=msgpack asks for base64 encoded msgpack, with camelCase keys if ?case=camel asks for them,
+// and the stream is gzipped for clients that accept it
+func (g *Games) streamUpdates(rw http.ResponseWriter, r *http.Request, clock data.Clock, broadcaster *Broadcaster, filters url.Values, initial *Update, catchUp ...CatchUpFunc) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != FormatJSON && format != FormatMsgpack {
+		http.Error(rw, fmt.Sprintf("Unknown format: %s", format), http.StatusBadRequest)
+		return
+	}
+	naming := r.URL.Query().Get("case")
+	if !validCase(naming) {
+		http.Error(rw, fmt.Sprintf("Unknown case: %s", naming), http.StatusBadRequest)
+		return
+	}
+
 	rw.Header().Set("Content-Type", "text/event-stream")
 	rw.Header().Set("Cache-Control", "no-cache")
 	rw.Header().Set("Connection", "keep-alive")
 
-	// make a channel to send SSE updates to the user
-	userChannel := make(chan *Update, 16)
-	chanId, err := broadcaster.Register(userChannel, g.logger)
+	// make a channel to send SSE updates to the user, buffering as many as configured
+	buffer := g.cfg.SSEBuffer
+	if buffer <= 0 {
+		buffer = defaultSSEBuffer
+	}
+	userChannel := make(chan *Update, buffer)
+	chanId, err := broadcaster.Register(userChannel, filters, g.logger, catchUp...)
 
 	if err != nil {
 		http.Error(rw, fmt.Sprintf("Unable to create channel: %s", err), http.StatusInternalServerError)
@@ -61,12 +322,48 @@ func (g *Games) GetUpdates(rw http.ResponseWriter, r *http.Request, broadcaster
 	}
 	defer broadcaster.Deregister(chanId, g.logger)
 
+	// a client that stops reading can leave the stream blocked on a write,
+	// so expire writes once the broadcaster gives up on it to let the handler return
+	disconnected := broadcaster.Done(chanId)
+	stopWatching := make(chan struct{})
+	watching := make(chan struct{})
+	go func() {
+		defer close(watching)
+		select {
+		case <-disconnected:
+			http.NewResponseController(rw).SetWriteDeadline(time.Now())
+		case <-stopWatching:
+		}
+	}()
+	defer func() {
+		close(stopWatching)
+		<-watching
+	}()
+
 	// flush messages to the updates channel
 	flusher, ok := rw.(http.Flusher)
 	if !ok {
 		http.Error(rw, "Streaming unsupported", http.StatusInternalServerError)
 		return
 	}
+	stream := newEventStream(rw, r, flusher)
+	defer stream.Close()
+
+	// tell the client how long to wait before reconnecting, and which instance it is connected to
+	fmt.Fprintf(stream, "retry: %d\n\n", g.cfg.SSERetry.Milliseconds())
+	instance, err := json.Marshal(instanceEvent{
+		Metadata: data.Metadata{Timestamp: clock.Now(), Ready: true, APIVersion: data.APIVersion},
+		Instance: g.instance,
+	})
+	if err != nil {
+		g.logger.Printf("[ERROR] Failed to marshal instance event: %v\r\n", err)
+	} else {
+		g.writeEvent(stream, &Update{Event: "instance", Data: string(instance)}, format, naming)
+	}
+	if initial != nil {
+		g.writeEvent(stream, initial, format, naming)
+	}
+	stream.Flush()
 
 	// keep alive timer
 	ticker := time.NewTicker(15 * time.Second)
@@ -77,15 +374,432 @@ func (g *Games) GetUpdates(rw http.ResponseWriter, r *http.Request, broadcaster
 	for {
 		select {
 		case update := <-userChannel:
-			// g.logger.Printf("[INFO] Sending update: %s", update)
-			fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", update.Event, update.Data)
-			flusher.Flush()
+			// send everything that's pending at once, skipping game states that are already outdated
+			for _, update := range coalesceUpdates(drainUpdates(update, userChannel), g.logger) {
+				// g.logger.Printf("[INFO] Sending update: %s", update)
+				g.writeEvent(stream, update, format, naming)
+			}
+			stream.Flush()
 		case <-ticker.C:
-			fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", "keep-alive", " ")
-			flusher.Flush()
+			fmt.Fprintf(stream, "event: %s\ndata: %s\n\n", "keep-alive", " ")
+			stream.Flush()
 		case <-r.Context().Done():
 			g.logger.Printf("[INFO] Connection %v closed! Reason: %v", chanId, r.Context().Err())
 			return
+		case <-disconnected:
+			g.logger.Printf("[INFO] Connection %v closed! Reason: client stopped reading", chanId)
+			return
+		}
+	}
+}
+
+// write an update as an SSE event, with its payload in the given format and key naming
+// updates that can't be encoded are logged and skipped rather than ending the stream
+func (g *Games) writeEvent(w io.Writer, update *Update, format string, naming string) {
+	payload := update.Data
+	if naming == CaseCamel {
+		camel, err := camelCaseKeys([]byte(payload))
+		if err != nil {
+			g.logger.Printf("[ERROR] Failed to rename %s update keys to camelCase: %v\r\n", update.Event, err)
+			return
 		}
+		payload = string(camel)
+	}
+	payload, err := encodePayload(payload, format)
+	if err != nil {
+		g.logger.Printf("[ERROR] Failed to encode %s update as %s: %v\r\n", update.Event, format, err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Event, payload)
+}
+
+// handler to start tracking a game that isn't in the cache yet, e.g. a future game
+func (g *Games) TrackGame(rw http.ResponseWriter, r *http.Request, store *data.GameCache, updates chan<- Update) {
+	g.logger.Println("[INFO] POST track called")
+
+	key, err := parseGameKey(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	game, added, err := store.Track(r.Context(), key)
+	if errors.Is(err, data.ErrGameNotFound) {
+		http.Error(rw, fmt.Sprintf("No game with id %d", key.ID), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to track game: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	// let connected clients know about the new game
+	if added {
+		add := &data.Games{
+			Metadata: data.Metadata{
+				Timestamp: store.Clock().Now(),
+			},
+			Data: []*data.Game{&game},
+		}
+		addJson, err := add.ToJSON()
+		if err != nil {
+			g.logger.Printf("[ERROR] Failed to marshal add to json: %v\r\n", err)
+		} else {
+			sendUpdate(r, updates, Update{Event: "add", Data: string(addJson), IDs: []data.GameKey{key}})
+		}
+	}
+
+	gameJson, err := json.Marshal(game)
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, gameJson)
+}
+
+// send an update to connected clients through the updates channel, like the workers do,
+// giving up if the request ends first, e.g. on shutdown
+func sendUpdate(r *http.Request, updates chan<- Update, update Update) {
+	select {
+	case updates <- update:
+	case <-r.Context().Done():
+	}
+}
+
+// handler to refetch a cached game right away, outside of the audit schedule
+func (g *Games) RefreshGame(rw http.ResponseWriter, r *http.Request, store *data.GameCache, updates chan<- Update) {
+	g.logger.Println("[INFO] POST refresh called")
+
+	key, err := parseGameKey(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := store.GetLink(key); err != nil {
+		http.Error(rw, fmt.Sprintf("No tracked game with id %d", key.ID), http.StatusNotFound)
+		return
+	}
+
+	// the fetch is timed by the cache, as with any fetch made for a request
+	changed, err := store.Fetch(r.Context(), key)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to refresh game: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	lookupDone := timeLookup(r)
+	game, valid := store.GetOne(r.Context(), key)
+	if !valid {
+		http.Error(rw, fmt.Sprintf("No tracked game with id %d", key.ID), http.StatusNotFound)
+		return
+	}
+	lookupDone()
+
+	// the audit won't see this change, so let connected clients know about it
+	if changed {
+		update := &data.Games{
+			Metadata: data.Metadata{
+				Timestamp: store.Clock().Now(),
+			},
+			Data: []*data.Game{&game},
+		}
+		updateJson, err := update.ToJSON()
+		if err != nil {
+			g.logger.Printf("[ERROR] Failed to marshal update to json: %v\r\n", err)
+		} else {
+			sendUpdate(r, updates, Update{Event: "update", Data: string(updateJson), IDs: []data.GameKey{key}})
+		}
+	}
+
+	gameJson, err := json.Marshal(game)
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, gameJson)
+}
+
+// handler passing a game's unprocessed MLB live feed through, with ?full=1 for every field instead of the ones we model
+func (g *Games) GetRawGame(rw http.ResponseWriter, r *http.Request, store *data.GameCache) {
+	g.logger.Println("[INFO] GET raw called")
+
+	key, err := parseGameKey(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := key.ID
+
+	link, err := store.GetLink(key)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("No tracked game with id %d", id), http.StatusNotFound)
+		return
+	}
+
+	body, err := data.OpenLiveFeed(r.Context(), link, r.URL.Query().Get("full") == "1")
+	if errors.Is(err, data.ErrGameNotFound) {
+		http.Error(rw, fmt.Sprintf("No game with id %d", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch live feed: %s", err), http.StatusBadGateway)
+		return
+	}
+	defer body.Close()
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(rw, body); err != nil {
+		g.logger.Printf("[ERROR] Failed to pass live feed for game %d through: %v\r\n", id, err)
+	}
+}
+
+// handler for a game's boxscore totals
+func (g *Games) GetBoxscore(rw http.ResponseWriter, r *http.Request, boxscores *data.BoxscoreCache) {
+	g.logger.Println("[INFO] GET boxscore called")
+
+	id, err := parseGameID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	boxscore, err := boxscores.Get(r.Context(), id)
+	if errors.Is(err, data.ErrGameNotFound) {
+		http.Error(rw, fmt.Sprintf("No game with id %d", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch boxscore: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	boxscoreJson, err := json.Marshal(boxscore)
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, boxscoreJson)
+}
+
+// handler for a game's scoring plays, for a summary of how the runs scored
+func (g *Games) GetScoring(rw http.ResponseWriter, r *http.Request, scoring *data.ScoringCache) {
+	g.logger.Println("[INFO] GET scoring called")
+
+	id, err := parseGameID(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plays, err := scoring.Get(r.Context(), id)
+	if errors.Is(err, data.ErrGameNotFound) {
+		http.Error(rw, fmt.Sprintf("No game with id %d", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch scoring plays: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	scoringJson, err := json.Marshal(plays)
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, scoringJson)
+}
+
+// read the game id from the request path
+func parseGameID(r *http.Request) (uint32, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid game id: %s", r.PathValue("id"))
+	}
+	return uint32(id), nil
+}
+
+// read the sport requested with ?sportId, MLB if none is
+func parseSportID(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("sportId")
+	if raw == "" {
+		return data.SportID, nil
+	}
+	sportID, err := strconv.Atoi(raw)
+	if err != nil || sportID <= 0 {
+		return 0, fmt.Errorf("Invalid sport id: %s", raw)
+	}
+	return sportID, nil
+}
+
+// read the cached game a request is for, by the id in its path and its ?sportId (MLB by default),
+// since game ids are only unique within a sport
+func parseGameKey(r *http.Request) (data.GameKey, error) {
+	id, err := parseGameID(r)
+	if err != nil {
+		return data.GameKey{}, err
+	}
+	sportID, err := parseSportID(r)
+	if err != nil {
+		return data.GameKey{}, err
+	}
+	return data.GameKey{SportID: sportID, ID: id}, nil
+}
+
+// copy an update with its data narrowed to the given games
+// update data holds either games (objects with an id and usually a sport id) or bare game ids, alongside metadata
+// items without a sport can't tell games of different sports apart, so they are matched by id alone
+func (u *Update) narrow(keys ...data.GameKey) (*Update, error) {
+	// batches keep the updates concerning the games, narrowed to them
+	if u.Batch != nil {
+		return u.mapBatch(func(update *Update) (*Update, error) {
+			if !slices.ContainsFunc(update.IDs, func(key data.GameKey) bool { return slices.Contains(keys, key) }) {
+				return nil, nil
+			}
+			return update.narrow(keys...)
+		})
+	}
+
+	var payload struct {
+		Metadata json.RawMessage   `json:"metadata"`
+		Data     []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(u.Data), &payload); err != nil {
+		return nil, err
+	}
+
+	kept := []json.RawMessage{}
+	for _, item := range payload.Data {
+		var game struct {
+			ID      uint32 `json:"id"`
+			SportID *int   `json:"sport_id"`
+		}
+		if err := json.Unmarshal(item, &game.ID); err != nil {
+			if err := json.Unmarshal(item, &game); err != nil {
+				return nil, err
+			}
+		}
+		matches := func(key data.GameKey) bool { return key.ID == game.ID }
+		if game.SportID != nil {
+			matches = func(key data.GameKey) bool { return key == data.KeyOf(*game.SportID, game.ID) }
+		}
+		if slices.ContainsFunc(keys, matches) {
+			kept = append(kept, item)
+		}
+	}
+	payload.Data = kept
+
+	narrowed, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Update{Event: u.Event, Data: string(narrowed), IDs: keys}, nil
+}
+
+// copy an update with its timestamps converted to loc
+// the updates in a batch are converted too, so they stay converted when the batch is filtered
+func (u *Update) inZone(loc *time.Location) (*Update, error) {
+	update := *u
+	if u.Batch != nil {
+		batch, err := u.mapBatch(func(update *Update) (*Update, error) {
+			return update.inZone(loc)
+		})
+		if err != nil {
+			return nil, err
+		} else if batch != nil {
+			update = *batch
+		}
+	}
+	if update.Data == "" {
+		return &update, nil
+	}
+
+	converted, err := convertTimestamps([]byte(update.Data), loc)
+	if err != nil {
+		return nil, err
+	}
+	update.Data = string(converted)
+	return &update, nil
+}
+
+// keep only the games in an update that are in the given status, or nil if none of them are
+// updates that only list game ids (e.g. removals) carry no status, so they are kept whole
+func (u *Update) withStatus(status data.GameStatus) (*Update, error) {
+	return u.keepGames(func(game updateGame) bool {
+		return data.GameStatus(game.State.Status.General) == status
+	})
+}
+
+// keep only the games in an update of the given sport, counting games without one as MLB, or nil if none of them are
+// updates that only list game ids (e.g. removals) carry no sport, so they are kept whole
+func (u *Update) withSport(sportID int) (*Update, error) {
+	return u.keepGames(func(game updateGame) bool {
+		return game.SportID == sportID || (game.SportID == 0 && sportID == data.SportID)
+	})
+}
+
+// the parts of a game in an update that clients filter on
+type updateGame struct {
+	ID      uint32 `json:"id"`
+	SportID int    `json:"sport_id"`
+	State   struct {
+		Status struct {
+			General string `json:"general"`
+		} `json:"status"`
+	} `json:"state"`
+}
+
+// keep only the games in an update that keep returns true for, or nil if it keeps none of them
+// updates that only list game ids (e.g. removals) are kept whole
+func (u *Update) keepGames(keep func(updateGame) bool) (*Update, error) {
+	if u.Batch != nil {
+		return u.mapBatch(func(update *Update) (*Update, error) {
+			return update.keepGames(keep)
+		})
+	}
+
+	// updates that aren't about any games (e.g. warm-up progress) concern every client
+	if len(u.IDs) == 0 {
+		return u, nil
+	}
+
+	var payload struct {
+		Metadata json.RawMessage   `json:"metadata"`
+		Data     []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(u.Data), &payload); err != nil {
+		return nil, err
+	}
+
+	kept := []json.RawMessage{}
+	var ids []data.GameKey
+	for _, item := range payload.Data {
+		var itemID uint32
+		if err := json.Unmarshal(item, &itemID); err == nil {
+			return u, nil
+		}
+
+		var game updateGame
+		if err := json.Unmarshal(item, &game); err != nil {
+			return nil, err
+		}
+		if keep(game) {
+			kept = append(kept, item)
+			ids = append(ids, data.KeyOf(game.SportID, game.ID))
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil
+	}
+	if len(kept) == len(payload.Data) {
+		return u, nil
+	}
+	payload.Data = kept
+
+	filtered, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
 	}
+	return &Update{Event: u.Event, Data: string(filtered), IDs: ids}, nil
 }
@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// open an update stream, let it run until the context ends, and return what was written
+func streamUpdates(t *testing.T, ctx context.Context, gh *Games, broadcaster *Broadcaster) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/games/update", nil).WithContext(ctx)
+	rw := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		gh.GetUpdates(rw, req, nil, broadcaster)
+		close(done)
+	}()
+	<-done
+
+	return rw.Body.String()
+}
+
+func TestGetUpdatesSendsRetry(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{SSERetry: 2500 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	body := streamUpdates(t, ctx, gh, NewBroadcaster(0, nil))
+
+	assert.True(t, strings.HasPrefix(body, "retry: 2500\n\n"), "stream should open with the retry hint")
+}
+
+func TestGetUpdatesSendsInstance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// each server instance has its own token, which every stream it serves opens with
+	instance := func(gh *Games) string {
+		body := streamUpdates(t, ctx, gh, NewBroadcaster(0, nil))
+		events := strings.Split(strings.TrimSpace(body), "\n\n")
+		if !assert.GreaterOrEqual(t, len(events), 2) {
+			return ""
+		}
+		data, found := strings.CutPrefix(events[1], "event: instance\ndata: ")
+		assert.True(t, found, "the instance should follow the retry hint")
+
+		var event instanceEvent
+		assert.NoError(t, json.Unmarshal([]byte(data), &event))
+		assert.NotEmpty(t, event.Instance)
+		return event.Instance
+	}
+
+	first := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	second := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	assert.Equal(t, instance(first), instance(first), "streams from the same instance should share its token")
+	assert.NotEqual(t, instance(first), instance(second), "streams from different instances should have different tokens")
+}
+
+func TestTrackGame(t *testing.T) {
+	// stub the MLB API with a single known game
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1.1/game/123/feed/live" {
+			http.NotFound(rw, r)
+			return
+		}
+		fmt.Fprint(rw, `{"gamePk": 123, "gameData": {"status": {"abstractGameState": "Preview", "detailedState": "Scheduled"}, "teams": {"away": {"name": "Away Team"}, "home": {"name": "Home Team"}}}}`)
+	}))
+	defer mlb.Close()
+	t.Setenv("MLB_API_URL", mlb.URL)
+
+	logger := log.New(io.Discard, "", 0)
+	gh := NewGames(logger, &config.Config{})
+	store := data.NewGameCache(nil, 0)
+	updates := make(chan Update, 1)
+
+	track := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+id+"/track", nil)
+		req.SetPathValue("id", id)
+		rw := httptest.NewRecorder()
+		gh.TrackGame(rw, req, store, updates)
+		return rw
+	}
+
+	rw := track("123")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	if assert.Len(t, updates, 1, "the new game should be sent through the updates channel") {
+		add := <-updates
+		assert.Equal(t, "add", add.Event)
+		assert.Equal(t, mlbKeys(123), add.IDs)
+	}
+	var game data.Game
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &game))
+	assert.Equal(t, uint32(123), game.ID)
+	assert.Equal(t, data.StatusPreview, game.State.Status.General)
+	_, tracked := store.GetOne(context.Background(), data.MLBKey(123))
+	assert.True(t, tracked, "tracked game should be in the cache")
+
+	rw = track("999")
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	_, tracked = store.GetOne(context.Background(), data.MLBKey(999))
+	assert.False(t, tracked, "unknown game should not be left in the cache")
+
+	rw = track("abc")
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
+
+func TestGetGameUpdatesOnlySendsThatGame(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	gh := NewGames(logger, &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		id, _ := strconv.ParseUint(link, 10, 32)
+		return data.Game{ID: uint32(id), Link: link, Metadata: data.Metadata{Ready: true}}, nil
+	}, 0)
+	for _, id := range []uint32{1, 2} {
+		_, err := store.Discover(data.ScheduledGame{ID: id, Link: strconv.Itoa(int(id))})
+		assert.NoError(t, err)
+	}
+	broadcaster := NewBroadcaster(0, nil)
+
+	stream := func(ctx context.Context, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/"+id+"/update", nil).WithContext(ctx)
+		req.SetPathValue("id", id)
+		rw := httptest.NewRecorder()
+		gh.GetGameUpdates(rw, req, store, broadcaster)
+		return rw
+	}
+
+	rw := stream(context.Background(), "3")
+	assert.Equal(t, http.StatusNotFound, rw.Code, "untracked games should not be streamed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- stream(ctx, "1")
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&broadcaster.Count) == 1 }, time.Second, 5*time.Millisecond)
+
+	broadcaster.Broadcast(&Update{Event: "remove", Data: `{"metadata":{},"data":[2]}`, IDs: mlbKeys(2)}, logger)
+	broadcaster.Broadcast(&Update{Event: "update", Data: `{"metadata":{},"data":[{"id":2},{"id":1}]}`, IDs: mlbKeys(2, 1)}, logger)
+	broadcaster.Broadcast(&Update{Event: "fail", Data: `{"metadata":{},"data":[1]}`, IDs: mlbKeys(1)}, logger)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	body := (<-done).Body.String()
+
+	events := strings.Split(strings.TrimSpace(body), "\n\n")
+	assert.Len(t, events, 5, "stream should hold the retry hint, the instance, the snapshot, and the two events for game 1")
+	assert.True(t, strings.HasPrefix(events[1], "event: instance\ndata: "))
+	assert.True(t, strings.HasPrefix(events[2], "event: snapshot\ndata: "))
+	assert.Contains(t, events[2], `"id":1`)
+	assert.Equal(t, "event: update\ndata: {\"metadata\":{},\"data\":[{\"id\":1}]}", events[3], "batched updates should be narrowed to the game")
+	assert.Equal(t, "event: fail\ndata: {\"metadata\":{},\"data\":[1]}", events[4])
+}
+
+func TestGetUpdatesCatchesUpNewClients(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		id, _ := strconv.ParseUint(link, 10, 32)
+		return data.Game{ID: uint32(id), Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	for _, id := range []uint32{1, 2} {
+		_, err := store.Discover(data.ScheduledGame{ID: id, Link: strconv.Itoa(int(id))})
+		assert.NoError(t, err)
+		store.GetOne(context.Background(), data.MLBKey(id))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/api/games/update", nil).WithContext(ctx)
+	rw := httptest.NewRecorder()
+	broadcaster := NewBroadcaster(0, nil)
+	done := make(chan struct{})
+	go func() {
+		gh.GetUpdates(rw, req, store, broadcaster)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&broadcaster.Count) == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	// the current games are sent without waiting for anything to change
+	events := strings.Split(strings.TrimSpace(rw.Body.String()), "\n\n")
+	if assert.Len(t, events, 3, "stream should hold the retry hint, the instance, and the catch-up snapshot") {
+		snapshot, found := strings.CutPrefix(events[2], "event: snapshot\ndata: ")
+		assert.True(t, found)
+		var games data.Games
+		assert.NoError(t, json.Unmarshal([]byte(snapshot), &games))
+		assert.Len(t, games.Data, 2)
+	}
+}
+
+func TestGetInitialIncludesAPIVersion(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	rw := httptest.NewRecorder()
+	gh.GetInitial(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil), data.NewGameCache(nil, 0))
+
+	var games data.Games
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &games))
+	assert.Equal(t, data.APIVersion, games.Metadata.APIVersion)
+}
+
+func TestGetInitialEmptyCache(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	rw := httptest.NewRecorder()
+	gh.GetInitial(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil), data.NewGameCache(nil, 0))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"data":[]`)
+	assert.NotContains(t, rw.Body.String(), `"data":null`)
+}
+
+func TestGetInitialFiltersBySport(t *testing.T) {
+	const tripleA = 11
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusPreview}}}, nil
+	}, 0)
+	for _, sg := range []data.ScheduledGame{
+		{ID: 1, Link: "1", SportID: data.SportID},
+		{ID: 2, Link: "2", SportID: tripleA},
+		{ID: 3, Link: "3", SportID: tripleA},
+	} {
+		_, err := store.Discover(sg)
+		assert.NoError(t, err)
+		store.GetOne(context.Background(), sg.Key())
+	}
+
+	initialLinks := func(target string) []string {
+		rw := httptest.NewRecorder()
+		gh.GetInitial(rw, httptest.NewRequest(http.MethodGet, target, nil), store)
+		assert.Equal(t, http.StatusOK, rw.Code, target)
+
+		var games data.Games
+		assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &games))
+		var links []string
+		for _, game := range games.Data {
+			links = append(links, game.Link)
+		}
+		return links
+	}
+
+	assert.Equal(t, []string{"1"}, initialLinks("/api/games/initial"), "MLB games should be sent by default")
+	assert.ElementsMatch(t, []string{"2", "3"}, initialLinks("/api/games/initial?sportId=11"))
+	assert.Empty(t, initialLinks("/api/games/initial?sportId=12"))
+
+	rw := httptest.NewRecorder()
+	gh.GetInitial(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial?sportId=mlb", nil), store)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
+
+func TestGetInitialMaxFinalAge(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	now := time.Now()
+	// each game's link is its status and how long ago it started
+	games := map[string]data.Status{
+		"recent-final": {General: data.StatusFinal, StartTime: api_data.Datetime{DateTime: now.Add(-2 * time.Hour)}},
+		"old-final":    {General: data.StatusFinal, StartTime: api_data.Datetime{DateTime: now.Add(-8 * time.Hour)}},
+		"older-final":  {General: data.StatusFinal, StartTime: api_data.Datetime{DateTime: now.Add(-12 * time.Hour)}},
+		"old-live":     {General: data.StatusLive, StartTime: api_data.Datetime{DateTime: now.Add(-8 * time.Hour)}},
+	}
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: games[link]}}, nil
+	}, 0)
+	id := uint32(0)
+	for link := range games {
+		id++
+		_, err := store.Discover(data.ScheduledGame{ID: id, Link: link, SportID: data.SportID})
+		assert.NoError(t, err)
+		store.GetOne(context.Background(), data.MLBKey(id))
+	}
+
+	initialLinks := func(target string) []string {
+		rw := httptest.NewRecorder()
+		gh.GetInitial(rw, httptest.NewRequest(http.MethodGet, target, nil), store)
+		assert.Equal(t, http.StatusOK, rw.Code, target)
+
+		var games data.Games
+		assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &games))
+		var links []string
+		for _, game := range games.Data {
+			links = append(links, game.Link)
+		}
+		return links
+	}
+
+	assert.ElementsMatch(t, []string{"recent-final", "old-final", "older-final", "old-live"}, initialLinks("/api/games/initial"), "all games should be sent by default")
+	assert.ElementsMatch(t, []string{"recent-final", "old-live"}, initialLinks("/api/games/initial?maxFinalAge=3h"), "only old finals should be left out")
+
+	rw := httptest.NewRecorder()
+	gh.GetInitial(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial?maxFinalAge=3", nil), store)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
+
+func TestGetExport(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{
+			ID:       745001,
+			Link:     link,
+			Matchup:  "NYY @ BOS",
+			Metadata: data.Metadata{Ready: true},
+			State: data.State{
+				Teams: data.Teams{
+					Away: data.Team{Info: data.Info{Name: "New York Yankees"}, Score: 3},
+					Home: data.Team{Info: data.Info{Name: "Boston Red Sox"}, Score: 2},
+				},
+				Inning: data.Inning{Number: 7, Top_bottom: "Top"},
+				Status: data.Status{General: data.StatusLive, Detailed: "In Progress"},
+			},
+		}, nil
+	}, 0)
+	_, err := store.Discover(data.ScheduledGame{ID: 745001, Link: "745001", SportID: data.SportID})
+	assert.NoError(t, err)
+	store.GetOne(context.Background(), data.MLBKey(745001))
+
+	rw := httptest.NewRecorder()
+	gh.GetExport(rw, httptest.NewRequest(http.MethodGet, "/api/games/export.csv", nil), store)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="games.csv"`, rw.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,matchup,away,home,away_score,home_score,status,detailed,inning,half\n"+
+		"745001,NYY @ BOS,New York Yankees,Boston Red Sox,3,2,Live,In Progress,7,Top\n", rw.Body.String())
+}
+
+func TestRefreshGame(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	gh := NewGames(logger, &config.Config{})
+
+	outs, failing := uint8(0), false
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		if failing {
+			return data.Game{}, fmt.Errorf("upstream is down")
+		}
+		return data.Game{ID: 1, Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Outs: outs, Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	_, err := store.Discover(data.ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	store.GetOne(context.Background(), data.MLBKey(1))
+
+	updates := make(chan Update, 1)
+
+	refresh := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+id+"/refresh", nil)
+		req.SetPathValue("id", id)
+		rw := httptest.NewRecorder()
+		gh.RefreshGame(rw, req, store, updates)
+		return rw
+	}
+
+	// the game changes upstream between audits
+	outs = 2
+	rw := refresh("1")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	var game data.Game
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &game))
+	assert.Equal(t, uint8(2), game.State.Outs, "refresh should return the updated game")
+	if assert.Len(t, updates, 1, "clients should be told about the refreshed game") {
+		assert.Equal(t, "update", (<-updates).Event)
+	}
+
+	assert.Equal(t, http.StatusNotFound, refresh("2").Code)
+
+	failing = true
+	assert.Equal(t, http.StatusBadGateway, refresh("1").Code)
+}
+
+func TestGetRawGame(t *testing.T) {
+	body := `{"gamePk": 123, "gameData": {"venue": {"name": "Fenway Park"}}}`
+	var fields []string
+	mlb := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fields = append(fields, r.URL.Query().Get("fields"))
+		fmt.Fprint(rw, body)
+	}))
+	defer mlb.Close()
+
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(nil, 0)
+	_, err := store.Discover(data.ScheduledGame{ID: 123, Link: mlb.URL + "/api/v1.1/game/123/feed/live?fields=gamePk"})
+	assert.NoError(t, err)
+
+	raw := func(id string, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/"+id+"/raw"+query, nil)
+		req.SetPathValue("id", id)
+		rw := httptest.NewRecorder()
+		gh.GetRawGame(rw, req, store)
+		return rw
+	}
+
+	rw := raw("123", "")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, body, rw.Body.String(), "upstream body should be passed through untouched")
+
+	rw = raw("123", "?full=1")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, body, rw.Body.String())
+	assert.Equal(t, []string{"gamePk", ""}, fields, "the full feed should drop our field selection")
+
+	assert.Equal(t, http.StatusNotFound, raw("789", "").Code, "untracked games should not be fetched")
+}
+
+func TestGetUpdatesUsesConfiguredBuffer(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{SSEBuffer: 4})
+	broadcaster := NewBroadcaster(0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		streamUpdates(t, ctx, gh, broadcaster)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&broadcaster.Count) == 1 }, time.Second, 5*time.Millisecond)
+
+	broadcaster.clients.Range(func(key, value any) bool {
+		assert.Equal(t, 4, cap(value.(*client).channel), "the client's channel should hold the configured number of updates")
+		return true
+	})
+	cancel()
+	<-done
+}
+
+func TestGetInitialHead(t *testing.T) {
+	changed := time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC)
+	fetched := changed
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{ID: 1, Link: link, Metadata: data.Metadata{Timestamp: fetched, Ready: true}, State: data.State{Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	// the envelope is stamped with the time it was sent, so pin it for GET and HEAD to describe the same body
+	store.SetClock(data.NewFakeClock(time.Date(2024, 7, 4, 19, 30, 0, 0, time.UTC)))
+	_, err := store.Discover(data.ScheduledGame{ID: 1, Link: "1", SportID: data.SportID})
+	assert.NoError(t, err)
+	store.GetOne(context.Background(), data.MLBKey(1))
+
+	// fetching the game again without finding it changed doesn't modify it
+	fetched = changed.Add(5 * time.Minute)
+	_, err = store.Fetch(context.Background(), data.MLBKey(1))
+	assert.NoError(t, err)
+
+	get := httptest.NewRecorder()
+	gh.GetInitial(get, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil), store)
+	head := httptest.NewRecorder()
+	gh.GetInitial(head, httptest.NewRequest(http.MethodHead, "/api/games/initial", nil), store)
+
+	assert.Equal(t, http.StatusOK, head.Code)
+	assert.Empty(t, head.Body.String(), "HEAD responses should have no body")
+	assert.Equal(t, "Thu, 04 Jul 2024 19:05:00 GMT", head.Header().Get("Last-Modified"), "the last modified time should be when the game last changed")
+	assert.Equal(t, get.Header().Get("Last-Modified"), head.Header().Get("Last-Modified"))
+	assert.Equal(t, strconv.Itoa(get.Body.Len()), get.Header().Get("Content-Length"), "the content length should be that of the body")
+	assert.Equal(t, get.Header().Get("Content-Length"), head.Header().Get("Content-Length"), "HEAD responses should have the content length of the body they leave out")
+	assert.Equal(t, "application/json", head.Header().Get("Content-Type"))
+}
+
+func TestGetGamesByDates(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	datedGames := data.NewDateGamesCache(func(ctx context.Context, date string) ([]data.DatedGame, error) {
+		switch date {
+		case "07/04/2024":
+			return []data.DatedGame{{ID: 2, Date: date, StartTime: time.Date(2024, 7, 4, 23, 5, 0, 0, time.UTC), Status: data.StatusFinal}}, nil
+		case "07/05/2024":
+			return []data.DatedGame{{ID: 1, Date: date, StartTime: time.Date(2024, 7, 5, 17, 5, 0, 0, time.UTC), Status: data.StatusFinal}}, nil
+		}
+		return nil, nil
+	}, 8, time.UTC)
+
+	rw := httptest.NewRecorder()
+	gh.GetGamesByDates(rw, httptest.NewRequest(http.MethodGet, "/api/games?dates=07/05/2024,07/04/2024", nil), datedGames)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	var games data.DatedGames
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &games))
+	if assert.Len(t, games.Data, 2) {
+		assert.Equal(t, "07/04/2024", games.Data[0].Date, "games should be sorted by start time, not by the order of the dates")
+		assert.Equal(t, "07/05/2024", games.Data[1].Date)
+	}
+
+	rw = httptest.NewRecorder()
+	gh.GetGamesByDates(rw, httptest.NewRequest(http.MethodGet, "/api/games?dates=2024-07-04", nil), datedGames)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+	rw = httptest.NewRecorder()
+	gh.GetGamesByDates(rw, httptest.NewRequest(http.MethodGet, "/api/games?dates=07/01/2024,07/02/2024,07/03/2024,07/04/2024,07/05/2024,07/06/2024,07/07/2024,07/08/2024", nil), datedGames)
+	assert.Equal(t, http.StatusBadRequest, rw.Code, "too many dates should be rejected")
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+type Health struct {
+	logger *log.Logger
+	ready  atomic.Bool
+}
+
+func NewHealth(l *log.Logger) *Health {
+	return &Health{logger: l}
+}
+
+// mark the server as ready once the games cache is warm
+func (h *Health) SetReady() {
+	h.ready.Store(true)
+}
+
+func (h *Health) Ready() bool {
+	return h.ready.Load()
+}
+
+// handler for readiness checks, which fail until the games cache is warm
+func (h *Health) GetHealth(rw http.ResponseWriter, r *http.Request) {
+	ready := h.Ready()
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	body, err := json.Marshal(map[string]bool{"ready": ready})
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, status, body)
+}
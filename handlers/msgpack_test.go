@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// read a msgpack value written by writeMsgpack back into the types encoding/json would decode to,
+// with integers as int64
+func readMsgpack(r *bytes.Reader) (any, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	readN := func(size int) (int, error) {
+		b := make([]byte, size)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, err
+		}
+		switch size {
+		case 1:
+			return int(b[0]), nil
+		case 2:
+			return int(binary.BigEndian.Uint16(b)), nil
+		default:
+			return int(binary.BigEndian.Uint32(b)), nil
+		}
+	}
+	readString := func(n int) (any, error) {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return string(b), err
+	}
+	readArray := func(n int) (any, error) {
+		array := make([]any, n)
+		for i := range array {
+			if array[i], err = readMsgpack(r); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	}
+	readMap := func(n int) (any, error) {
+		m := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			key, err := readMsgpack(r)
+			if err != nil {
+				return nil, err
+			}
+			if m[key.(string)], err = readMsgpack(r); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	readInt := func(size int, signed bool) (any, error) {
+		b := make([]byte, 8)
+		if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+			return nil, err
+		}
+		if signed && b[8-size]&0x80 != 0 {
+			for i := 0; i < 8-size; i++ {
+				b[i] = 0xff
+			}
+		}
+		return int64(binary.BigEndian.Uint64(b)), nil
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return readString(int(code & 0x1f))
+	case code&0xf0 == 0x90:
+		return readArray(int(code & 0x0f))
+	case code&0xf0 == 0x80:
+		return readMap(int(code & 0x0f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcb:
+		bits, err := readInt(8, false)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(uint64(bits.(int64))), nil
+	case 0xcc, 0xcd, 0xce:
+		return readInt(1<<(code-0xcc), false)
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return readInt(1<<(code-0xd0), true)
+	case 0xd9, 0xda, 0xdb:
+		n, err := readN(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return readString(n)
+	case 0xdc, 0xdd:
+		n, err := readN(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readArray(n)
+	case 0xde, 0xdf:
+		n, err := readN(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMap(n)
+	}
+	return nil, fmt.Errorf("unexpected msgpack code 0x%x", code)
+}
+
+// decode JSON the way readMsgpack does, with integers as int64 and other numbers as float64
+func decodeJSONNumbers(t *testing.T, payload string) any {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	assert.NoError(t, decoder.Decode(&value))
+
+	var normalize func(any) any
+	normalize = func(value any) any {
+		switch v := value.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				return i
+			}
+			f, _ := v.Float64()
+			return f
+		case []any:
+			for i := range v {
+				v[i] = normalize(v[i])
+			}
+		case map[string]any:
+			for key := range v {
+				v[key] = normalize(v[key])
+			}
+		}
+		return value
+	}
+	return normalize(value)
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	games := &data.Games{
+		Metadata: data.Metadata{Timestamp: time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC), Ready: true},
+		Data: []*data.Game{{
+			ID:   745001,
+			Link: "/api/v1.1/game/745001/feed/live",
+			State: data.State{
+				Teams: data.Teams{
+					Away: data.Team{Info: data.Info{Name: "New York Yankees"}, Score: 3},
+					Home: data.Team{Info: data.Info{Name: "Boston Red Sox"}, Score: 4},
+				},
+				Inning: data.Inning{Number: 9, Top_bottom: "Bottom"},
+				Status: data.Status{General: data.StatusLive, Detailed: "In Progress"},
+			},
+		}},
+	}
+	gamesJson, err := games.ToJSON()
+	assert.NoError(t, err)
+
+	cases := map[string]string{
+		"game":          string(gamesJson),
+		"long string":   fmt.Sprintf(`{"name": %q}`, strings.Repeat("x", 70000)),
+		"long array":    fmt.Sprintf(`[%s1]`, strings.Repeat("1,", 20)),
+		"numbers":       `[0, 127, 128, 255, 256, 65535, 65536, 4294967296, -1, -32, -33, -128, -129, -32768, -32769, -2147483649, 1.5, -0.25, 1e300]`,
+		"empty values":  `{"array": [], "object": {}, "string": "", "null": null, "bool": false}`,
+		"unicode title": `{"name": "Ronald Acuña Jr."}`,
+	}
+
+	for name, payload := range cases {
+		encoded, err := encodePayload(payload, FormatMsgpack)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		packed, err := base64.StdEncoding.DecodeString(encoded)
+		assert.NoError(t, err, name)
+
+		r := bytes.NewReader(packed)
+		decoded, err := readMsgpack(r)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0, r.Len(), "%s: nothing should follow the value", name)
+		assert.Equal(t, decodeJSONNumbers(t, payload), decoded, name)
+	}
+
+	packed, err := jsonToMsgpack(string(gamesJson))
+	assert.NoError(t, err)
+	assert.Less(t, len(packed), len(gamesJson), "msgpack should be smaller than the JSON it came from")
+}
+
+func TestEncodePayloadDefaultsToJSON(t *testing.T) {
+	for _, format := range []string{"", FormatJSON} {
+		encoded, err := encodePayload(`{"a": 1}`, format)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a": 1}`, encoded)
+	}
+}
+
+func TestGetUpdatesUnknownFormat(t *testing.T) {
+	g := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	rw := httptest.NewRecorder()
+	g.GetUpdates(rw, httptest.NewRequest(http.MethodGet, "/api/games/update?format=xml", nil), nil, NewBroadcaster(0, nil))
+
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
+
+func TestGetUpdatesSendsMsgpack(t *testing.T) {
+	g := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rw := httptest.NewRecorder()
+	g.GetUpdates(rw, httptest.NewRequest(http.MethodGet, "/api/games/update?format=msgpack", nil).WithContext(ctx), nil, NewBroadcaster(0, nil))
+
+	// the instance event that opens the stream is sent as msgpack
+	events := strings.Split(strings.TrimSpace(rw.Body.String()), "\n\n")
+	if !assert.GreaterOrEqual(t, len(events), 2) {
+		return
+	}
+	encoded, found := strings.CutPrefix(events[1], "event: instance\ndata: ")
+	if !assert.True(t, found) {
+		return
+	}
+	packed, err := base64.StdEncoding.DecodeString(encoded)
+	assert.NoError(t, err)
+	decoded, err := readMsgpack(bytes.NewReader(packed))
+	assert.NoError(t, err)
+	assert.Contains(t, decoded, "instance")
+}
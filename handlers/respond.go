@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// key naming conventions a response can be sent in
+const (
+	CaseSnake = "snake"
+	CaseCamel = "camel"
+)
+
+// the zone the timestamps in a request's JSON response are converted to, kept in its context
+type outputTimezone struct {
+	location *time.Location
+	logger   *log.Logger
+}
+
+type outputTimezoneKey struct{}
+
+// wrap a handler so the timestamps in its JSON responses are sent in loc, keeping the instants they stand for
+func OutputTimezone(next http.Handler, loc *time.Location, logger *log.Logger) http.Handler {
+	zone := &outputTimezone{location: loc, logger: logger}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), outputTimezoneKey{}, zone)))
+	})
+}
+
+// write a JSON response, indented for reading by hand if the request asks for ?pretty=1,
+// and with camelCase keys if it asks for ?case=camel
+// HEAD requests are answered with the same headers, including the Content-Length, but no body
+// with Server-Timing on, the time spent rewriting the body counts towards its serialization
+func writeJSON(rw http.ResponseWriter, r *http.Request, status int, body []byte) {
+	start := time.Now()
+	naming := r.URL.Query().Get("case")
+	if !validCase(naming) {
+		http.Error(rw, fmt.Sprintf("Unknown case: %s", naming), http.StatusBadRequest)
+		return
+	}
+	if naming == CaseCamel {
+		if camel, err := camelCaseKeys(body); err == nil {
+			body = camel
+		}
+	}
+	if zone, ok := r.Context().Value(outputTimezoneKey{}).(*outputTimezone); ok {
+		if converted, err := convertTimestamps(body, zone.location); err != nil {
+			zone.logger.Printf("[ERROR] Failed to convert response timestamps: %v\r\n", err)
+		} else {
+			body = converted
+		}
+	}
+
+	if r.URL.Query().Get("pretty") == "1" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err == nil {
+			body = indented.Bytes()
+		}
+	}
+
+	recordTiming(r, TimingSerialize, start)
+	if timing := serverTimingHeader(r); timing != "" {
+		rw.Header().Set("Server-Timing", timing)
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	rw.WriteHeader(status)
+	// HEAD requests get the headers the body would have, without it
+	if r.Method != http.MethodHead {
+		rw.Write(body)
+	}
+}
+
+// whether a ?case value is one responses can be sent in, empty for the struct tags as they are
+func validCase(naming string) bool {
+	return naming == "" || naming == CaseSnake || naming == CaseCamel
+}
+
+// rename every object key in a JSON document from snake_case to camelCase, keeping everything else as is
+// the struct tags stay snake_case, since the MLB field lists are generated from them
+func camelCaseKeys(body []byte) ([]byte, error) {
+	return rewriteJSON(body, camelCase, nil)
+}
+
+// convert every timestamp in a JSON document to the given zone, keeping the instants they stand for
+// zero times are left alone, since they mean the time is unknown
+func convertTimestamps(body []byte, loc *time.Location) ([]byte, error) {
+	return rewriteJSON(body, nil, func(value string) string {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil || t.IsZero() {
+			return value
+		}
+		return t.In(loc).Format(time.RFC3339Nano)
+	})
+}
+
+// rewrite the object keys and string values of a JSON document, keeping its structure and key order
+// either function can be nil to leave what it would rewrite as is
+func rewriteJSON(body []byte, key func(string) string, value func(string) string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	if err := copyRewritten(decoder, &out, key, value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+	return out.Bytes(), nil
+}
+
+// copy the next JSON value from the decoder to out, rewriting its object keys and string values
+func copyRewritten(decoder *json.Decoder, out *bytes.Buffer, key func(string) string, value func(string) string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		if str, isString := token.(string); isString && value != nil {
+			token = value(str)
+		}
+		encoded, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		out.WriteByte('{')
+		for i := 0; decoder.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			name := token.(string)
+			if key != nil {
+				name = key(name)
+			}
+			encoded, err := json.Marshal(name)
+			if err != nil {
+				return err
+			}
+			out.Write(encoded)
+			out.WriteByte(':')
+			if err := copyRewritten(decoder, out, key, value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case '[':
+		out.WriteByte('[')
+		for i := 0; decoder.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := copyRewritten(decoder, out, key, value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	}
+
+	// consume the closing delimiter
+	_, err = decoder.Token()
+	return err
+}
+
+// convert a snake_case name to camelCase, e.g. top_bottom to topBottom
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if r, size := utf8.DecodeRuneInString(parts[i]); size > 0 {
+			parts[i] = string(unicode.ToUpper(r)) + parts[i][size:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONPretty(t *testing.T) {
+	body := []byte(`{"metadata":{"ready":true},"data":[1,2]}`)
+
+	rw := httptest.NewRecorder()
+	writeJSON(rw, httptest.NewRequest(http.MethodGet, "/api/standings?pretty=1", nil), http.StatusOK, body)
+	assert.Equal(t, "{\n  \"metadata\": {\n    \"ready\": true\n  },\n  \"data\": [\n    1,\n    2\n  ]\n}", rw.Body.String(), "pretty responses should be indented")
+	assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+	rw = httptest.NewRecorder()
+	writeJSON(rw, httptest.NewRequest(http.MethodGet, "/api/standings", nil), http.StatusOK, body)
+	assert.Equal(t, string(body), rw.Body.String(), "responses should be minified by default")
+}
+
+func TestWriteJSONCamelCase(t *testing.T) {
+	body := []byte(`{"metadata":{"api_version":"1"},"data":[{"id":1,"state":{"inning":{"number":3,"top_bottom":"Top"},"delay_reason":"rain_delay"}}]}`)
+
+	rw := httptest.NewRecorder()
+	writeJSON(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial?case=camel", nil), http.StatusOK, body)
+	assert.Equal(t, `{"metadata":{"apiVersion":"1"},"data":[{"id":1,"state":{"inning":{"number":3,"topBottom":"Top"},"delayReason":"rain_delay"}}]}`, rw.Body.String(), "keys should be renamed in place, leaving values alone")
+
+	rw = httptest.NewRecorder()
+	writeJSON(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil), http.StatusOK, body)
+	assert.Equal(t, string(body), rw.Body.String(), "keys should follow the struct tags by default")
+
+	rw = httptest.NewRecorder()
+	writeJSON(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial?case=kebab", nil), http.StatusOK, body)
+	assert.Equal(t, http.StatusBadRequest, rw.Code, "unknown cases should be rejected")
+}
+
+func TestWriteJSONOutputTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	games := &data.Games{
+		Metadata: data.Metadata{Timestamp: time.Date(2024, 7, 4, 23, 5, 0, 0, time.UTC)},
+		Data:     []*data.Game{{ID: 1, State: data.State{Status: data.Status{General: data.StatusLive, StartTime: api_data.Datetime{DateTime: time.Date(2024, 7, 4, 23, 5, 30, 0, time.UTC)}}}}},
+	}
+	body, err := games.ToJSON()
+	assert.NoError(t, err)
+
+	handler := OutputTimezone(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writeJSON(rw, r, http.StatusOK, body)
+	}), newYork, log.New(io.Discard, "", 0))
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil))
+
+	var sent data.Games
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &sent))
+	assert.Contains(t, rw.Body.String(), `"timestamp":"2024-07-04T19:05:00-04:00"`, "timestamps should be sent in the configured zone")
+	assert.Contains(t, rw.Body.String(), `"dateTime":"2024-07-04T19:05:30-04:00"`)
+	assert.True(t, sent.Data[0].State.Status.StartTime.DateTime.Equal(games.Data[0].State.Status.StartTime.DateTime), "the instant should be unchanged")
+	assert.Contains(t, rw.Body.String(), `"timestamp":"0001-01-01T00:00:00Z"`, "unknown times should be left alone")
+}
+
+func TestWriteJSONLogsFailedConversions(t *testing.T) {
+	var logs strings.Builder
+	handler := OutputTimezone(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writeJSON(rw, r, http.StatusOK, []byte(`{"timestamp":"2024-07-04T23:05:00Z"`))
+	}), time.UTC, log.New(&logs, "", 0))
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil))
+
+	assert.Equal(t, `{"timestamp":"2024-07-04T23:05:00Z"`, rw.Body.String(), "a body that can't be converted should be sent as it is")
+	assert.Contains(t, logs.String(), "[ERROR] Failed to convert response timestamps")
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/claycot/mlb-gameday-api/data"
+)
+
+type Standings struct {
+	logger *log.Logger
+}
+
+func NewStandings(l *log.Logger) *Standings {
+	return &Standings{l}
+}
+
+// handler for the current division standings
+func (s *Standings) GetStandings(rw http.ResponseWriter, r *http.Request, store *data.StandingsCache) {
+	s.logger.Println("[INFO] GET standings called")
+
+	standings, err := store.Get(r.Context())
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch standings: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	standingsJson, err := standings.ToJSON()
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, standingsJson)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+)
+
+type Teams struct {
+	logger *log.Logger
+}
+
+func NewTeams(l *log.Logger) *Teams {
+	return &Teams{l}
+}
+
+// handler for a team's games between ?start= and ?end= dates (YYYY-MM-DD, inclusive)
+func (t *Teams) GetTeamGames(rw http.ResponseWriter, r *http.Request, schedules *data.TeamScheduleCache) {
+	t.logger.Println("[INFO] GET team games called")
+
+	teamID, err := strconv.ParseUint(r.PathValue("teamId"), 10, 32)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid team id: %s", r.PathValue("teamId")), http.StatusBadRequest)
+		return
+	}
+
+	start, end := r.URL.Query().Get("start"), r.URL.Query().Get("end")
+	startDate, err := time.Parse(data.DateFormat, start)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid start date: %q", start), http.StatusBadRequest)
+		return
+	}
+	endDate, err := time.Parse(data.DateFormat, end)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid end date: %q", end), http.StatusBadRequest)
+		return
+	}
+	if endDate.Before(startDate) {
+		http.Error(rw, "end date is before start date", http.StatusBadRequest)
+		return
+	}
+
+	games, err := schedules.Get(r.Context(), uint32(teamID), start, end)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch team games: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	teamGames := &data.TeamGames{
+		Metadata: data.Metadata{
+			Timestamp: time.Now(),
+			Ready:     true,
+		},
+		Data: games,
+	}
+	gamesJson, err := teamGames.ToJSON()
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, gamesJson)
+}
+
+// handler for a team's next game that hasn't finished, within the next data.NextGameWindow days
+func (t *Teams) GetNextGame(rw http.ResponseWriter, r *http.Request, nextGames *data.NextGameCache) {
+	t.logger.Println("[INFO] GET team next game called")
+
+	teamID, err := strconv.ParseUint(r.PathValue("teamId"), 10, 32)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid team id: %s", r.PathValue("teamId")), http.StatusBadRequest)
+		return
+	}
+
+	game, err := nextGames.Get(r.Context(), uint32(teamID))
+	if errors.Is(err, data.ErrNoNextGame) {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(rw, fmt.Sprintf("Unable to fetch team schedule: %s", err), http.StatusBadGateway)
+		return
+	}
+
+	nextGame := &data.TeamNextGame{
+		Metadata: data.Metadata{
+			Timestamp: time.Now(),
+			Ready:     true,
+		},
+		Data: *game,
+	}
+	gameJson, err := nextGame.ToJSON()
+	if err != nil {
+		http.Error(rw, "Unable to marshal JSON", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, gameJson)
+}
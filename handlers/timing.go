@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+)
+
+// metrics reported in the Server-Timing header
+const (
+	// looking games up in the cache
+	TimingCache = "cache"
+	// fetching games from the MLB API on demand, for games that weren't ready
+	TimingFetch = "fetch"
+	// marshalling and rewriting the response body
+	TimingSerialize = "serialize"
+)
+
+// the time spent on each part of a request, kept in its context when Server-Timing is on
+type serverTiming struct {
+	mu      sync.Mutex
+	names   []string
+	elapsed map[string]time.Duration
+}
+
+type serverTimingKey struct{}
+
+// wrap a handler so its JSON responses report where their time went in a Server-Timing header, for browser devtools
+func ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		timing := &serverTiming{elapsed: make(map[string]time.Duration)}
+		ctx := context.WithValue(r.Context(), serverTimingKey{}, timing)
+		// games fetched on demand while serving the request, wherever it happens, count as fetch time
+		ctx = data.WithFetchTimer(ctx, func(elapsed time.Duration) {
+			timing.add(TimingFetch, elapsed)
+		})
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// add the time since start to a metric of the request, if it is being timed
+// a metric recorded more than once is reported as the total
+func recordTiming(r *http.Request, name string, start time.Time) {
+	timing, ok := r.Context().Value(serverTimingKey{}).(*serverTiming)
+	if !ok {
+		return
+	}
+	timing.add(name, time.Since(start))
+}
+
+// start timing a cache lookup, returning a function that records it once it's done
+// games fetched on demand during the lookup are left out, since they are reported as fetch time
+func timeLookup(r *http.Request) func() {
+	timing, ok := r.Context().Value(serverTimingKey{}).(*serverTiming)
+	if !ok {
+		return func() {}
+	}
+	start := time.Now()
+	fetched := timing.get(TimingFetch)
+	return func() {
+		timing.add(TimingCache, time.Since(start)-(timing.get(TimingFetch)-fetched))
+	}
+}
+
+func (st *serverTiming) add(name string, elapsed time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, exists := st.elapsed[name]; !exists {
+		st.names = append(st.names, name)
+	}
+	st.elapsed[name] += elapsed
+}
+
+func (st *serverTiming) get(name string) time.Duration {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.elapsed[name]
+}
+
+// the Server-Timing header value for the request, in the order the metrics were first recorded,
+// e.g. "cache;dur=0.412, serialize;dur=1.030", or empty if the request isn't being timed
+func serverTimingHeader(r *http.Request) string {
+	timing, ok := r.Context().Value(serverTimingKey{}).(*serverTiming)
+	if !ok {
+		return ""
+	}
+
+	timing.mu.Lock()
+	defer timing.mu.Unlock()
+	metrics := make([]string, len(timing.names))
+	for i, name := range timing.names {
+		metrics[i] = fmt.Sprintf("%s;dur=%.3f", name, float64(timing.elapsed[name].Microseconds())/1000)
+	}
+	return strings.Join(metrics, ", ")
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerTiming(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{ID: 1, Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	_, err := store.Discover(data.ScheduledGame{ID: 1, Link: "1", SportID: data.SportID})
+	assert.NoError(t, err)
+	store.GetOne(context.Background(), data.MLBKey(1))
+	initial := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetInitial(rw, r, store)
+	})
+
+	rw := httptest.NewRecorder()
+	ServerTiming(initial).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	timing := rw.Header().Get("Server-Timing")
+	assert.Regexp(t, regexp.MustCompile(`^cache;dur=\d+\.\d{3}, serialize;dur=\d+\.\d{3}$`), timing, "each metric should be listed once with its duration in milliseconds")
+
+	rw = httptest.NewRecorder()
+	initial.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil))
+	assert.Empty(t, rw.Header().Get("Server-Timing"), "responses should not be timed unless it is turned on")
+}
+
+// fetches made on demand count as fetch time, and not as time spent in the cache
+func TestServerTimingSeparatesFetches(t *testing.T) {
+	gh := NewGames(log.New(io.Discard, "", 0), &config.Config{})
+	store := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		time.Sleep(20 * time.Millisecond)
+		return data.Game{ID: 1, Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	_, err := store.Discover(data.ScheduledGame{ID: 1, Link: "1", SportID: data.SportID})
+	assert.NoError(t, err)
+	updates := make(chan Update, 1)
+	refresh := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		gh.RefreshGame(rw, r, store, updates)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/games/1/refresh", nil)
+	req.SetPathValue("id", "1")
+	rw := httptest.NewRecorder()
+	ServerTiming(refresh).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	durations := make(map[string]float64)
+	for _, match := range regexp.MustCompile(`(\w+);dur=(\d+\.\d{3})`).FindAllStringSubmatch(rw.Header().Get("Server-Timing"), -1) {
+		durations[match[1]], _ = strconv.ParseFloat(match[2], 64)
+	}
+	assert.GreaterOrEqual(t, durations[TimingFetch], 20.0, "the fetch should be reported")
+	assert.Less(t, durations[TimingCache], 20.0, "the fetch should not count towards the cache lookup")
+}
@@ -1,18 +1,63 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/claycot/mlb-gameday-api/data"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port           int
-	Hostname       string
-	AllowedOrigins []string
+	Port              int
+	Hostname          string
+	SocketPath        string
+	AllowedOrigins    []string
+	ReplayDir         string
+	DateOffsets       []int
+	SSERetry          time.Duration
+	FetchConcurrency  int
+	APIKey            string
+	Timezone          *time.Location
+	AuditInterval     time.Duration
+	FindInterval      time.Duration
+	MLBAPIURL         string
+	BreakerThreshold  int
+	BreakerCooldown   time.Duration
+	SnapshotInterval  time.Duration
+	BoxscoreCacheSize int
+	StandingsTTL      time.Duration
+	StartingSoonLead  time.Duration
+	ArchiveDir        string
+	ArchiveInterval   time.Duration
+	ArchiveRetention  int
+	FeaturedGames     *data.Featured
+	SportIDs          []int
+	SSEMaxDrops       uint64
+	SSEBuffer         int
+	RemovedRetention  time.Duration
+	RateLimit         float64
+	RateBurst         int
+	TrustProxy        bool
+	LookAheadDays     int
+	PreviewDays       int
+	EarlyHints        bool
+	TLSCert           string
+	TLSKey            string
+	TLSRedirectPort   int
+	MissingThreshold  int
+	BatchUpdates      bool
+	PrefetchGames     bool
+	UpdateThrottle    map[data.GameStatus]time.Duration
+	AuditFetchBudget  int
+	OutputTimezone    *time.Location
+	ServerTiming      bool
+	LogUpstreamSizes  bool
+	Dispositions      data.Dispositions
 }
 
 // load the config, with defaults if the .env file doesn't exist or values are not provided
@@ -28,10 +73,311 @@ func Load(logger *log.Logger) (*Config, error) {
 		return nil, err
 	}
 
+	// sports whose games are tracked, e.g. "1,11" for MLB and Triple-A
+	sportIDs, err := getEnvInts("SPORT_IDS", "1")
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse SPORT_IDS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// days relative to today whose games are tracked, e.g. "-1,0" for yesterday and today
+	dateOffsets, err := getEnvInts("DATE_OFFSETS", "0")
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse DATE_OFFSETS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how long clients should wait before reconnecting to the SSE stream
+	sseRetryMs, err := strconv.Atoi(getEnv("SSE_RETRY_MS", "3000"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse SSE_RETRY_MS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// consecutive updates a client can miss for not reading before it is disconnected (0 never disconnects)
+	sseMaxDrops, err := strconv.ParseUint(getEnv("SSE_MAX_DROPS", "32"), 10, 64)
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse SSE_MAX_DROPS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// updates queued per client before they start being dropped
+	// a larger buffer rides out slow networks with fewer drops, but holds more memory for every client
+	sseBuffer, err := strconv.Atoi(getEnv("SSE_BUFFER", "16"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse SSE_BUFFER var: %v\r\n", err)
+		return nil, err
+	}
+
+	// maximum number of simultaneous game fetches from the MLB API
+	fetchConcurrency, err := strconv.Atoi(getEnv("FETCH_CONCURRENCY", "8"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse FETCH_CONCURRENCY var: %v\r\n", err)
+		return nil, err
+	}
+
+	// timezone used to decide what "today" is, since the server might change day early
+	timezone, err := time.LoadLocation(getEnv("TIMEZONE", "America/Los_Angeles"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse TIMEZONE var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how often cached games are audited and new games are looked for
+	auditInterval, err := time.ParseDuration(getEnv("AUDIT_INTERVAL", "30s"))
+	if err == nil && auditInterval <= 0 {
+		err = fmt.Errorf("interval must be positive: %v", auditInterval)
+	}
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse AUDIT_INTERVAL var: %v\r\n", err)
+		return nil, err
+	}
+	findInterval, err := time.ParseDuration(getEnv("FIND_INTERVAL", "15m"))
+	if err == nil && findInterval <= 0 {
+		err = fmt.Errorf("interval must be positive: %v", findInterval)
+	}
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse FIND_INTERVAL var: %v\r\n", err)
+		return nil, err
+	}
+
+	// consecutive MLB API failures before calls are paused, and for how long
+	breakerThreshold, err := strconv.Atoi(getEnv("BREAKER_THRESHOLD", "5"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse BREAKER_THRESHOLD var: %v\r\n", err)
+		return nil, err
+	}
+	breakerCooldown, err := time.ParseDuration(getEnv("BREAKER_COOLDOWN", "30s"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse BREAKER_COOLDOWN var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how often a full snapshot of the games is sent over SSE (0 disables snapshots)
+	snapshotInterval, err := time.ParseDuration(getEnv("SNAPSHOT_INTERVAL", "0s"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse SNAPSHOT_INTERVAL var: %v\r\n", err)
+		return nil, err
+	}
+
+	// number of final game boxscores kept in memory
+	boxscoreCacheSize, err := strconv.Atoi(getEnv("BOXSCORE_CACHE_SIZE", "64"))
+	if err == nil && boxscoreCacheSize <= 0 {
+		err = fmt.Errorf("cache size must be positive: %d", boxscoreCacheSize)
+	}
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse BOXSCORE_CACHE_SIZE var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how long standings are cached before being refetched
+	standingsTTL, err := time.ParseDuration(getEnv("STANDINGS_TTL", "10m"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse STANDINGS_TTL var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how far ahead of first pitch games are announced as starting soon (0 disables announcements)
+	startingSoonLead, err := time.ParseDuration(getEnv("STARTING_SOON_LEAD", "30m"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse STARTING_SOON_LEAD var: %v\r\n", err)
+		return nil, err
+	}
+
+	// featured games are listed in FEATURED_GAMES as game ids or team matchups, e.g. "745123,NYY-BOS"
+	featuredGames, err := data.ParseFeatured(strings.Split(getEnv("FEATURED_GAMES", ""), ","))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse FEATURED_GAMES var: %v\r\n", err)
+		return nil, err
+	}
+
+	// overrides of the pruning rules by detailed state, e.g. "Forfeit=prune,Completed Early=keep,Cancelled=prune-after:2h"
+	dispositions, err := data.ParseDispositions(strings.Split(getEnv("GAME_DISPOSITIONS", ""), ","))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse GAME_DISPOSITIONS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how often gzipped snapshots of the cache are written to ARCHIVE_DIR, and how many are kept
+	// the newest one is also read on startup, so notable events announced before a restart aren't announced again
+	archiveInterval, err := time.ParseDuration(getEnv("ARCHIVE_INTERVAL", "5m"))
+	if err == nil && archiveInterval <= 0 {
+		err = fmt.Errorf("interval must be positive: %v", archiveInterval)
+	}
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse ARCHIVE_INTERVAL var: %v\r\n", err)
+		return nil, err
+	}
+	archiveRetention, err := strconv.Atoi(getEnv("ARCHIVE_RETENTION", "288"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse ARCHIVE_RETENTION var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how long removed game ids are listed in the initial games, so clients that missed the removal can drop them
+	removedRetention, err := time.ParseDuration(getEnv("REMOVED_RETENTION", "6h"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse REMOVED_RETENTION var: %v\r\n", err)
+		return nil, err
+	}
+
+	// requests per second allowed from each client IP on the non-streaming endpoints, and how many may come at once
+	// a rate of 0, the default, disables rate limiting, since behind a proxy every client shares its IP unless TRUST_PROXY is set
+	rateLimit, err := strconv.ParseFloat(getEnv("RATE_LIMIT", "0"), 64)
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse RATE_LIMIT var: %v\r\n", err)
+		return nil, err
+	}
+	rateBurst, err := strconv.Atoi(getEnv("RATE_BURST", "20"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse RATE_BURST var: %v\r\n", err)
+		return nil, err
+	}
+
+	// when running behind a trusted reverse proxy, client IPs are taken from Fly-Client-IP or X-Forwarded-For
+	trustProxy, err := strconv.ParseBool(getEnv("TRUST_PROXY", "false"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse TRUST_PROXY var: %v\r\n", err)
+		return nil, err
+	}
+
+	// on an off-day with no games on any tracked date, how many days ahead to look for the next games (0 doesn't look)
+	lookAheadDays, err := strconv.Atoi(getEnv("LOOK_AHEAD_DAYS", "0"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse LOOK_AHEAD_DAYS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// how many days after the last tracked date to also load games from, as upcoming previews for a multi-day board
+	previewDays, err := strconv.Atoi(getEnv("PREVIEW_DAYS", "0"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse PREVIEW_DAYS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// send 103 Early Hints on the initial games so browsers can connect ahead of opening the update stream
+	earlyHints, err := strconv.ParseBool(getEnv("EARLY_HINTS", "false"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse EARLY_HINTS var: %v\r\n", err)
+		return nil, err
+	}
+
+	// with a TLS certificate and key, a port on which plain HTTP requests are redirected to HTTPS (0 doesn't redirect)
+	tlsRedirectPort, err := strconv.Atoi(getEnv("TLS_REDIRECT_PORT", "0"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse TLS_REDIRECT_PORT var: %v\r\n", err)
+		return nil, err
+	}
+
+	// consecutive schedule listings a game can be missing from before it is removed (0, the default, never removes it)
+	missingThreshold, err := strconv.Atoi(getEnv("MISSING_THRESHOLD", "0"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse MISSING_THRESHOLD var: %v\r\n", err)
+		return nil, err
+	}
+
+	// send everything from an audit cycle to clients as one batched "updates" event instead of an event each
+	batchUpdates, err := strconv.ParseBool(getEnv("BATCH_UPDATES", "false"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse BATCH_UPDATES var: %v\r\n", err)
+		return nil, err
+	}
+
+	// fetch games in the background as soon as they are discovered, so the initial games don't wait on them
+	prefetchGames, err := strconv.ParseBool(getEnv("PREFETCH_GAMES", "false"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse PREFETCH_GAMES var: %v\r\n", err)
+		return nil, err
+	}
+
+	// the least time between updates sent for a game, per status, e.g. "Live=10s" (changes in between are coalesced)
+	updateThrottle, err := getEnvStatusDurations("UPDATE_THROTTLE", "")
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse UPDATE_THROTTLE var: %v\r\n", err)
+		return nil, err
+	}
+
+	// most games refreshed per audit, live games first but with a quarter kept for the others,
+	// and the rest left for the next audit (0 refreshes every due game)
+	auditFetchBudget, err := strconv.Atoi(getEnv("AUDIT_FETCH_BUDGET", "0"))
+	if err == nil && auditFetchBudget < 0 {
+		err = fmt.Errorf("budget can't be negative: %d", auditFetchBudget)
+	}
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse AUDIT_FETCH_BUDGET var: %v\r\n", err)
+		return nil, err
+	}
+
+	// zone the timestamps sent to clients are converted to, e.g. "America/New_York" (empty sends them as they are)
+	var outputTimezone *time.Location
+	if name := getEnv("OUTPUT_TIMEZONE", ""); name != "" {
+		outputTimezone, err = time.LoadLocation(name)
+		if err != nil {
+			logger.Printf("[ERROR] Failed to parse OUTPUT_TIMEZONE var: %v\r\n", err)
+			return nil, err
+		}
+	}
+
+	// debugging aid: break down where JSON responses spend their time in a Server-Timing header
+	serverTiming, err := strconv.ParseBool(getEnv("SERVER_TIMING", "false"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse SERVER_TIMING var: %v\r\n", err)
+		return nil, err
+	}
+
+	// debugging aid: log the size of every response read from the MLB API, on top of the totals on the status endpoint
+	logUpstreamSizes, err := strconv.ParseBool(getEnv("LOG_UPSTREAM_SIZES", "false"))
+	if err != nil {
+		logger.Printf("[ERROR] Failed to parse LOG_UPSTREAM_SIZES var: %v\r\n", err)
+		return nil, err
+	}
+
 	return &Config{
-		Port:           port,
-		Hostname:       getEnv("HOSTNAME_", ""),
-		AllowedOrigins: strings.Split(getEnv("ALLOWED_ORIGINS", "*"), ","),
+		Port:              port,
+		Hostname:          getEnv("HOSTNAME_", ""),
+		SocketPath:        getEnv("SOCKET_PATH", ""),
+		AllowedOrigins:    strings.Split(getEnv("ALLOWED_ORIGINS", "*"), ","),
+		ReplayDir:         getEnv("REPLAY_DIR", ""),
+		DateOffsets:       dateOffsets,
+		SSERetry:          time.Duration(sseRetryMs) * time.Millisecond,
+		FetchConcurrency:  fetchConcurrency,
+		APIKey:            getEnv("API_KEY", ""),
+		Timezone:          timezone,
+		AuditInterval:     auditInterval,
+		FindInterval:      findInterval,
+		MLBAPIURL:         getEnv("MLB_API_URL", ""),
+		BreakerThreshold:  breakerThreshold,
+		BreakerCooldown:   breakerCooldown,
+		SnapshotInterval:  snapshotInterval,
+		BoxscoreCacheSize: boxscoreCacheSize,
+		StandingsTTL:      standingsTTL,
+		StartingSoonLead:  startingSoonLead,
+		ArchiveDir:        getEnv("ARCHIVE_DIR", ""),
+		ArchiveInterval:   archiveInterval,
+		ArchiveRetention:  archiveRetention,
+		FeaturedGames:     featuredGames,
+		SportIDs:          sportIDs,
+		SSEMaxDrops:       sseMaxDrops,
+		SSEBuffer:         sseBuffer,
+		RemovedRetention:  removedRetention,
+		RateLimit:         rateLimit,
+		RateBurst:         rateBurst,
+		TrustProxy:        trustProxy,
+		LookAheadDays:     lookAheadDays,
+		PreviewDays:       previewDays,
+		EarlyHints:        earlyHints,
+		TLSCert:           getEnv("TLS_CERT", ""),
+		TLSKey:            getEnv("TLS_KEY", ""),
+		TLSRedirectPort:   tlsRedirectPort,
+		MissingThreshold:  missingThreshold,
+		BatchUpdates:      batchUpdates,
+		PrefetchGames:     prefetchGames,
+		UpdateThrottle:    updateThrottle,
+		AuditFetchBudget:  auditFetchBudget,
+		OutputTimezone:    outputTimezone,
+		ServerTiming:      serverTiming,
+		LogUpstreamSizes:  logUpstreamSizes,
+		Dispositions:      dispositions,
 	}, nil
 }
 
@@ -41,3 +387,41 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parse a comma-separated list of integers
+func getEnvInts(key, defaultValue string) ([]int, error) {
+	var values []int
+	for _, raw := range strings.Split(getEnv(key, defaultValue), ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// parse a comma-separated list of status=duration pairs, e.g. "Live=10s,Preview=1m", rejecting unknown statuses
+func getEnvStatusDurations(key, defaultValue string) (map[data.GameStatus]time.Duration, error) {
+	values := make(map[data.GameStatus]time.Duration)
+	raw := getEnv(key, defaultValue)
+	if strings.TrimSpace(raw) == "" {
+		return values, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		name, rawValue, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("missing duration for %q", strings.TrimSpace(pair))
+		}
+		status, err := data.ParseGameStatus(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := time.ParseDuration(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, err
+		}
+		values[status] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/subtle"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// only allow requests carrying the configured API key in the X-API-Key header
+// if no key is configured, protected routes are disabled entirely
+func requireAPIKey(key string, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get("X-API-Key")
+		if key == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(rw, r)
+	}
+}
+
+// send a 103 Early Hints response with the given Link header values before handling the request,
+// so clients can start on the linked resources while the response is prepared
+func earlyHints(links []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		for _, link := range links {
+			rw.Header().Add("Link", link)
+		}
+		rw.WriteHeader(http.StatusEarlyHints)
+		next(rw, r)
+	}
+}
+
+// a token bucket for one client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limits each client IP to a steady rate of requests, allowing short bursts
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rate    float64
+	burst   float64
+	// take client IPs from X-Forwarded-For, which is only safe behind a proxy that sets it
+	trustProxy bool
+	// when idle buckets were last swept out
+	swept time.Time
+	now   func() time.Time
+}
+
+// create a rate limiter allowing rate requests per second per IP, with bursts of up to burst requests
+func newRateLimiter(rate float64, burst int, trustProxy bool) *rateLimiter {
+	return &rateLimiter{
+		buckets:    make(map[string]*bucket),
+		rate:       rate,
+		burst:      float64(burst),
+		trustProxy: trustProxy,
+		now:        time.Now,
+	}
+}
+
+// reject requests from clients over their rate with 429 Too Many Requests, and when to retry
+// a limiter with no rate lets everything through
+func (rl *rateLimiter) limit(next http.HandlerFunc) http.HandlerFunc {
+	if rl.rate <= 0 {
+		return next
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if wait := rl.take(rl.clientIP(r)); wait > 0 {
+			rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(rw, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next(rw, r)
+	}
+}
+
+// take a token from a client's bucket, returning how long to wait for one if it is empty
+func (rl *rateLimiter) take(ip string) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.sweep(now)
+
+	b, exists := rl.buckets[ip]
+	if !exists {
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[ip] = b
+	}
+
+	// refill for the time since the last request, up to the burst
+	b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// forget clients whose buckets have refilled, so the map doesn't grow with every IP ever seen
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.swept) < time.Minute {
+		return
+	}
+	rl.swept = now
+
+	full := time.Duration(rl.burst / rl.rate * float64(time.Second))
+	for ip, b := range rl.buckets {
+		if now.Sub(b.last) > full {
+			delete(rl.buckets, ip)
+		}
+	}
+}
+
+// get the IP a request came from if the proxy is trusted, from Fly-Client-IP (set by Fly's proxy)
+// or else the last X-Forwarded-For entry (the one added by the proxy)
+func (rl *rateLimiter) clientIP(r *http.Request) string {
+	if rl.trustProxy {
+		if fly := strings.TrimSpace(r.Header.Get("Fly-Client-IP")); fly != "" {
+			return fly
+		}
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
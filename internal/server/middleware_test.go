@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAPIKey(t *testing.T) {
+	ok := func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusOK) }
+
+	cases := []struct {
+		name     string
+		key      string
+		provided string
+		expected int
+	}{
+		{"correct key", "secret", "secret", http.StatusOK},
+		{"wrong key", "secret", "guess", http.StatusUnauthorized},
+		{"missing key", "secret", "", http.StatusUnauthorized},
+		{"no key configured", "", "", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/debug/clients", nil)
+		if c.provided != "" {
+			req.Header.Set("X-API-Key", c.provided)
+		}
+		rw := httptest.NewRecorder()
+		requireAPIKey(c.key, ok)(rw, req)
+		assert.Equal(t, c.expected, rw.Code, c.name)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ok := func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusOK) }
+	now := time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC)
+
+	rl := newRateLimiter(0.5, 2, false)
+	rl.now = func() time.Time { return now }
+	handler := rl.limit(ok)
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/initial", nil)
+		req.RemoteAddr = remoteAddr
+		rw := httptest.NewRecorder()
+		handler(rw, req)
+		return rw
+	}
+
+	// the burst is allowed, then the client has to wait two seconds for a token
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5000").Code)
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5001").Code)
+	rw := request("10.0.0.1:5002")
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "2", rw.Header().Get("Retry-After"))
+
+	// other clients have their own bucket
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:5000").Code)
+
+	now = now.Add(time.Second)
+	rw = request("10.0.0.1:5000")
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5000").Code)
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	ok := func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusOK) }
+
+	request := func(rl *rateLimiter, forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/standings", nil)
+		req.RemoteAddr = "192.168.1.1:4000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rw := httptest.NewRecorder()
+		rl.limit(ok)(rw, req)
+		return rw.Code
+	}
+
+	// behind a trusted proxy, clients sharing the proxy's address are limited separately
+	trusted := newRateLimiter(1, 1, true)
+	assert.Equal(t, http.StatusOK, request(trusted, "1.1.1.1, 203.0.113.1"))
+	assert.Equal(t, http.StatusOK, request(trusted, "203.0.113.2"))
+	// only the entry added by the proxy counts, not whatever the client claimed
+	assert.Equal(t, http.StatusTooManyRequests, request(trusted, "9.9.9.9, 203.0.113.1"))
+
+	// otherwise the header is ignored
+	untrusted := newRateLimiter(1, 1, false)
+	assert.Equal(t, http.StatusOK, request(untrusted, "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request(untrusted, "203.0.113.2"))
+}
+
+func TestRateLimitFlyClientIP(t *testing.T) {
+	ok := func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusOK) }
+	request := func(rl *rateLimiter, client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/standings", nil)
+		req.RemoteAddr = "172.16.0.1:4000"
+		req.Header.Set("Fly-Client-IP", client)
+		req.Header.Set("X-Forwarded-For", "172.16.0.2")
+		rw := httptest.NewRecorder()
+		rl.limit(ok)(rw, req)
+		return rw.Code
+	}
+
+	// behind Fly's proxy, clients are told apart by the address it reports for them
+	trusted := newRateLimiter(1, 1, true)
+	assert.Equal(t, http.StatusOK, request(trusted, "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, request(trusted, "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, request(trusted, "203.0.113.1"))
+
+	untrusted := newRateLimiter(1, 1, false)
+	assert.Equal(t, http.StatusOK, request(untrusted, "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request(untrusted, "203.0.113.2"), "the header should be ignored unless the proxy is trusted")
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	ok := func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusOK) }
+	handler := newRateLimiter(0, 0, false).limit(ok)
+
+	for i := 0; i < 100; i++ {
+		rw := httptest.NewRecorder()
+		handler(rw, httptest.NewRequest(http.MethodGet, "/api/games/initial", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+	}
+}
+
+func TestEarlyHints(t *testing.T) {
+	link := "</api/games/update>; rel=preconnect"
+	srv := httptest.NewServer(earlyHints([]string{link}, func(rw http.ResponseWriter, r *http.Request) {
+		io.WriteString(rw, "ok")
+	}))
+	defer srv.Close()
+
+	var hints []int
+	var hintLinks []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			hints = append(hints, code)
+			hintLinks = append(hintLinks, header.Values("Link")...)
+			return nil
+		},
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, []int{http.StatusEarlyHints}, hints)
+	assert.Equal(t, []string{link}, hintLinks)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(body))
+}
@@ -5,22 +5,73 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/claycot/mlb-gameday-api/data"
 	"github.com/claycot/mlb-gameday-api/handlers"
+	"github.com/claycot/mlb-gameday-api/internal/config"
 	"github.com/claycot/mlb-gameday-api/internal/workers"
 )
 
-func Initialize(ctx context.Context, wg *sync.WaitGroup, logger *log.Logger) *http.ServeMux {
+func Initialize(ctx context.Context, wg *sync.WaitGroup, cfg *config.Config, logger *log.Logger) (*http.ServeMux, error) {
 	mux := http.NewServeMux()
 
-	// initialize game store and updates channel
-	gamesStore := &data.GameCache{}
+	// initialize game store, using recorded games instead of the MLB API in replay mode
+	// calls to the MLB API go through a circuit breaker so outages fail fast
+	breaker := data.NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	gamesStore := data.NewGameCache(breaker.Fetch(data.FetchGame), cfg.FetchConcurrency)
+	listGames := breaker.List(data.ListGamesIn(gamesStore.Clock(), cfg.Timezone))
+	if cfg.ReplayDir != "" {
+		replay, err := data.NewReplay(cfg.ReplayDir)
+		if err != nil {
+			return nil, err
+		}
+		logger.Printf("[INFO] Replay mode enabled, serving recorded games from %s", cfg.ReplayDir)
+		gamesStore = data.NewGameCache(replay.FetchGame, cfg.FetchConcurrency)
+		listGames = replay.ListGames
+	}
+
+	// flag editorially featured games
+	gamesStore.SetFeatured(cfg.FeaturedGames)
+
+	// prune games in some detailed states differently, e.g. dropping forfeits right away
+	gamesStore.SetDispositions(cfg.Dispositions)
+
+	// remember removed games for a while, so clients that missed the removal can catch up
+	gamesStore.SetRemovedRetention(cfg.RemovedRetention)
+	gamesStore.SetMissingThreshold(cfg.MissingThreshold)
+	gamesStore.SetLogger(logger)
+	if cfg.LogUpstreamSizes {
+		data.SetUsageLogger(logger)
+	}
+	gamesStore.SetFetchBudget(cfg.AuditFetchBudget)
+	if cfg.PrefetchGames {
+		gamesStore.SetPrefetch(ctx)
+	}
+
+	// final boxscores and scoring plays are immutable, so keep recent ones around
+	boxscores := data.NewBoxscoreCache(nil, cfg.BoxscoreCacheSize)
+	scoring := data.NewScoringCache(nil, cfg.BoxscoreCacheSize)
+
+	// standings are a separate read path from the live games cache
+	standings := data.NewStandingsCache(nil, cfg.StandingsTTL)
+
+	// past team schedules never change, so keep recent lookups around
+	teamSchedules := data.NewTeamScheduleCache(nil, 64, cfg.Timezone)
+	nextGames := data.NewNextGameCache(nil, 64, 5*time.Minute, cfg.Timezone)
+
+	// and neither do the games on past dates
+	datedGames := data.NewDateGamesCache(nil, 64, cfg.Timezone)
+
+	// initialize updates channel
 	updates := make(chan handlers.Update)
-	broadcaster := handlers.NewBroadcaster()
+	broadcaster := handlers.NewBroadcaster(cfg.SSEMaxDrops, cfg.OutputTimezone)
+	health := handlers.NewHealth(logger)
 
-	// use a broadcaster to send updates to all connected clients
+	// use a broadcaster to send updates to all connected clients, until the updates channel is closed
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		for {
 			// wait for updates
 			msg, ok := <-updates
@@ -41,31 +92,114 @@ func Initialize(ctx context.Context, wg *sync.WaitGroup, logger *log.Logger) *ht
 		}
 	}()
 
+	// handlers send their updates through a channel of their own, forwarded to the updates channel like a worker,
+	// so that it is never closed while a request is sending on it
+	requested := make(chan handlers.Update)
+
+	// start background workers
+	// they are tracked separately from wg, so that the updates channel is only closed once none of them can send on it
+	var workersWg sync.WaitGroup
+	workersWg.Add(3)
+	go func() {
+		defer workersWg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update := <-requested:
+				updates <- update
+			}
+		}
+	}()
+	go workers.AuditGames(ctx, cfg, gamesStore, updates, logger, &workersWg)
+	go workers.FindNewGames(ctx, cfg, gamesStore, listGames, updates, health, logger, &workersWg)
+	if cfg.ArchiveDir != "" {
+		workersWg.Add(1)
+		go workers.ArchiveGames(ctx, cfg, gamesStore, logger, &workersWg)
+	}
+
 	// on context cancelation, wait for workers to finish and then close the channel
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		<-ctx.Done()
 		logger.Println("[INFO] Context canceled, waiting for workers to finish...")
 
-		wg.Wait()
+		workersWg.Wait()
 		logger.Println("[INFO] All workers done, closing updates channel")
 		close(updates)
 	}()
 
-	// start background workers
-	wg.Add(2)
-	go workers.AuditGames(ctx, gamesStore, updates, logger, wg)
-	go workers.FindNewGames(ctx, gamesStore, updates, logger, wg)
-
 	// initialize handlers
-	gh := handlers.NewGames(logger)
+	gh := handlers.NewGames(logger, cfg)
+	dh := handlers.NewDebug(logger, cfg, breaker)
+	sh := handlers.NewStandings(logger)
+	th := handlers.NewTeams(logger)
+
+	// the non-streaming endpoints are rate limited per client, streams are long-lived and /healthz is for probes
+	limiter := newRateLimiter(cfg.RateLimit, cfg.RateBurst, cfg.TrustProxy)
 
 	// define routes
-	mux.HandleFunc("/api/games/initial", func(rw http.ResponseWriter, r *http.Request) {
+	initial := func(rw http.ResponseWriter, r *http.Request) {
 		gh.GetInitial(rw, r, gamesStore)
-	})
+	}
+	// clients fetch the initial games and then open the update stream, which can be connected to in the meantime
+	if cfg.EarlyHints {
+		initial = earlyHints([]string{"</api/games/update>; rel=preconnect"}, initial)
+	}
+	// GET routes also serve HEAD requests, which caches use to check freshness
+	mux.HandleFunc("GET /api/games/initial", limiter.limit(initial))
+	mux.HandleFunc("GET /api/games/export.csv", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetExport(rw, r, gamesStore)
+	}))
 	mux.HandleFunc("/api/games/update", func(rw http.ResponseWriter, r *http.Request) {
-		gh.GetUpdates(rw, r, broadcaster)
+		gh.GetUpdates(rw, r, gamesStore, broadcaster)
+	})
+	mux.HandleFunc("GET /api/games", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetGamesByDates(rw, r, datedGames)
+	}))
+	mux.HandleFunc("GET /api/games/{id}/update", func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetGameUpdates(rw, r, gamesStore, broadcaster)
 	})
+	// tracking takes up a slot in the cache, so only trusted callers can start it
+	mux.HandleFunc("POST /api/games/{id}/track", limiter.limit(requireAPIKey(cfg.APIKey, func(rw http.ResponseWriter, r *http.Request) {
+		gh.TrackGame(rw, r, gamesStore, requested)
+	})))
+	mux.HandleFunc("POST /api/games/{id}/refresh", limiter.limit(requireAPIKey(cfg.APIKey, func(rw http.ResponseWriter, r *http.Request) {
+		gh.RefreshGame(rw, r, gamesStore, requested)
+	})))
+	mux.HandleFunc("GET /api/games/{id}/raw", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetRawGame(rw, r, gamesStore)
+	}))
+	mux.HandleFunc("GET /api/games/{id}/boxscore", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetBoxscore(rw, r, boxscores)
+	}))
+	mux.HandleFunc("GET /api/games/{id}/scoring", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetScoring(rw, r, scoring)
+	}))
+	mux.HandleFunc("GET /api/badge", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		gh.GetBadge(rw, r, gamesStore)
+	}))
+	mux.HandleFunc("GET /api/standings", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		sh.GetStandings(rw, r, standings)
+	}))
+	mux.HandleFunc("GET /api/teams/{teamId}/games", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		th.GetTeamGames(rw, r, teamSchedules)
+	}))
+	mux.HandleFunc("GET /api/teams/{teamId}/next", limiter.limit(func(rw http.ResponseWriter, r *http.Request) {
+		th.GetNextGame(rw, r, nextGames)
+	}))
+	mux.HandleFunc("/healthz", health.GetHealth)
+	mux.HandleFunc("/api/debug/clients", limiter.limit(requireAPIKey(cfg.APIKey, func(rw http.ResponseWriter, r *http.Request) {
+		dh.GetClients(rw, r, broadcaster)
+	})))
+	mux.HandleFunc("/api/debug/status", limiter.limit(requireAPIKey(cfg.APIKey, func(rw http.ResponseWriter, r *http.Request) {
+		dh.GetStatus(rw, r, gamesStore)
+	})))
+	mux.HandleFunc("GET /api/debug/games", limiter.limit(requireAPIKey(cfg.APIKey, func(rw http.ResponseWriter, r *http.Request) {
+		dh.GetUpdateCounts(rw, r, gamesStore)
+	})))
+	mux.HandleFunc("GET /api/debug/upstream", limiter.limit(requireAPIKey(cfg.APIKey, dh.GetUpstream)))
 
-	return mux
+	return mux, nil
 }
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// every goroutine started by Initialize should be tracked by wg and exit once ctx is canceled
+func TestInitializeShutdownLeavesNoGoroutines(t *testing.T) {
+	ignore := goleak.IgnoreCurrent()
+
+	cfg := &config.Config{
+		ReplayDir:         t.TempDir(),
+		ArchiveDir:        t.TempDir(),
+		DateOffsets:       []int{0},
+		SportIDs:          []int{1},
+		FetchConcurrency:  1,
+		Timezone:          time.UTC,
+		AuditInterval:     10 * time.Millisecond,
+		FindInterval:      10 * time.Millisecond,
+		ArchiveInterval:   10 * time.Millisecond,
+		ArchiveRetention:  1,
+		BoxscoreCacheSize: 1,
+		StandingsTTL:      time.Minute,
+		BreakerThreshold:  1,
+		BreakerCooldown:   time.Minute,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	_, err := Initialize(ctx, &wg, cfg, log.New(io.Discard, "", 0))
+	assert.NoError(t, err)
+
+	// let the workers run a few cycles before shutting down
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for goroutines to finish")
+	}
+
+	goleak.VerifyNone(t, ignore)
+}
+
+// tracking takes up a slot in the cache, so it needs the API key like the other write endpoints
+func TestTrackGameRequiresAPIKey(t *testing.T) {
+	cfg := &config.Config{
+		ReplayDir:         t.TempDir(),
+		DateOffsets:       []int{0},
+		SportIDs:          []int{1},
+		FetchConcurrency:  1,
+		Timezone:          time.UTC,
+		AuditInterval:     time.Hour,
+		FindInterval:      time.Hour,
+		BoxscoreCacheSize: 1,
+		StandingsTTL:      time.Minute,
+		BreakerThreshold:  1,
+		BreakerCooldown:   time.Minute,
+		APIKey:            "secret",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+	mux, err := Initialize(ctx, &wg, cfg, log.New(io.Discard, "", 0))
+	assert.NoError(t, err)
+
+	track := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/123/track", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, track(""))
+	assert.Equal(t, http.StatusUnauthorized, track("wrong"))
+	assert.NotEqual(t, http.StatusUnauthorized, track("secret"))
+}
@@ -2,12 +2,19 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/claycot/mlb-gameday-api/handlers"
 	"github.com/claycot/mlb-gameday-api/internal/config"
 	"github.com/rs/cors"
 )
@@ -16,35 +23,68 @@ type Server struct {
 	addr    string
 	handler http.Handler
 	logger  *log.Logger
+	wg      *sync.WaitGroup
+	// unix socket to listen on instead of addr, if set
+	socketPath string
+	// certificate and key files to serve HTTPS with, plaintext is served unless both are set
+	tlsCert string
+	tlsKey  string
+	// address to redirect plain HTTP requests to HTTPS from, if set, and the port they are sent to
+	redirectAddr string
+	httpsPort    int
 }
 
 func New(ctx context.Context, wg *sync.WaitGroup, cfg *config.Config, logger *log.Logger) (*Server, error) {
 	// initialize routes, passing wg for worker daemons
-	router := Initialize(ctx, wg, logger)
+	router, err := Initialize(ctx, wg, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
 
 	// configure CORS usuing the config
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins: cfg.AllowedOrigins,
-		AllowedMethods: []string{"GET"},
-		AllowedHeaders: []string{"Content-Type"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
 	})
 
-	return &Server{
-		addr:    fmt.Sprintf("%s:%d", cfg.Hostname, cfg.Port),
-		handler: corsMiddleware.Handler(router),
-		logger:  logger,
-	}, nil
+	// optionally report where each JSON response spent its time, for debugging latency from the browser
+	var handler http.Handler = router
+	if cfg.ServerTiming {
+		handler = handlers.ServerTiming(handler)
+	}
+	// optionally send the timestamps in JSON responses in a zone of the deployer's choosing
+	if cfg.OutputTimezone != nil {
+		handler = handlers.OutputTimezone(handler, cfg.OutputTimezone, logger)
+	}
+
+	srv := &Server{
+		addr:       fmt.Sprintf("%s:%d", cfg.Hostname, cfg.Port),
+		handler:    corsMiddleware.Handler(handler),
+		logger:     logger,
+		wg:         wg,
+		socketPath: cfg.SocketPath,
+		tlsCert:    cfg.TLSCert,
+		tlsKey:     cfg.TLSKey,
+		httpsPort:  cfg.Port,
+	}
+	if cfg.TLSRedirectPort != 0 {
+		srv.redirectAddr = fmt.Sprintf("%s:%d", cfg.Hostname, cfg.TLSRedirectPort)
+	}
+	return srv, nil
 }
 
 func (s *Server) Run(ctx context.Context) error {
-	s.logger.Printf("[INFO] Starting server on %s", s.addr)
-
+	// requests share the server's context, so open SSE streams end on shutdown instead of holding it up
 	server := &http.Server{
-		Addr:    s.addr,
-		Handler: s.handler,
+		Addr:        s.addr,
+		Handler:     s.handler,
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
 
+	s.wg.Add(1)
 	go func() {
+		defer s.wg.Done()
 		<-ctx.Done()
 		s.logger.Println("[INFO] Shutting down server...")
 		ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -55,5 +95,74 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}()
 
-	return server.ListenAndServe()
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	// serve HTTPS when a certificate is configured, which also negotiates HTTP/2 with clients that support it
+	if s.tlsCert != "" && s.tlsKey != "" {
+		if s.redirectAddr != "" {
+			s.redirect(ctx)
+		}
+		s.logger.Println("[INFO] Serving HTTPS")
+		return server.ServeTLS(listener, s.tlsCert, s.tlsKey)
+	}
+
+	return server.Serve(listener)
+}
+
+// redirect plain HTTP requests on redirectAddr to HTTPS until ctx is canceled
+func (s *Server) redirect(ctx context.Context) {
+	redirect := &http.Server{
+		Addr:    s.redirectAddr,
+		Handler: redirectToHTTPS(s.httpsPort),
+	}
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		<-ctx.Done()
+		redirect.Close()
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.logger.Printf("[INFO] Redirecting HTTP on %s to HTTPS", s.redirectAddr)
+		if err := redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Printf("[ERROR] HTTP redirect server failed: %v\r\n", err)
+		}
+	}()
+}
+
+// permanently redirect a request to the same host and path over HTTPS on the given port
+func redirectToHTTPS(port int) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+		http.Redirect(rw, r, target.String(), http.StatusMovedPermanently)
+	}
+}
+
+// listen on the unix socket if one is configured, otherwise on host:port
+func (s *Server) listen() (net.Listener, error) {
+	if s.socketPath == "" {
+		s.logger.Printf("[INFO] Starting server on %s", s.addr)
+		return net.Listen("tcp", s.addr)
+	}
+
+	// a socket file left behind by an unclean exit would block the listen
+	if err := os.Remove(s.socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket %s: %w", s.socketPath, err)
+	}
+
+	// the listener unlinks the socket file when it is closed on shutdown
+	s.logger.Printf("[INFO] Starting server on unix socket %s", s.socketPath)
+	return net.Listen("unix", s.socketPath)
 }
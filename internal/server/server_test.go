@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunOnUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+
+	var wg sync.WaitGroup
+	srv := &Server{
+		handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			io.WriteString(rw, "ok")
+		}),
+		logger:     log.New(io.Discard, "", 0),
+		wg:         &wg,
+		socketPath: socketPath,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(socketPath)
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "server should create the socket")
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+	resp, err := client.Get("http://unix/healthz")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "ok", string(body))
+	}
+	client.CloseIdleConnections()
+
+	cancel()
+	assert.ErrorIs(t, <-errs, http.ErrServerClosed)
+	wg.Wait()
+
+	_, err = os.Stat(socketPath)
+	assert.ErrorIs(t, err, os.ErrNotExist, "socket file should be removed on shutdown")
+}
+
+// write a self-signed certificate for localhost to dir, returning the paths of the certificate and key
+func writeSelfSignedCert(t *testing.T, dir string) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certPath, keyPath, pool
+}
+
+func TestRunWithTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, pool := writeSelfSignedCert(t, dir)
+	socketPath := filepath.Join(dir, "api.sock")
+
+	var wg sync.WaitGroup
+	srv := &Server{
+		handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			io.WriteString(rw, "ok")
+		}),
+		logger:     log.New(io.Discard, "", 0),
+		wg:         &wg,
+		socketPath: socketPath,
+		tlsCert:    certPath,
+		tlsKey:     keyPath,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(socketPath)
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "server should create the socket")
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+			TLSClientConfig:   &tls.Config{RootCAs: pool},
+			ForceAttemptHTTP2: true,
+		},
+	}
+	resp, err := client.Get("https://localhost/healthz")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "ok", string(body))
+		assert.NotNil(t, resp.TLS, "response should come over TLS")
+		assert.Equal(t, 2, resp.ProtoMajor, "HTTP/2 should be negotiated over TLS")
+	}
+	client.CloseIdleConnections()
+
+	cancel()
+	assert.ErrorIs(t, <-errs, http.ErrServerClosed)
+	wg.Wait()
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	cases := []struct {
+		host     string
+		port     int
+		expected string
+	}{
+		{"example.com", 443, "https://example.com/api/games/initial?pretty=1"},
+		{"example.com:80", 443, "https://example.com/api/games/initial?pretty=1"},
+		{"example.com:8081", 8443, "https://example.com:8443/api/games/initial?pretty=1"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/initial?pretty=1", nil)
+		req.Host = c.host
+		rw := httptest.NewRecorder()
+		redirectToHTTPS(c.port)(rw, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, rw.Code, c.host)
+		assert.Equal(t, c.expected, rw.Header().Get("Location"), c.host)
+	}
+}
@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+)
+
+// archives are named by the time they were written, so they sort oldest first
+const (
+	archivePrefix     = "games-"
+	archiveSuffix     = ".json.gz"
+	archiveTimeFormat = "20060102T150405Z"
+)
+
+// periodically write a gzipped snapshot of the games store to the archive directory, keeping the newest few
+func ArchiveGames(ctx context.Context, cfg *config.Config, gamesStore *data.GameCache, logger *log.Logger, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(cfg.ArchiveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		// if context is canceled, shut down the worker
+		case <-ctx.Done():
+			logger.Println("[INFO] Shutting down ArchiveGames worker")
+			return
+		// on each tick, write a snapshot and rotate out old ones
+		case <-ticker.C:
+			if err := writeArchive(cfg.ArchiveDir, gamesStore, gamesStore.Clock().Now()); err != nil {
+				logger.Printf("[ERROR] Failed to write archive: %v\r\n", err)
+				continue
+			}
+			if err := pruneArchives(cfg.ArchiveDir, cfg.ArchiveRetention); err != nil {
+				logger.Printf("[ERROR] Failed to prune archives: %v\r\n", err)
+			}
+		}
+	}
+}
+
+// write the ready games to a gzipped, timestamped file in the directory
+func writeArchive(dir string, gamesStore *data.GameCache, now time.Time) error {
+	games, err := data.GetInitialGames(gamesStore, data.SortDefault)
+	if err != nil {
+		return err
+	}
+	gamesJson, err := games.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a partial archive is never left behind
+	name := filepath.Join(dir, archivePrefix+now.UTC().Format(archiveTimeFormat)+archiveSuffix)
+	file, err := os.Create(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	zw := gzip.NewWriter(file)
+	if _, err := zw.Write(gamesJson); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+// list the names of the archives in the directory, oldest first
+func listArchives(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), archivePrefix) && strings.HasSuffix(entry.Name(), archiveSuffix) {
+			archives = append(archives, entry.Name())
+		}
+	}
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// read the games in the newest archive in the directory, nil if there are none yet
+func readLatestArchive(dir string) (*data.Games, error) {
+	archives, err := listArchives(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Open(filepath.Join(dir, archives[len(archives)-1]))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var games data.Games
+	if err := json.NewDecoder(zr).Decode(&games); err != nil {
+		return nil, err
+	}
+	return &games, nil
+}
+
+// delete all but the newest retain archives in the directory
+func pruneArchives(dir string, retain int) error {
+	archives, err := listArchives(dir)
+	if err != nil {
+		return err
+	}
+	if len(archives) <= retain {
+		return nil
+	}
+
+	for _, name := range archives[:len(archives)-retain] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package workers
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchivesAreWrittenAndRotated(t *testing.T) {
+	dir := t.TempDir()
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{ID: 1, Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	_, err := gamesStore.Discover(data.ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	gamesStore.GetOne(context.Background(), data.MLBKey(1))
+
+	start := time.Date(2024, 4, 1, 19, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, writeArchive(dir, gamesStore, start.Add(time.Duration(i)*time.Minute)))
+		assert.NoError(t, pruneArchives(dir, 3))
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{
+		"games-20240401T190200Z.json.gz",
+		"games-20240401T190300Z.json.gz",
+		"games-20240401T190400Z.json.gz",
+	}, names, "only the newest archives should be kept")
+
+	// each archive holds the serialized cache
+	file, err := os.Open(filepath.Join(dir, names[2]))
+	assert.NoError(t, err)
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+
+	var games data.Games
+	assert.NoError(t, json.Unmarshal(raw, &games))
+	assert.Len(t, games.Data, 1)
+	assert.Equal(t, uint32(1), games.Data[0].ID)
+}
+
+func TestReadLatestArchive(t *testing.T) {
+	dir := t.TempDir()
+	games, err := readLatestArchive(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Nil(t, games, "a missing archive directory has no archives to read")
+
+	outs := uint8(0)
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{ID: 1, Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Outs: outs, Status: data.Status{General: data.StatusLive}}}, nil
+	}, 0)
+	_, err = gamesStore.Discover(data.ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	gamesStore.GetOne(context.Background(), data.MLBKey(1))
+
+	start := time.Date(2024, 4, 1, 19, 0, 0, 0, time.UTC)
+	assert.NoError(t, writeArchive(dir, gamesStore, start))
+	outs = 2
+	_, err = gamesStore.Fetch(context.Background(), data.MLBKey(1))
+	assert.NoError(t, err)
+	assert.NoError(t, writeArchive(dir, gamesStore, start.Add(time.Minute)))
+
+	games, err = readLatestArchive(dir)
+	assert.NoError(t, err)
+	if assert.NotNil(t, games) && assert.Len(t, games.Data, 1) {
+		assert.Equal(t, uint8(2), games.Data[0].State.Outs, "the newest archive should be read")
+	}
+}
+
+// a game in a status the MLB API added later is archived as-is, and shouldn't keep the rest of the archive from being read
+func TestArchiveRoundTripWithUnknownStatus(t *testing.T) {
+	dir := t.TempDir()
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		id, general := uint32(1), data.StatusLive
+		if link == "2" {
+			id, general = 2, data.GameStatus("Other")
+		}
+		return data.Game{ID: id, Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: general}}}, nil
+	}, 0)
+	for id := uint32(1); id <= 2; id++ {
+		_, err := gamesStore.Discover(data.ScheduledGame{ID: id, Link: strconv.Itoa(int(id))})
+		assert.NoError(t, err)
+		gamesStore.GetOne(context.Background(), data.MLBKey(id))
+	}
+
+	assert.NoError(t, writeArchive(dir, gamesStore, time.Date(2024, 4, 1, 19, 0, 0, 0, time.UTC)))
+	games, err := readLatestArchive(dir)
+	assert.NoError(t, err)
+	if assert.NotNil(t, games) && assert.Len(t, games.Data, 2) {
+		statuses := []data.GameStatus{games.Data[0].State.Status.General, games.Data[1].State.Status.General}
+		assert.ElementsMatch(t, []data.GameStatus{data.StatusLive, "Other"}, statuses)
+	}
+}
@@ -3,63 +3,130 @@ package workers
 import (
 	"context"
 	"log"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/claycot/mlb-gameday-api/data"
 	"github.com/claycot/mlb-gameday-api/handlers"
+	"github.com/claycot/mlb-gameday-api/internal/config"
 )
 
 // run the audit games function on the games store and send updates as SSE events
-func AuditGames(ctx context.Context, gamesStore *data.GameCache, updates chan handlers.Update, logger *log.Logger, wg *sync.WaitGroup) {
+func AuditGames(ctx context.Context, cfg *config.Config, gamesStore *data.GameCache, updates chan handlers.Update, logger *log.Logger, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	// update games every 30 seconds
-	ticker := time.NewTicker(30 * time.Second)
+	// update games every 30 seconds (by default)
+	ticker := time.NewTicker(cfg.AuditInterval)
 	defer ticker.Stop()
 
+	// optionally send full snapshots so clients can resync (a nil channel never fires)
+	var snapshots <-chan time.Time
+	if cfg.SnapshotInterval > 0 {
+		snapshotTicker := time.NewTicker(cfg.SnapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshots = snapshotTicker.C
+	}
+
+	// start times of games already announced as starting soon
+	announced := make(map[data.GameKey]time.Time)
+	// notable events already announced, per game
+	// those in the newest archive were announced before a restart, so they aren't announced again
+	announcedNotables := make(map[data.GameKey]map[string]bool)
+	if cfg.ArchiveDir != "" {
+		archived, err := readLatestArchive(cfg.ArchiveDir)
+		if err != nil {
+			logger.Printf("[ERROR] Failed to read archive for announced notables: %v\r\n", err)
+		} else if archived != nil {
+			findNewNotables(archived, announcedNotables)
+		}
+	}
+	// when an update was last sent for each game, and the games whose updates are held back by the throttle
+	lastSent := make(map[data.GameKey]time.Time)
+	held := make(map[data.GameKey]bool)
+
 	for {
 		select {
 		// if context is canceled, shut down the worker
 		case <-ctx.Done():
 			logger.Println("[INFO] Shutting down AuditGames worker")
 			return
+		// on each snapshot tick, send every ready game
+		case <-snapshots:
+			sendSnapshot("snapshot", gamesStore, updates, logger)
 		// on each tick, audit the games store
 		case <-ticker.C:
-			updated, removed, failed := gamesStore.Audit(ctx)
+			// with batching on, everything from the cycle is sent together in one update once it's done
+			var batch []handlers.Update
+			send := func(update handlers.Update) {
+				if cfg.BatchUpdates {
+					batch = append(batch, update)
+				} else {
+					updates <- update
+				}
+			}
+
+			// the audit reports the games it changed, removed and failed to fetch
+			// the ready games before it are only kept to tell which games scored
+			before, err := data.GetInitialGames(gamesStore, data.SortDefault)
+			if err != nil {
+				logger.Printf("[ERROR] Failed to get games before audit: %v\r\n", err)
+				continue
+			}
+			updatedKeys, removedKeys, failedKeys := gamesStore.Audit(ctx)
+			removed, failed := data.KeyIDs(removedKeys), data.KeyIDs(failedKeys)
+			after, err := data.GetInitialGames(gamesStore, data.SortDefault)
+			if err != nil {
+				logger.Printf("[ERROR] Failed to get games after audit: %v\r\n", err)
+				continue
+			}
+			due := throttleUpdates(after, updatedKeys, cfg.UpdateThrottle, lastSent, held, gamesStore.Clock().Now())
 
-			// process updated games by pulling the new information
+			// take each updated game from the post-audit state, skipping any that aren't ready to be sent
+			isDue := make(map[data.GameKey]bool, len(due))
+			for _, key := range due {
+				isDue[key] = true
+			}
+			var updated []*data.Game
+			for _, game := range after.Data {
+				if isDue[game.Key()] {
+					updated = append(updated, game)
+				}
+			}
+
+			// process updated games by sending their new information
 			if len(updated) > 0 {
-				logger.Printf("[INFO] Updated games: %v", updated)
+				logger.Printf("[INFO] Updated games: %v", gameIDs(updated))
 				// create a wrapper for the games
 				update := &data.Games{
 					Metadata: data.Metadata{
-						Timestamp: time.Now(),
+						Timestamp: gamesStore.Clock().Now(),
 					},
-					Data: make([]*data.Game, len(updated)),
-				}
-
-				// retrieve and set information for each game
-				var wgGetGames sync.WaitGroup
-				for i, id := range updated {
-					wgGetGames.Add(1)
-					go func(writeIndex int, gameId uint32) {
-						defer wgGetGames.Done()
-						game, valid := gamesStore.GetOne(ctx, gameId)
-						if valid {
-							update.Data[writeIndex] = &game
-						}
-						// TODO: what about errors? uncertain 😇
-					}(i, id)
+					Data: updated,
 				}
-				wgGetGames.Wait()
 
 				// marshal to json and return
 				updateJson, err := update.ToJSON()
 				if err != nil {
 					logger.Printf("[ERROR] Failed to marshal updates to json: %v\r\n", err)
 				} else {
-					updates <- handlers.Update{Event: "update", Data: string(updateJson)}
+					send(handlers.Update{Event: "update", Data: string(updateJson), IDs: gameKeys(updated)})
+				}
+			}
+			// games where a run scored also get a score event, for clients that only follow the score
+			if scored := data.ScoreChanges(before, after); len(scored) > 0 {
+				score := &data.Games{
+					Metadata: data.Metadata{
+						Timestamp: gamesStore.Clock().Now(),
+					},
+					Data: scored,
+				}
+				// marshal to json and return
+				scoreJson, err := score.ToJSON()
+				if err != nil {
+					logger.Printf("[ERROR] Failed to marshal score changes to json: %v\r\n", err)
+				} else {
+					send(handlers.Update{Event: "score", Data: string(scoreJson), IDs: gameKeys(scored)})
 				}
 			}
 			// process removed games by outputting their IDs
@@ -67,7 +134,7 @@ func AuditGames(ctx context.Context, gamesStore *data.GameCache, updates chan ha
 				logger.Printf("[INFO] Removed games: %v", removed)
 				remove := &data.GameIDs{
 					Metadata: data.Metadata{
-						Timestamp: time.Now(),
+						Timestamp: gamesStore.Clock().Now(),
 					},
 					Data: make([]*uint32, len(removed)),
 				}
@@ -79,7 +146,7 @@ func AuditGames(ctx context.Context, gamesStore *data.GameCache, updates chan ha
 				if err != nil {
 					logger.Printf("[ERROR] Failed to marshal updates to json: %v\r\n", err)
 				} else {
-					updates <- handlers.Update{Event: "remove", Data: string(updateJson)}
+					send(handlers.Update{Event: "remove", Data: string(updateJson), IDs: removedKeys})
 				}
 			}
 			// process failed games by outputting their IDs
@@ -87,7 +154,7 @@ func AuditGames(ctx context.Context, gamesStore *data.GameCache, updates chan ha
 				logger.Printf("[ERROR] Failed to get info on games: %v", failed)
 				fail := &data.GameIDs{
 					Metadata: data.Metadata{
-						Timestamp: time.Now(),
+						Timestamp: gamesStore.Clock().Now(),
 					},
 					Data: make([]*uint32, len(failed)),
 				}
@@ -99,9 +166,206 @@ func AuditGames(ctx context.Context, gamesStore *data.GameCache, updates chan ha
 				if err != nil {
 					logger.Printf("[ERROR] Failed to marshal updates to json: %v\r\n", err)
 				} else {
-					updates <- handlers.Update{Event: "fail", Data: string(updateJson)}
+					send(handlers.Update{Event: "fail", Data: string(updateJson), IDs: failedKeys})
+				}
+			}
+			// announce preview games that are about to start
+			if cfg.StartingSoonLead > 0 {
+				soon := findStartingSoon(after, announced, cfg.StartingSoonLead, gamesStore.Clock().Now())
+				if len(soon) > 0 {
+					logger.Printf("[INFO] Games starting soon: %d", len(soon))
+					startingSoon := &data.Games{
+						Metadata: data.Metadata{
+							Timestamp: gamesStore.Clock().Now(),
+						},
+						Data: soon,
+					}
+					// marshal to json and return
+					updateJson, err := startingSoon.ToJSON()
+					if err != nil {
+						logger.Printf("[ERROR] Failed to marshal starting soon to json: %v\r\n", err)
+					} else {
+						send(handlers.Update{Event: "starting_soon", Data: string(updateJson), IDs: gameKeys(soon)})
+					}
+				}
+			}
+
+			// announce rare events like immaculate innings as they happen
+			forgetNotables(announcedNotables, gamesStore)
+			if notable := findNewNotables(after, announcedNotables); len(notable) > 0 {
+				logger.Printf("[INFO] Notable events: %d", len(notable))
+				notables := &data.Notables{
+					Metadata: data.Metadata{
+						Timestamp: gamesStore.Clock().Now(),
+					},
+					Data: notable,
+				}
+				// marshal to json and return
+				notableJson, err := notables.ToJSON()
+				if err != nil {
+					logger.Printf("[ERROR] Failed to marshal notable events to json: %v\r\n", err)
+				} else {
+					var ids []data.GameKey
+					for _, n := range notable {
+						if !slices.Contains(ids, n.Key()) {
+							ids = append(ids, n.Key())
+						}
+					}
+					send(handlers.Update{Event: "notable", Data: string(notableJson), IDs: ids})
 				}
 			}
+
+			if len(batch) > 0 {
+				batchUpdate, err := handlers.NewBatch(batch, gamesStore.Clock().Now())
+				if err != nil {
+					logger.Printf("[ERROR] Failed to batch updates: %v\r\n", err)
+				} else {
+					updates <- *batchUpdate
+				}
+			}
+		}
+	}
+}
+
+// send the full state of all ready games as the given event, e.g. a periodic snapshot
+func sendSnapshot(event string, gamesStore *data.GameCache, updates chan handlers.Update, logger *log.Logger) {
+	snapshot, err := data.GetInitialGames(gamesStore, data.SortDefault)
+	if err != nil {
+		logger.Printf("[ERROR] Failed to get games for snapshot: %v\r\n", err)
+		return
+	}
+
+	snapshotJson, err := snapshot.ToJSON()
+	if err != nil {
+		logger.Printf("[ERROR] Failed to marshal snapshot to json: %v\r\n", err)
+		return
+	}
+	updates <- handlers.Update{Event: event, Data: string(snapshotJson), IDs: gameKeys(snapshot.Data)}
+}
+
+// list the ids of the given games
+func gameIDs(games []*data.Game) []uint32 {
+	ids := make([]uint32, len(games))
+	for i, game := range games {
+		ids[i] = game.ID
+	}
+	return ids
+}
+
+// list the cache keys of the given games
+func gameKeys(games []*data.Game) []data.GameKey {
+	keys := make([]data.GameKey, len(games))
+	for i, game := range games {
+		keys[i] = game.Key()
+	}
+	return keys
+}
+
+// find preview games starting within the lead time that haven't been announced yet, marking them announced
+// a game whose start time changes is forgotten, so it is announced again once its new start is near
+func findStartingSoon(games *data.Games, announced map[data.GameKey]time.Time, lead time.Duration, now time.Time) []*data.Game {
+	var soon []*data.Game
+	present := make(map[data.GameKey]bool, len(games.Data))
+
+	for _, game := range games.Data {
+		key := game.Key()
+		present[key] = true
+		start := game.State.Status.StartTime.DateTime
+
+		// forget announcements for games that were rescheduled
+		if announcedStart, exists := announced[key]; exists && !announcedStart.Equal(start) {
+			delete(announced, key)
+		}
+
+		if game.State.Status.General != data.StatusPreview {
+			continue
+		}
+		if _, exists := announced[key]; exists {
+			continue
+		}
+
+		untilStart := start.Sub(now)
+		if untilStart > 0 && untilStart <= lead {
+			announced[key] = start
+			soon = append(soon, game)
+		}
+	}
+
+	// forget games that are no longer tracked
+	for key := range announced {
+		if !present[key] {
+			delete(announced, key)
+		}
+	}
+
+	return soon
+}
+
+// pick the updated games whose updates can be sent now, holding back games updated within their status' throttle interval
+// held games are sent with their latest state once the interval has passed, even if they haven't changed again
+// games that leave the cache are forgotten
+func throttleUpdates(games *data.Games, updated []data.GameKey, intervals map[data.GameStatus]time.Duration, lastSent map[data.GameKey]time.Time, held map[data.GameKey]bool, now time.Time) []data.GameKey {
+	if len(intervals) == 0 {
+		return updated
+	}
+	for _, key := range updated {
+		held[key] = true
+	}
+
+	var due []data.GameKey
+	present := make(map[data.GameKey]bool, len(games.Data))
+	for _, game := range games.Data {
+		key := game.Key()
+		present[key] = true
+		if !held[key] {
+			continue
+		}
+		if sent, exists := lastSent[key]; exists && now.Sub(sent) < intervals[game.State.Status.General] {
+			continue
+		}
+		delete(held, key)
+		lastSent[key] = now
+		due = append(due, key)
+	}
+
+	for key := range lastSent {
+		if !present[key] {
+			delete(lastSent, key)
+		}
+	}
+	for key := range held {
+		if !present[key] {
+			delete(held, key)
+		}
+	}
+	return due
+}
+
+// find notable events in the games that haven't been announced yet, marking them announced
+func findNewNotables(games *data.Games, announced map[data.GameKey]map[string]bool) []data.GameNotable {
+	var found []data.GameNotable
+	for _, game := range games.Data {
+		key := game.Key()
+		for _, notable := range game.State.Notables {
+			if announced[key][notable.Key()] {
+				continue
+			}
+			if announced[key] == nil {
+				announced[key] = make(map[string]bool)
+			}
+			announced[key][notable.Key()] = true
+			found = append(found, data.GameNotable{Game: game, Notable: notable})
+		}
+	}
+	return found
+}
+
+// forget the announced notables of games that are no longer tracked
+// games still being fetched are tracked, so notables restored for them on startup are kept until they are ready
+func forgetNotables(announced map[data.GameKey]map[string]bool, gamesStore *data.GameCache) {
+	for key := range announced {
+		if _, err := gamesStore.GetLink(key); err != nil {
+			delete(announced, key)
 		}
 	}
 }
@@ -0,0 +1,281 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/api_data"
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/handlers"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// the keys of MLB games with the given ids
+func mlbKeys(ids ...uint32) []data.GameKey {
+	keys := make([]data.GameKey, len(ids))
+	for i, id := range ids {
+		keys[i] = data.MLBKey(id)
+	}
+	return keys
+}
+
+func TestAuditGamesSendsSnapshots(t *testing.T) {
+	cfg := &config.Config{AuditInterval: time.Hour, SnapshotInterval: 50 * time.Millisecond}
+	updates := make(chan handlers.Update, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go AuditGames(ctx, cfg, data.NewGameCache(nil, 0), updates, log.New(io.Discard, "", 0), &wg)
+
+	time.Sleep(175 * time.Millisecond)
+	cancel()
+	wg.Wait()
+	close(updates)
+
+	snapshots := 0
+	for update := range updates {
+		assert.Equal(t, "snapshot", update.Event)
+		snapshots++
+	}
+	assert.GreaterOrEqual(t, snapshots, 2, "snapshots should arrive at the configured interval")
+	assert.LessOrEqual(t, snapshots, 4, "snapshots should not arrive faster than the configured interval")
+}
+
+func TestAuditGamesSnapshotsOffByDefault(t *testing.T) {
+	cfg := &config.Config{AuditInterval: time.Hour}
+	updates := make(chan handlers.Update, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go AuditGames(ctx, cfg, data.NewGameCache(nil, 0), updates, log.New(io.Discard, "", 0), &wg)
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	assert.Len(t, updates, 0, "no snapshots should be sent when disabled")
+}
+
+// a preview game should be announced once as it crosses the lead window, and again if rescheduled
+func TestFindStartingSoon(t *testing.T) {
+	now := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	lead := 30 * time.Minute
+	announced := make(map[data.GameKey]time.Time)
+
+	game := &data.Game{ID: 1}
+	game.State.Status.General = data.StatusPreview
+	game.State.Status.StartTime.DateTime = now.Add(45 * time.Minute)
+	games := &data.Games{Data: []*data.Game{game}}
+
+	assert.Empty(t, findStartingSoon(games, announced, lead, now), "game outside the lead window should not be announced")
+
+	now = now.Add(25 * time.Minute)
+	soon := findStartingSoon(games, announced, lead, now)
+	assert.Len(t, soon, 1, "game inside the lead window should be announced")
+	assert.Empty(t, findStartingSoon(games, announced, lead, now), "game should only be announced once")
+
+	// a delayed start should allow a fresh announcement once the new start time is near
+	game.State.Status.StartTime.DateTime = now.Add(time.Hour)
+	assert.Empty(t, findStartingSoon(games, announced, lead, now))
+	now = now.Add(40 * time.Minute)
+	assert.Len(t, findStartingSoon(games, announced, lead, now), 1, "rescheduled game should be announced again")
+
+	// games that leave the cache are forgotten
+	findStartingSoon(&data.Games{}, announced, lead, now)
+	assert.Empty(t, announced)
+}
+
+// a live game should get at most one update per throttle window, with changes in between coalesced into the next
+func TestThrottleUpdates(t *testing.T) {
+	now := time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC)
+	intervals := map[data.GameStatus]time.Duration{data.StatusLive: 10 * time.Second}
+	lastSent := make(map[data.GameKey]time.Time)
+	held := make(map[data.GameKey]bool)
+
+	live := &data.Game{ID: 1}
+	live.State.Status.General = data.StatusLive
+	preview := &data.Game{ID: 2}
+	preview.State.Status.General = data.StatusPreview
+	games := &data.Games{Data: []*data.Game{live, preview}}
+
+	both := []data.GameKey{data.MLBKey(1), data.MLBKey(2)}
+	assert.Equal(t, both, throttleUpdates(games, both, intervals, lastSent, held, now))
+
+	// changes within the window are held back, unless the game's status isn't throttled
+	sent := 0
+	for i := 1; i <= 3; i++ {
+		due := throttleUpdates(games, both, intervals, lastSent, held, now.Add(time.Duration(i)*3*time.Second))
+		assert.Equal(t, []data.GameKey{data.MLBKey(2)}, due)
+		sent += len(due)
+	}
+	assert.Equal(t, 3, sent, "only the unthrottled game should have been sent during the window")
+
+	// once the window has passed, the held game is sent with its latest state even without another change
+	assert.Equal(t, []data.GameKey{data.MLBKey(1)}, throttleUpdates(games, nil, intervals, lastSent, held, now.Add(11*time.Second)))
+	assert.Empty(t, throttleUpdates(games, nil, intervals, lastSent, held, now.Add(12*time.Second)), "nothing is held after it's sent")
+
+	// games that leave the cache are forgotten
+	throttleUpdates(&data.Games{}, nil, intervals, lastSent, held, now.Add(13*time.Second))
+	assert.Empty(t, lastSent)
+	assert.Empty(t, held)
+}
+
+// with batching on, everything that happened in an audit cycle should arrive as one update
+func TestAuditGamesBatchesCycle(t *testing.T) {
+	clock := data.NewFakeClock(time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC))
+	// each game changes on every fetch, except game 3, which fails after its first
+	var fetches [4]atomic.Int32
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		id, _ := strconv.Atoi(link)
+		n := fetches[id].Add(1)
+		if link == "3" && n > 1 {
+			return data.Game{}, errors.New("upstream is down")
+		}
+		return data.Game{
+			ID:       uint32(id),
+			Link:     link,
+			Metadata: data.Metadata{Ready: true, Timestamp: clock.Now()},
+			State:    data.State{Outs: uint8(n % 3), Status: data.Status{General: data.StatusLive}},
+		}, nil
+	}, 0)
+	gamesStore.SetClock(clock)
+	for _, id := range []uint32{1, 2, 3} {
+		_, err := gamesStore.Discover(data.ScheduledGame{ID: id, Link: strconv.Itoa(int(id))})
+		assert.NoError(t, err)
+		gamesStore.GetOne(context.Background(), data.MLBKey(id))
+	}
+	clock.Advance(time.Minute)
+
+	cfg := &config.Config{AuditInterval: 20 * time.Millisecond, BatchUpdates: true}
+	updates := make(chan handlers.Update, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go AuditGames(ctx, cfg, gamesStore, updates, log.New(io.Discard, "", 0), &wg)
+
+	var update handlers.Update
+	select {
+	case update = <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("no update was sent")
+	}
+	cancel()
+	wg.Wait()
+
+	assert.Equal(t, handlers.BatchEvent, update.Event, "the cycle should be sent as a single update")
+	assert.ElementsMatch(t, mlbKeys(1, 2, 3), update.IDs)
+
+	var batch struct {
+		Data []struct {
+			Event string          `json:"event"`
+			Data  json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(update.Data), &batch))
+	var events []string
+	for _, event := range batch.Data {
+		events = append(events, event.Event)
+	}
+	assert.Equal(t, []string{"update", "fail"}, events)
+}
+
+// a notable event should be announced once, and again only if its game leaves the cache and comes back
+func TestFindNewNotables(t *testing.T) {
+	announced := make(map[data.GameKey]map[string]bool)
+	game := &data.Game{ID: 1}
+	game.State.Notables = []data.Notable{{Kind: data.NotableNoHitter, Team: "home", Description: "The Home Team haven't allowed a hit through 7 innings"}}
+	games := &data.Games{Data: []*data.Game{game}}
+
+	found := findNewNotables(games, announced)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, uint32(1), found[0].ID)
+	}
+
+	// the no-hitter going another inning is the same notable
+	game.State.Notables[0].Description = "The Home Team haven't allowed a hit through 8 innings"
+	assert.Empty(t, findNewNotables(games, announced), "a notable should only be announced once")
+
+	game.State.Notables = append(game.State.Notables, data.Notable{Kind: data.NotableCycle, Team: "away", PlayerID: 30, Inning: 9})
+	assert.Len(t, findNewNotables(games, announced), 1, "new notables in the game should be announced")
+
+	forgetNotables(announced, data.NewGameCache(nil, 0))
+	assert.Empty(t, announced, "games that leave the cache should be forgotten")
+}
+
+// notables restored from before a restart aren't announced again, and are forgotten with their games
+func TestRestoredNotablesAreNotAnnouncedAgain(t *testing.T) {
+	inProgress := data.Notable{Kind: data.NotableNoHitter, Team: "home", Description: "The Home Team haven't allowed a hit through 7 innings"}
+	complete := data.Notable{Kind: data.NotableNoHitter, Team: "home", Description: "The Home Team threw a no-hitter", Complete: true}
+	game := func(notables ...data.Notable) *data.Game {
+		return &data.Game{ID: 1, State: data.State{Notables: notables}}
+	}
+
+	announced := make(map[data.GameKey]map[string]bool)
+	findNewNotables(&data.Games{Data: []*data.Game{game(inProgress)}}, announced)
+
+	assert.Empty(t, findNewNotables(&data.Games{Data: []*data.Game{game(inProgress)}}, announced), "a restored notable should not be announced again")
+	found := findNewNotables(&data.Games{Data: []*data.Game{game(inProgress, complete)}}, announced)
+	if assert.Len(t, found, 1, "the completed no-hitter should be announced") {
+		assert.Equal(t, complete, found[0].Notable)
+	}
+
+	// game 1 is still tracked, game 2 never was
+	gamesStore := data.NewGameCache(nil, 0)
+	_, err := gamesStore.Discover(data.ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	findNewNotables(&data.Games{Data: []*data.Game{{ID: 2, State: data.State{Notables: []data.Notable{inProgress}}}}}, announced)
+	forgetNotables(announced, gamesStore)
+	assert.Contains(t, announced, data.MLBKey(1), "tracked games should keep their notables, even before they are ready")
+	assert.NotContains(t, announced, data.MLBKey(2), "untracked games should be forgotten")
+}
+
+// games the audit prunes are announced as removed
+func TestAuditGamesSendsAuditedRemovals(t *testing.T) {
+	clock := data.NewFakeClock(time.Date(2024, 7, 4, 19, 5, 0, 0, time.UTC))
+	start := clock.Now()
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{
+			ID:       1,
+			Link:     link,
+			Metadata: data.Metadata{Ready: true, Timestamp: clock.Now()},
+			State:    data.State{Status: data.Status{General: data.StatusFinal, StartTime: api_data.Datetime{DateTime: start}}},
+		}, nil
+	}, 0)
+	gamesStore.SetClock(clock)
+	_, err := gamesStore.Discover(data.ScheduledGame{ID: 1, Link: "1"})
+	assert.NoError(t, err)
+	gamesStore.GetOne(context.Background(), data.MLBKey(1))
+	clock.Advance(16 * time.Hour)
+
+	cfg := &config.Config{AuditInterval: 20 * time.Millisecond}
+	updates := make(chan handlers.Update, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go AuditGames(ctx, cfg, gamesStore, updates, log.New(io.Discard, "", 0), &wg)
+
+	var update handlers.Update
+	select {
+	case update = <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("no update was sent")
+	}
+	cancel()
+	wg.Wait()
+
+	assert.Equal(t, "remove", update.Event, "the pruned game should be announced as removed")
+	assert.Equal(t, mlbKeys(1), update.IDs)
+}
@@ -3,24 +3,43 @@ package workers
 import (
 	"context"
 	"log"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/claycot/mlb-gameday-api/data"
 	"github.com/claycot/mlb-gameday-api/handlers"
+	"github.com/claycot/mlb-gameday-api/internal/config"
 )
 
 // fetch new games and update gamesStore
-func FindNewGames(ctx context.Context, gamesStore *data.GameCache, updates chan handlers.Update, logger *log.Logger, wg *sync.WaitGroup) {
+func FindNewGames(ctx context.Context, cfg *config.Config, gamesStore *data.GameCache, listGames data.ListFunc, updates chan handlers.Update, health *handlers.Health, logger *log.Logger, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	// update games every 15 minutes
-	ticker := time.NewTicker(15 * time.Minute)
+	// update games every 15 minutes (by default)
+	ticker := time.NewTicker(cfg.FindInterval)
 	defer ticker.Stop()
 
-	// run immediately on creation
+	// run immediately on creation, discovering games and then warming them all,
+	// so clients connecting during the warm-up can show its progress instead of an empty board
 	logger.Println("[INFO] FindNewGames: running initial fetch")
-	updateGames(ctx, gamesStore, updates, logger)
+	found := discoverGames(ctx, cfg, gamesStore, listGames, logger)
+	failed := gamesStore.Warm(ctx, func(ready, total int) {
+		sendWarmup(ready, total, gamesStore.Clock(), updates, logger)
+	})
+	if len(failed) > 0 {
+		logger.Printf("[ERROR] FindNewGames: failed to warm games: %v", failed)
+	}
+	// an empty cache from a failed listing isn't warm, so readiness waits for a pass that listed everything
+	if found.complete {
+		health.SetReady()
+		logger.Println("[INFO] FindNewGames: cache warmed, server ready")
+	} else {
+		logger.Printf("[WARN] FindNewGames: initial discovery was incomplete, server not ready\r\n")
+	}
+
+	// then send everything that was warmed
+	sendSnapshot("initial", gamesStore, updates, logger)
 
 	for {
 		select {
@@ -31,71 +50,228 @@ func FindNewGames(ctx context.Context, gamesStore *data.GameCache, updates chan
 		// on each tick, fetch new games, add them to game store, and retrieve their info
 		case <-ticker.C:
 			logger.Println("[INFO] FindNewGames: finding new games")
-			updateGames(ctx, gamesStore, updates, logger)
+			complete := updateGames(ctx, cfg, gamesStore, listGames, updates, logger)
+			if complete && !health.Ready() {
+				health.SetReady()
+				logger.Println("[INFO] FindNewGames: discovery completed, server ready")
+			}
 		}
 	}
 }
 
-func updateGames(ctx context.Context, gamesStore *data.GameCache, updates chan handlers.Update, logger *log.Logger) {
-	var added []uint32
-	// fetch a list of all games on today's date and their links
-	gameIds, gameLinks, err := data.ListGamesByDate(ctx, logger, "")
-	if err != nil {
-		logger.Printf("[ERROR] Added 0 games: %v\r\n", err)
-		return
-	}
-
-	// add new games to the cache
-	for i, id := range gameIds {
-		// if !discovered, game already existed or cache is full (full cache throws err)
-		discovered, err := gamesStore.Discover(id, gameLinks[i])
+// discover new games, fetch their information, and announce them along with games rescheduled or gone from the schedule,
+// returning whether every listing succeeded
+func updateGames(ctx context.Context, cfg *config.Config, gamesStore *data.GameCache, listGames data.ListFunc, updates chan handlers.Update, logger *log.Logger) bool {
+	found := discoverGames(ctx, cfg, gamesStore, listGames, logger)
+	added, removed := found.added, data.KeyIDs(found.removed)
 
-		// cache may be full
-		// TODO: handle this error more smarter
-		if err != nil {
-			return
+	// let clients move postponed games over to their new ids
+	if len(found.rescheduled) > 0 {
+		logger.Printf("[INFO] Rescheduled games: %v", found.rescheduled)
+		reschedule := &data.Reschedules{
+			Metadata: data.Metadata{
+				Timestamp: gamesStore.Clock().Now(),
+			},
+			Data: found.rescheduled,
+		}
+		ids := make([]data.GameKey, 0, 2*len(found.rescheduled))
+		for _, r := range found.rescheduled {
+			ids = append(ids, data.KeyOf(r.SportID, r.ID), data.KeyOf(r.SportID, r.NewID))
 		}
+		rescheduleJson, err := reschedule.ToJSON()
+		if err == nil {
+			updates <- handlers.Update{Event: "reschedule", Data: string(rescheduleJson), IDs: ids}
+		} else {
+			logger.Printf("[ERROR] Failed to marshal reschedule to json: %v\r\n", err)
+		}
+	}
 
-		// if the game is new, queue it for fetching
-		if discovered {
-			added = append(added, id)
+	if len(removed) > 0 {
+		logger.Printf("[INFO] Removed games missing from the schedule: %v", removed)
+		remove := &data.GameIDs{
+			Metadata: data.Metadata{
+				Timestamp: gamesStore.Clock().Now(),
+			},
+			Data: make([]*uint32, len(removed)),
+		}
+		for i := range removed {
+			remove.Data[i] = &removed[i]
+		}
+		removeJson, err := remove.ToJSON()
+		if err == nil {
+			updates <- handlers.Update{Event: "remove", Data: string(removeJson), IDs: found.removed}
+		} else {
+			logger.Printf("[ERROR] Failed to marshal remove to json: %v\r\n", err)
 		}
 	}
 
 	// if games were added, update their information and notify channel
 	if len(added) > 0 {
-		logger.Printf("[INFO] Added games: %v", added)
+		logger.Printf("[INFO] Added games: %v", data.KeyIDs(added))
 		add := &data.Games{
 			Metadata: data.Metadata{
-				Timestamp: time.Now(),
+				Timestamp: gamesStore.Clock().Now(),
 			},
 			Data: make([]*data.Game, len(added)),
 		}
 
 		// fetch information on new games
 		var wgGameInfo sync.WaitGroup
-		for i, id := range added {
+		for i, key := range added {
 			wgGameInfo.Add(1)
-			go func(writeIndex int, gameId uint32) {
+			go func(writeIndex int, gameKey data.GameKey) {
 				defer wgGameInfo.Done()
-				game, valid := gamesStore.GetOne(ctx, gameId)
+				game, valid := gamesStore.GetOne(ctx, gameKey)
 				if valid {
 					add.Data[writeIndex] = &game
 				} else {
-					logger.Printf("[ERROR] Failed to get information on game %d", gameId)
+					logger.Printf("[ERROR] Failed to get information on game %d", gameKey.ID)
 				}
-			}(i, id)
+			}(i, key)
 		}
 		wgGameInfo.Wait()
 
+		// games that couldn't be fetched are left out rather than sent as null
+		fetched := make([]data.GameKey, 0, len(added))
+		for i, game := range add.Data {
+			if game != nil {
+				fetched = append(fetched, added[i])
+			}
+		}
+		add.Data = slices.DeleteFunc(add.Data, func(game *data.Game) bool { return game == nil })
+
 		// marshal into json and send
 		addJson, err := add.ToJSON()
 		if err == nil {
-			updates <- handlers.Update{Event: "add", Data: string(addJson)}
+			updates <- handlers.Update{Event: "add", Data: string(addJson), IDs: fetched}
 		} else {
 			logger.Printf("[ERROR] Failed to marshal add to json: %v\r\n", err)
 		}
 	} else {
 		logger.Println("[INFO] Added 0 games")
 	}
+	return found.complete
+}
+
+// what changed in the cache from a schedule listing
+type discovery struct {
+	// games newly added to the cache
+	added []data.GameKey
+	// games removed for having been missing from the schedule
+	removed []data.GameKey
+	// postponed games removed because they were listed again under a new id
+	rescheduled []data.Reschedule
+	// whether every sport was listed on every date
+	complete bool
+}
+
+// list the games of each tracked sport on each tracked date, add new ones to the cache,
+// and reconcile the cache with the listing if it is complete
+func discoverGames(ctx context.Context, cfg *config.Config, gamesStore *data.GameCache, listGames data.ListFunc, logger *log.Logger) discovery {
+	var found discovery
+
+	// fetch a list of all games of each tracked sport on each tracked date and their links,
+	// along with the days after them loaded as upcoming previews
+	var games []data.ScheduledGame
+	var dates []string
+	complete := true
+	latest := slices.Max(cfg.DateOffsets)
+	offsets := slices.Clone(cfg.DateOffsets)
+	for days := 1; days <= cfg.PreviewDays; days++ {
+		offsets = append(offsets, latest+days)
+	}
+	for _, offset := range offsets {
+		dateGames, date, ok := listDate(ctx, cfg, gamesStore, listGames, offset, logger)
+		games = append(games, dateGames...)
+		dates = append(dates, date)
+		complete = complete && ok
+	}
+	latest = slices.Max(offsets)
+
+	// on an off-day, look ahead for the next day with games so the board has upcoming games to show
+	if len(games) == 0 && cfg.LookAheadDays > 0 {
+		for days := 1; days <= cfg.LookAheadDays && len(games) == 0; days++ {
+			dateGames, date, ok := listDate(ctx, cfg, gamesStore, listGames, latest+days, logger)
+			games = dateGames
+			dates = append(dates, date)
+			complete = complete && ok
+		}
+		if len(games) > 0 {
+			logger.Printf("[INFO] No games on any tracked date, showing the next %d games", len(games))
+		}
+	}
+	found.complete = complete
+	if len(games) == 0 {
+		logger.Println("[INFO] Added 0 games: no games listed on any tracked date")
+		return found
+	}
+
+	// add new games to the cache
+	for _, game := range games {
+		// if !discovered, game already existed or cache is full (full cache throws err)
+		discovered, err := gamesStore.Discover(game)
+
+		// cache may be full
+		// TODO: handle this error more smarter
+		if err != nil {
+			break
+		}
+
+		// if the game is new, queue it for fetching
+		if discovered {
+			found.added = append(found.added, game.Key())
+		}
+	}
+
+	// games missing from a listing that didn't fail anywhere may have been rescheduled or be gone,
+	// an empty listing is more likely a glitch
+	if complete {
+		found.rescheduled = gamesStore.Rescheduled(games, found.added)
+
+		listed := make([]data.GameKey, len(games))
+		for i, game := range games {
+			listed[i] = game.Key()
+		}
+		found.removed = gamesStore.Reconcile(listed, dates)
+	}
+	return found
+}
+
+// list the games of each tracked sport on the date offset days from today, stamped with that date,
+// and return the date and whether every sport was listed
+func listDate(ctx context.Context, cfg *config.Config, gamesStore *data.GameCache, listGames data.ListFunc, offset int, logger *log.Logger) ([]data.ScheduledGame, string, bool) {
+	var games []data.ScheduledGame
+	complete := true
+	dateString := data.ScheduleDate(gamesStore.Clock(), cfg.Timezone, offset)
+	for _, sportID := range cfg.SportIDs {
+		dateGames, err := listGames(ctx, logger, sportID, dateString)
+		if err != nil {
+			logger.Printf("[ERROR] Failed to list sport %d games on %s: %v\r\n", sportID, dateString, err)
+			complete = false
+			continue
+		}
+		for i := range dateGames {
+			dateGames[i].Date = dateString
+		}
+		games = append(games, dateGames...)
+	}
+	return games, dateString, complete
+}
+
+// send how many of the games discovered on startup are ready so far, stamped with the time on the clock
+func sendWarmup(ready, total int, clock data.Clock, updates chan handlers.Update, logger *log.Logger) {
+	warmup := &data.WarmupProgress{
+		Metadata: data.Metadata{
+			Timestamp: clock.Now(),
+			Ready:     ready == total,
+		},
+		Data: data.Progress{Ready: ready, Total: total},
+	}
+
+	warmupJson, err := warmup.ToJSON()
+	if err != nil {
+		logger.Printf("[ERROR] Failed to marshal warmup to json: %v\r\n", err)
+		return
+	}
+	updates <- handlers.Update{Event: "warmup", Data: string(warmupJson)}
 }
@@ -0,0 +1,385 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/claycot/mlb-gameday-api/data"
+	"github.com/claycot/mlb-gameday-api/handlers"
+	"github.com/claycot/mlb-gameday-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// games from yesterday and today should coexist in the cache
+func TestUpdateGamesMultipleDates(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	cfg := &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{-1, 0}, Timezone: time.UTC}
+	yesterday := data.ScheduleDate(data.RealClock, cfg.Timezone, -1)
+	today := data.ScheduleDate(data.RealClock, cfg.Timezone, 0)
+
+	schedules := map[string][]data.ScheduledGame{
+		yesterday: {{ID: 1, Link: "yesterday-1"}},
+		today:     {{ID: 2, Link: "today-2"}, {ID: 3, Link: "today-3"}},
+	}
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		return schedules[dateString], nil
+	}
+
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}}, nil
+	}, 0)
+	updates := make(chan handlers.Update, 1)
+
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+
+	games, err := gamesStore.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, games, 3, "games from both dates should be cached")
+
+	update := <-updates
+	assert.Equal(t, "add", update.Event)
+}
+
+// the server should only report ready once the initial games have been fetched
+func TestFindNewGamesReadyAfterWarmup(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		return []data.ScheduledGame{{ID: 1, Link: "1"}, {ID: 2, Link: "2"}}, nil
+	}
+
+	// hold every fetch until released
+	release := make(chan struct{})
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		<-release
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}}, nil
+	}, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	health := handlers.NewHealth(logger)
+	updates := make(chan handlers.Update, 16)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go FindNewGames(ctx, &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC, FindInterval: time.Hour}, gamesStore, listGames, updates, health, logger, &wg)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, health.Ready(), "server should not be ready while games are being fetched")
+
+	close(release)
+	assert.Eventually(t, health.Ready, time.Second, 10*time.Millisecond, "server should be ready after warm-up")
+
+	games, err := gamesStore.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, games, 2, "all games should be ready once the server is")
+
+	cancel()
+	wg.Wait()
+}
+
+// a failed listing leaves nothing to warm, so the server should wait for a discovery pass that lists everything
+func TestFindNewGamesReadyAfterCompleteDiscovery(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	var mu sync.Mutex
+	listed := false
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !listed {
+			return nil, errors.New("unexpected response from MLB API: 503 Service Unavailable")
+		}
+		return []data.ScheduledGame{{ID: 1, Link: "1"}}, nil
+	}
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}}, nil
+	}, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	health := handlers.NewHealth(logger)
+	updates := make(chan handlers.Update, 16)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go FindNewGames(ctx, &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC, FindInterval: 10 * time.Millisecond}, gamesStore, listGames, updates, health, logger, &wg)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, health.Ready(), "server should not be ready until a discovery pass completes")
+
+	mu.Lock()
+	listed = true
+	mu.Unlock()
+	assert.Eventually(t, health.Ready, time.Second, 10*time.Millisecond, "server should be ready once the schedule is listed")
+
+	games, err := gamesStore.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, games, 1, "games from the completed pass should be ready once the server is")
+
+	cancel()
+	wg.Wait()
+}
+
+// clients connecting during the warm-up should see its progress, followed by every game once it's done
+func TestFindNewGamesWarmupProgress(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		return []data.ScheduledGame{{ID: 1, Link: "1"}, {ID: 2, Link: "2"}, {ID: 3, Link: "3"}}, nil
+	}
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusPreview}}}, nil
+	}, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan handlers.Update, 16)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go FindNewGames(ctx, &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC, FindInterval: time.Hour}, gamesStore, listGames, updates, handlers.NewHealth(logger), logger, &wg)
+
+	for ready := 1; ready <= 3; ready++ {
+		update := <-updates
+		if assert.Equal(t, "warmup", update.Event, "progress should come before the games") {
+			var warmup data.WarmupProgress
+			assert.NoError(t, json.Unmarshal([]byte(update.Data), &warmup))
+			assert.Equal(t, data.Progress{Ready: ready, Total: 3}, warmup.Data)
+		}
+	}
+
+	update := <-updates
+	if assert.Equal(t, "initial", update.Event, "the warm-up should end with every game") {
+		var initial data.Games
+		assert.NoError(t, json.Unmarshal([]byte(update.Data), &initial))
+		assert.True(t, initial.Metadata.Ready, "the initial games should be ready")
+		assert.Len(t, initial.Data, 3)
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+// games from different sports should coexist in the cache and carry their sport
+func TestUpdateGamesMultipleSports(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	const tripleA = 11
+	cfg := &config.Config{SportIDs: []int{data.SportID, tripleA}, DateOffsets: []int{0}, Timezone: time.UTC}
+	schedules := map[int][]data.ScheduledGame{
+		data.SportID: {{ID: 1, Link: "mlb-1", SportID: data.SportID}},
+		// the same id showing up for another sport is a different game
+		tripleA: {{ID: 2, Link: "aaa-2", SportID: tripleA}, {ID: 1, Link: "aaa-1", SportID: tripleA}},
+	}
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		return schedules[sportID], nil
+	}
+
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}}, nil
+	}, 0)
+	updates := make(chan handlers.Update, 1)
+
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+
+	mlb, valid := gamesStore.GetOne(context.Background(), data.MLBKey(1))
+	assert.True(t, valid)
+	assert.Equal(t, data.SportID, mlb.SportID)
+	assert.Equal(t, "mlb-1", mlb.Link, "the same id from another sport should not replace the game")
+
+	for id, link := range map[uint32]string{1: "aaa-1", 2: "aaa-2"} {
+		aaa, valid := gamesStore.GetOne(context.Background(), data.GameKey{SportID: tripleA, ID: id})
+		assert.True(t, valid)
+		assert.Equal(t, tripleA, aaa.SportID)
+		assert.Equal(t, link, aaa.Link)
+	}
+}
+
+// on an off-day, the next day with games should be loaded if looking ahead is enabled
+func TestUpdateGamesLooksAheadOnOffDay(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	tomorrow := data.ScheduleDate(data.RealClock, time.UTC, 1)
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		if dateString == tomorrow {
+			return []data.ScheduledGame{{ID: 1, Link: "tomorrow-1"}}, nil
+		}
+		return []data.ScheduledGame{}, nil
+	}
+	newStore := func() *data.GameCache {
+		return data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+			return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusPreview}}}, nil
+		}, 0)
+	}
+
+	cfg := &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC}
+	gamesStore := newStore()
+	updateGames(context.Background(), cfg, gamesStore, listGames, make(chan handlers.Update, 1), logger)
+	games, err := gamesStore.GetAll()
+	assert.NoError(t, err)
+	assert.Empty(t, games, "tomorrow's games should not be loaded by default")
+
+	cfg.LookAheadDays = 3
+	gamesStore = newStore()
+	updates := make(chan handlers.Update, 1)
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+
+	game, valid := gamesStore.GetOne(context.Background(), data.MLBKey(1))
+	assert.True(t, valid, "tomorrow's games should be loaded on an off-day")
+	assert.Equal(t, data.StatusPreview, game.State.Status.General)
+	assert.Equal(t, "add", (<-updates).Event)
+}
+
+// games from the next days should be loaded as previews alongside today's
+func TestUpdateGamesLoadsPreviewDays(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	cfg := &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC, PreviewDays: 2}
+	schedules := map[string][]data.ScheduledGame{
+		data.ScheduleDate(data.RealClock, cfg.Timezone, 0): {{ID: 1, Link: "today-1"}},
+		data.ScheduleDate(data.RealClock, cfg.Timezone, 1): {{ID: 2, Link: "tomorrow-2"}},
+		data.ScheduleDate(data.RealClock, cfg.Timezone, 2): {{ID: 3, Link: "two-days-3"}},
+		data.ScheduleDate(data.RealClock, cfg.Timezone, 3): {{ID: 4, Link: "three-days-4"}},
+	}
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		return schedules[dateString], nil
+	}
+
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusPreview}}}, nil
+	}, 0)
+	updates := make(chan handlers.Update, 1)
+
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+
+	update := <-updates
+	assert.Equal(t, "add", update.Event)
+	assert.ElementsMatch(t, mlbKeys(1, 2, 3), update.IDs, "games from today and the next two days should be loaded")
+}
+
+// a game briefly missing from the schedule should be kept, and only removed once it stays missing
+func TestUpdateGamesRemovesGamesMissingFromSchedule(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	cfg := &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC}
+	schedule := []data.ScheduledGame{{ID: 1, Link: "1"}, {ID: 2, Link: "2"}}
+	listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+		return schedule, nil
+	}
+
+	gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+		return data.Game{Link: link, Metadata: data.Metadata{Ready: true}, State: data.State{Status: data.Status{General: data.StatusPreview}}}, nil
+	}, 0)
+	gamesStore.SetMissingThreshold(3)
+	updates := make(chan handlers.Update, 4)
+
+	tracked := func() []string {
+		games, err := gamesStore.GetAll()
+		assert.NoError(t, err)
+		var links []string
+		for _, game := range games {
+			links = append(links, game.Link)
+		}
+		return links
+	}
+
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+	assert.Equal(t, "add", (<-updates).Event)
+	assert.ElementsMatch(t, []string{"1", "2"}, tracked())
+
+	// missing once, then listed again, starts the count over
+	schedule = schedule[:1]
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+	schedule = append(schedule, data.ScheduledGame{ID: 2, Link: "2"})
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+	assert.ElementsMatch(t, []string{"1", "2"}, tracked(), "a game missing once should be kept")
+
+	schedule = schedule[:1]
+	for i := 0; i < 2; i++ {
+		updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+	}
+	assert.ElementsMatch(t, []string{"1", "2"}, tracked(), "a game missing twice should be kept")
+	assert.Empty(t, updates)
+
+	updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+	assert.Equal(t, []string{"1"}, tracked(), "a game missing three times should be removed")
+	update := <-updates
+	assert.Equal(t, "remove", update.Event)
+	assert.Equal(t, mlbKeys(2), update.IDs)
+}
+
+// a postponed game listed again under a new id should be announced as rescheduled, not left behind,
+// whether or not the MLB API marked it postponed first
+func TestUpdateGamesDetectsReschedule(t *testing.T) {
+	for name, postponed := range map[string]data.Status{
+		"still scheduled": {General: data.StatusPreview, Detailed: "Scheduled"},
+		"postponed":       {General: data.StatusFinal, Detailed: "Postponed"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			logger := log.New(io.Discard, "", 0)
+
+			cfg := &config.Config{SportIDs: []int{data.SportID}, DateOffsets: []int{0}, Timezone: time.UTC}
+			schedule := []data.ScheduledGame{
+				{ID: 1, Link: "1", AwayTeam: "Away Team", HomeTeam: "Home Team"},
+				{ID: 2, Link: "2", AwayTeam: "Other Away", HomeTeam: "Other Home"},
+			}
+			listGames := func(ctx context.Context, logger *log.Logger, sportID int, dateString string) ([]data.ScheduledGame, error) {
+				return schedule, nil
+			}
+
+			status := data.Status{General: data.StatusPreview, Detailed: "Scheduled"}
+			gamesStore := data.NewGameCache(func(ctx context.Context, link string) (data.Game, error) {
+				for _, sg := range schedule {
+					if sg.Link == link {
+						return data.Game{
+							ID:       sg.ID,
+							Link:     link,
+							Metadata: data.Metadata{Ready: true},
+							State: data.State{
+								Teams:  data.Teams{Away: data.Team{Info: data.Info{Name: sg.AwayTeam}}, Home: data.Team{Info: data.Info{Name: sg.HomeTeam}}},
+								Status: status,
+							},
+						}, nil
+					}
+				}
+				return data.Game{}, data.ErrGameNotFound
+			}, 0)
+			gamesStore.SetMissingThreshold(3)
+			updates := make(chan handlers.Update, 4)
+
+			updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+			assert.Equal(t, "add", (<-updates).Event)
+
+			// game 1 may be marked postponed before it is listed again as game 5
+			status = postponed
+			_, err := gamesStore.Fetch(context.Background(), data.MLBKey(1))
+			assert.NoError(t, err)
+			status = data.Status{General: data.StatusPreview, Detailed: "Scheduled"}
+			schedule = []data.ScheduledGame{
+				{ID: 2, Link: "2", AwayTeam: "Other Away", HomeTeam: "Other Home"},
+				{ID: 5, Link: "5", AwayTeam: "Away Team", HomeTeam: "Home Team"},
+			}
+			updateGames(context.Background(), cfg, gamesStore, listGames, updates, logger)
+
+			reschedule := <-updates
+			assert.Equal(t, "reschedule", reschedule.Event)
+			assert.Equal(t, mlbKeys(1, 5), reschedule.IDs)
+			var payload data.Reschedules
+			assert.NoError(t, json.Unmarshal([]byte(reschedule.Data), &payload))
+			assert.Equal(t, []data.Reschedule{{ID: 1, NewID: 5, SportID: data.SportID}}, payload.Data)
+
+			add := <-updates
+			assert.Equal(t, "add", add.Event)
+			assert.Equal(t, mlbKeys(5), add.IDs)
+
+			_, tracked := gamesStore.GetOne(context.Background(), data.MLBKey(1))
+			assert.False(t, tracked, "the postponed game should be removed")
+			_, tracked = gamesStore.GetOne(context.Background(), data.MLBKey(2))
+			assert.True(t, tracked, "other games should be left alone")
+		})
+	}
+}